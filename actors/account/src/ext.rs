@@ -0,0 +1,15 @@
+use fvm_ipld_encoding::tuple::*;
+use fvm_shared::address::Address;
+
+pub mod init {
+    use super::*;
+
+    pub const CHANGE_ACCOUNT_ADDRESS_METHOD: u64 = 4;
+
+    /// Init actor ChangeAccountAddress Params
+    #[derive(Serialize_tuple, Deserialize_tuple)]
+    pub struct ChangeAccountAddressParams {
+        pub old_address: Address,
+        pub new_address: Address,
+    }
+}
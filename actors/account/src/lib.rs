@@ -2,23 +2,31 @@
 // SPDX-License-Identifier: Apache-2.0, MIT
 
 use fvm_ipld_encoding::ipld_block::IpldBlock;
-use fvm_shared::address::Protocol;
+use fvm_shared::address::{Address, Protocol};
 use fvm_shared::crypto::signature::SignatureType::{Secp256k1, BLS};
 use fvm_shared::crypto::signature::{Signature, SignatureType};
+use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
+use fvm_shared::version::NetworkVersion;
 use fvm_shared::{MethodNum, METHOD_CONSTRUCTOR};
 use num_derive::FromPrimitive;
 
-use fil_actors_runtime::builtin::singletons::SYSTEM_ACTOR_ADDR;
+use fil_actors_runtime::builtin::singletons::{INIT_ACTOR_ADDR, SYSTEM_ACTOR_ADDR};
 use fil_actors_runtime::runtime::{ActorCode, Runtime};
-use fil_actors_runtime::{actor_dispatch, ActorDowncast, FIRST_EXPORTED_METHOD_NUMBER};
+use fil_actors_runtime::{
+    actor_dispatch, extract_send_result, ActorContext, ActorDowncast, FIRST_EXPORTED_METHOD_NUMBER,
+};
 use fil_actors_runtime::{actor_error, ActorError};
-use types::{AuthenticateMessageReturn, ConstructorParams, PubkeyAddressReturn};
+use num_traits::Zero;
+use types::{
+    AuthenticateMessageReturn, ChangePubkeyParams, ConstructorParams, PubkeyAddressReturn,
+};
 
 use crate::types::AuthenticateMessageParams;
 
 pub use self::state::State;
 
+pub mod ext;
 mod state;
 pub mod testing;
 pub mod types;
@@ -26,6 +34,11 @@ pub mod types;
 #[cfg(feature = "fil-actor")]
 fil_actors_runtime::wasm_trampoline!(Actor);
 
+/// The network version from which an account's public key may be changed.
+/// Account key rotation activates with network version 24; actors running at network
+/// version 23 (v14) keep the previous behaviour.
+pub const CHANGE_PUBKEY_NETWORK_VERSION: NetworkVersion = NetworkVersion::V24;
+
 /// Account actor methods available
 #[derive(FromPrimitive)]
 #[repr(u64)]
@@ -35,6 +48,7 @@ pub enum Method {
     // Deprecated in v10
     // AuthenticateMessage = 3,
     AuthenticateMessageExported = frc42_dispatch::method_hash!("AuthenticateMessage"),
+    ChangePubkeyExported = frc42_dispatch::method_hash!("ChangePubkey"),
 }
 
 /// Account Actor
@@ -73,14 +87,10 @@ impl Actor {
         rt.validate_immediate_caller_accept_any()?;
         let st: State = rt.state()?;
         let address = st.address;
-        let sig_type: SignatureType = match address.protocol() {
-            Protocol::Secp256k1 => Secp256k1,
-            Protocol::BLS => BLS,
-            protocol => {
-                return Err(actor_error!(illegal_state;
-                    "account address must use BLS or SECP protocol, got {}", protocol));
-            }
-        };
+        let sig_type = signature_type(&address).ok_or_else(|| {
+            actor_error!(illegal_state;
+                "account address must use BLS or SECP protocol, got {}", address.protocol())
+        })?;
         let sig = Signature { sig_type, bytes: params.signature };
         rt.verify_signature(&sig, &address, &params.message).map_err(|e| {
             e.downcast_default(
@@ -92,6 +102,57 @@ impl Actor {
         Ok(AuthenticateMessageReturn { authenticated: true })
     }
 
+    /// Rotates the public key controlling this account, preserving its ID address.
+    /// Must be invoked by the account itself, i.e. by a message signed with the current key.
+    /// The signature must be made by the new key over the account's ID address bytes.
+    /// The init actor's address map is updated so that the new key address resolves to this
+    /// account. The old key address continues to resolve to this account too, so it can't be
+    /// used to create a new account under the old key, nor be rotated back to.
+    /// Not available before CHANGE_PUBKEY_NETWORK_VERSION.
+    pub fn change_pubkey(rt: &impl Runtime, params: ChangePubkeyParams) -> Result<(), ActorError> {
+        let receiver = rt.message().receiver();
+        rt.validate_immediate_caller_is(std::iter::once(&receiver))?;
+        if rt.network_version() < CHANGE_PUBKEY_NETWORK_VERSION {
+            return Err(actor_error!(forbidden;
+                "changing public key not allowed before network version {}",
+                CHANGE_PUBKEY_NETWORK_VERSION));
+        }
+
+        let new_address = params.new_address;
+        let sig_type = signature_type(&new_address).ok_or_else(|| {
+            actor_error!(illegal_argument;
+                "new address must use BLS or SECP protocol, got {}", new_address.protocol())
+        })?;
+        let st: State = rt.state()?;
+        if st.address == new_address {
+            return Err(actor_error!(illegal_argument; "new address {} is unchanged", new_address));
+        }
+
+        let sig = Signature { sig_type, bytes: params.signature };
+        rt.verify_signature(&sig, &new_address, &receiver.to_bytes()).map_err(|e| {
+            e.downcast_default(
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+                "failed to verify new key, signature invalid",
+            )
+        })?;
+
+        extract_send_result(rt.send_simple(
+            &INIT_ACTOR_ADDR,
+            ext::init::CHANGE_ACCOUNT_ADDRESS_METHOD,
+            IpldBlock::serialize_cbor(&ext::init::ChangeAccountAddressParams {
+                old_address: st.address,
+                new_address,
+            })?,
+            TokenAmount::zero(),
+        ))
+        .context("failed to update init actor address map")?;
+
+        rt.transaction(|st: &mut State, _| {
+            st.address = new_address;
+            Ok(())
+        })
+    }
+
     /// Fallback method for unimplemented method numbers.
     pub fn fallback(
         rt: &impl Runtime,
@@ -107,6 +168,14 @@ impl Actor {
     }
 }
 
+fn signature_type(address: &Address) -> Option<SignatureType> {
+    match address.protocol() {
+        Protocol::Secp256k1 => Some(Secp256k1),
+        Protocol::BLS => Some(BLS),
+        _ => None,
+    }
+}
+
 impl ActorCode for Actor {
     type Methods = Method;
 
@@ -118,6 +187,7 @@ impl ActorCode for Actor {
         Constructor => constructor,
         PubkeyAddress => pubkey_address,
         AuthenticateMessageExported => authenticate_message,
+        ChangePubkeyExported => change_pubkey,
         _ => fallback,
     }
 }
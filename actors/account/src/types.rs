@@ -27,3 +27,12 @@ pub struct AuthenticateMessageParams {
 pub struct AuthenticateMessageReturn {
     pub authenticated: bool,
 }
+
+#[derive(Debug, Serialize_tuple, Deserialize_tuple)]
+pub struct ChangePubkeyParams {
+    /// The new BLS or SECP key address controlling this account.
+    pub new_address: Address,
+    /// Signature by the new key over the account's ID address bytes, proving possession.
+    #[serde(with = "strict_bytes")]
+    pub signature: Vec<u8>,
+}
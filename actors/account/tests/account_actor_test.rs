@@ -7,12 +7,19 @@ use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_ipld_encoding::RawBytes;
 use fvm_shared::address::Address;
 use fvm_shared::crypto::signature::Signature;
+use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
+use fvm_shared::version::NetworkVersion;
 use fvm_shared::MethodNum;
+use num_traits::Zero;
 
-use fil_actor_account::types::AuthenticateMessageParams;
-use fil_actor_account::{testing::check_state_invariants, Actor as AccountActor, Method, State};
-use fil_actors_runtime::builtin::SYSTEM_ACTOR_ADDR;
+use fil_actor_account::ext::init::{ChangeAccountAddressParams, CHANGE_ACCOUNT_ADDRESS_METHOD};
+use fil_actor_account::types::{AuthenticateMessageParams, ChangePubkeyParams};
+use fil_actor_account::{
+    testing::check_state_invariants, Actor as AccountActor, Method, State,
+    CHANGE_PUBKEY_NETWORK_VERSION,
+};
+use fil_actors_runtime::builtin::{INIT_ACTOR_ADDR, SYSTEM_ACTOR_ADDR};
 use fil_actors_runtime::test_utils::*;
 use fil_actors_runtime::FIRST_EXPORTED_METHOD_NUMBER;
 
@@ -159,6 +166,175 @@ fn authenticate_message() {
         .unwrap());
 }
 
+#[test]
+fn change_pubkey() {
+    let receiver = Address::new_id(100);
+    let rt = MockRuntime {
+        receiver,
+        network_version: CHANGE_PUBKEY_NETWORK_VERSION,
+        ..Default::default()
+    };
+    rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
+
+    let old_addr = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    let new_addr = Address::new_bls(&[3; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    rt.call::<AccountActor>(
+        Method::Constructor as MethodNum,
+        IpldBlock::serialize_cbor(&old_addr).unwrap(),
+    )
+    .unwrap();
+
+    let params = IpldBlock::serialize_cbor(&ChangePubkeyParams {
+        new_address: new_addr,
+        signature: vec![7; 4],
+    })
+    .unwrap();
+
+    // Only the account itself may change its key.
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(101));
+    rt.expect_validate_caller_addr(vec![receiver]);
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        rt.call::<AccountActor>(Method::ChangePubkeyExported as MethodNum, params.clone()),
+    );
+    rt.verify();
+
+    // A bad signature from the new key is rejected.
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, receiver);
+    rt.expect_validate_caller_addr(vec![receiver]);
+    rt.expect_verify_signature(ExpectedVerifySig {
+        sig: Signature::new_bls(vec![7; 4]),
+        signer: new_addr,
+        plaintext: receiver.to_bytes(),
+        result: Err(anyhow!("bad signature")),
+    });
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "bad signature",
+        rt.call::<AccountActor>(Method::ChangePubkeyExported as MethodNum, params.clone()),
+    );
+    rt.verify();
+    assert_eq!(old_addr, rt.get_state::<State>().address);
+
+    // A valid change re-maps the address in the init actor and updates state.
+    rt.expect_validate_caller_addr(vec![receiver]);
+    rt.expect_verify_signature(ExpectedVerifySig {
+        sig: Signature::new_bls(vec![7; 4]),
+        signer: new_addr,
+        plaintext: receiver.to_bytes(),
+        result: Ok(()),
+    });
+    rt.expect_send_simple(
+        INIT_ACTOR_ADDR,
+        CHANGE_ACCOUNT_ADDRESS_METHOD,
+        IpldBlock::serialize_cbor(&ChangeAccountAddressParams {
+            old_address: old_addr,
+            new_address: new_addr,
+        })
+        .unwrap(),
+        TokenAmount::zero(),
+        None,
+        ExitCode::OK,
+    );
+    rt.call::<AccountActor>(Method::ChangePubkeyExported as MethodNum, params).unwrap();
+    rt.verify();
+    assert_eq!(new_addr, rt.get_state::<State>().address);
+
+    rt.expect_validate_caller_any();
+    let pk: Address = rt
+        .call::<AccountActor>(Method::PubkeyAddress as MethodNum, None)
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .unwrap();
+    assert_eq!(new_addr, pk);
+
+    // Messages are now authenticated against the new key.
+    rt.expect_validate_caller_any();
+    rt.expect_verify_signature(ExpectedVerifySig {
+        sig: Signature::new_bls(vec![]),
+        signer: new_addr,
+        plaintext: vec![],
+        result: Ok(()),
+    });
+    rt.call::<AccountActor>(
+        Method::AuthenticateMessageExported as MethodNum,
+        IpldBlock::serialize_cbor(&AuthenticateMessageParams {
+            signature: vec![],
+            message: vec![],
+        })
+        .unwrap(),
+    )
+    .unwrap();
+    rt.verify();
+    check_state(&rt);
+}
+
+#[test]
+fn change_pubkey_rejects_invalid_address() {
+    let receiver = Address::new_id(100);
+    let rt = MockRuntime {
+        receiver,
+        network_version: CHANGE_PUBKEY_NETWORK_VERSION,
+        ..Default::default()
+    };
+    rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
+
+    let addr = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    rt.call::<AccountActor>(
+        Method::Constructor as MethodNum,
+        IpldBlock::serialize_cbor(&addr).unwrap(),
+    )
+    .unwrap();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, receiver);
+    for new_address in [Address::new_id(1), Address::new_actor(&[1, 2, 3]), addr] {
+        rt.expect_validate_caller_addr(vec![receiver]);
+        expect_abort(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            rt.call::<AccountActor>(
+                Method::ChangePubkeyExported as MethodNum,
+                IpldBlock::serialize_cbor(&ChangePubkeyParams { new_address, signature: vec![] })
+                    .unwrap(),
+            ),
+        );
+        rt.verify();
+    }
+    assert_eq!(addr, rt.get_state::<State>().address);
+}
+
+#[test]
+fn change_pubkey_not_allowed_before_activation() {
+    let receiver = Address::new_id(100);
+    let rt = MockRuntime { receiver, network_version: NetworkVersion::V23, ..Default::default() };
+    rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
+
+    let addr = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    rt.call::<AccountActor>(
+        Method::Constructor as MethodNum,
+        IpldBlock::serialize_cbor(&addr).unwrap(),
+    )
+    .unwrap();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, receiver);
+    rt.expect_validate_caller_addr(vec![receiver]);
+    let new_address = Address::new_bls(&[3; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "not allowed before network version",
+        rt.call::<AccountActor>(
+            Method::ChangePubkeyExported as MethodNum,
+            IpldBlock::serialize_cbor(&ChangePubkeyParams { new_address, signature: vec![7; 4] })
+                .unwrap(),
+        ),
+    );
+    rt.verify();
+    assert_eq!(addr, rt.get_state::<State>().address);
+}
+
 #[test]
 fn test_fallback() {
     let rt = MockRuntime { receiver: Address::new_id(100), ..Default::default() };
@@ -7,13 +7,15 @@ use fil_actors_runtime::{
 };
 
 use fvm_ipld_encoding::tuple::*;
+use fvm_shared::address::{Address, Protocol};
 use fvm_shared::econ::TokenAmount;
+use fvm_shared::MethodNum;
 
 use fvm_shared::METHOD_CONSTRUCTOR;
 use num_derive::FromPrimitive;
 use num_traits::Zero;
 
-pub use self::state::{Entry, State};
+pub use self::state::{Entry, State, MAX_CRON_ENTRIES};
 
 mod state;
 pub mod testing;
@@ -29,6 +31,8 @@ fil_actors_runtime::wasm_trampoline!(Actor);
 pub enum Method {
     Constructor = METHOD_CONSTRUCTOR,
     EpochTick = 2,
+    RegisterCronEntry = 3,
+    DeregisterCronEntry = 4,
 }
 
 /// Constructor parameters for Cron actor, contains entries
@@ -39,6 +43,22 @@ pub struct ConstructorParams {
     pub entries: Vec<Entry>,
 }
 
+/// Parameters for registering an entry to be called during each EpochTick.
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct RegisterCronEntryParams {
+    /// The actor to call (ID address)
+    pub receiver: Address,
+    /// The method number to call (must accept empty parameters)
+    pub method_num: MethodNum,
+}
+
+/// Parameters for removing a previously registered entry.
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct DeregisterCronEntryParams {
+    pub receiver: Address,
+    pub method_num: MethodNum,
+}
+
 /// Cron actor
 pub struct Actor;
 
@@ -74,6 +94,44 @@ impl Actor {
         }
         Ok(())
     }
+
+    /// Registers an entry to be called at the end of every epoch, after existing entries.
+    /// May only be called by the system actor.
+    fn register_cron_entry(
+        rt: &impl Runtime,
+        params: RegisterCronEntryParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_is(std::iter::once(&SYSTEM_ACTOR_ADDR))?;
+        if params.receiver.protocol() != Protocol::ID {
+            return Err(actor_error!(
+                illegal_argument,
+                "cron entry receiver {} must be an ID address",
+                params.receiver
+            ));
+        }
+        if params.method_num == 0 {
+            return Err(actor_error!(
+                illegal_argument,
+                "cron entry method number must be non-zero"
+            ));
+        }
+
+        rt.transaction(|st: &mut State, _| {
+            st.add_entry(Entry { receiver: params.receiver, method_num: params.method_num })
+        })
+    }
+
+    /// Removes a registered entry.
+    /// May only be called by the system actor.
+    fn deregister_cron_entry(
+        rt: &impl Runtime,
+        params: DeregisterCronEntryParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_is(std::iter::once(&SYSTEM_ACTOR_ADDR))?;
+        rt.transaction(|st: &mut State, _| {
+            st.remove_entry(&Entry { receiver: params.receiver, method_num: params.method_num })
+        })
+    }
 }
 
 impl ActorCode for Actor {
@@ -86,5 +144,7 @@ impl ActorCode for Actor {
     actor_dispatch! {
         Constructor => constructor,
         EpochTick => epoch_tick,
+        RegisterCronEntry => register_cron_entry,
+        DeregisterCronEntry => deregister_cron_entry,
     }
 }
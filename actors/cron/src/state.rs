@@ -1,10 +1,15 @@
 // Copyright 2019-2022 ChainSafe Systems
 // SPDX-License-Identifier: Apache-2.0, MIT
 
+use fil_actors_runtime::{actor_error, ActorError};
 use fvm_ipld_encoding::tuple::*;
 use fvm_shared::address::Address;
 use fvm_shared::MethodNum;
 
+/// Maximum number of entries the cron actor will call each epoch.
+/// This bounds the gas spent by each epoch tick.
+pub const MAX_CRON_ENTRIES: usize = 32;
+
 /// Cron actor state which holds entries to call during epoch tick
 #[derive(Default, Serialize_tuple, Deserialize_tuple, Clone, Debug)]
 pub struct State {
@@ -19,3 +24,41 @@ pub struct Entry {
     /// The method number to call (must accept empty parameters)
     pub method_num: MethodNum,
 }
+
+impl State {
+    /// Appends an entry to be called after existing entries.
+    /// Fails if the entry is already present or the maximum number of entries is reached.
+    pub fn add_entry(&mut self, entry: Entry) -> Result<(), ActorError> {
+        if self.entries.contains(&entry) {
+            return Err(actor_error!(
+                illegal_argument,
+                "cron entry for {} method {} already registered",
+                entry.receiver,
+                entry.method_num
+            ));
+        }
+        if self.entries.len() >= MAX_CRON_ENTRIES {
+            return Err(actor_error!(
+                forbidden,
+                "cannot register more than {} cron entries",
+                MAX_CRON_ENTRIES
+            ));
+        }
+        self.entries.push(entry);
+        Ok(())
+    }
+
+    /// Removes an entry, preserving the order of the remaining entries.
+    pub fn remove_entry(&mut self, entry: &Entry) -> Result<(), ActorError> {
+        let idx = self.entries.iter().position(|e| e == entry).ok_or_else(|| {
+            actor_error!(
+                not_found,
+                "no cron entry for {} method {}",
+                entry.receiver,
+                entry.method_num
+            )
+        })?;
+        self.entries.remove(idx);
+        Ok(())
+    }
+}
@@ -1,7 +1,7 @@
 use fil_actors_runtime::MessageAccumulator;
 use fvm_shared::address::Protocol;
 
-use crate::State;
+use crate::{State, MAX_CRON_ENTRIES};
 
 pub struct StateSummary {
     pub entry_count: usize,
@@ -19,7 +19,15 @@ pub fn check_state_invariants(state: &State) -> (StateSummary, MessageAccumulato
             entry.method_num > 0,
             format!("entry {i} has invalid method number {}", entry.method_num),
         );
+        acc.require(
+            !state.entries[..i].contains(entry),
+            format!("entry {i} duplicates an earlier entry"),
+        );
     });
+    acc.require(
+        state.entries.len() <= MAX_CRON_ENTRIES,
+        format!("{} entries exceeds maximum {MAX_CRON_ENTRIES}", state.entries.len()),
+    );
 
     (StateSummary { entry_count: state.entries.len() }, acc)
 }
@@ -4,9 +4,12 @@
 use std::cell::RefCell;
 
 use fil_actor_cron::testing::check_state_invariants;
-use fil_actor_cron::{Actor as CronActor, ConstructorParams, Entry, State};
+use fil_actor_cron::{
+    Actor as CronActor, ConstructorParams, DeregisterCronEntryParams, Entry, Method,
+    RegisterCronEntryParams, State, MAX_CRON_ENTRIES,
+};
 use fil_actors_runtime::test_utils::*;
-use fil_actors_runtime::SYSTEM_ACTOR_ADDR;
+use fil_actors_runtime::{ActorError, SYSTEM_ACTOR_ADDR};
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_shared::address::Address;
 use fvm_shared::econ::TokenAmount;
@@ -117,6 +120,111 @@ fn epoch_tick_with_entries() {
     epoch_tick_and_verify(&rt);
 }
 
+#[test]
+fn register_and_deregister_entries() {
+    let rt = construct_runtime();
+
+    let entry1 = Entry { receiver: Address::new_id(1001), method_num: 1001 };
+    let entry2 = Entry { receiver: Address::new_id(1002), method_num: 1002 };
+    let entry3 = Entry { receiver: Address::new_id(1001), method_num: 1003 };
+    construct_and_verify(&rt, &ConstructorParams { entries: vec![entry1.clone()] });
+
+    register_entry(&rt, &entry2).unwrap();
+    register_entry(&rt, &entry3).unwrap();
+    let state: State = rt.get_state();
+    assert_eq!(vec![entry1.clone(), entry2.clone(), entry3.clone()], state.entries);
+    check_state(&rt);
+
+    // Registered entries are called after existing entries.
+    for entry in [&entry1, &entry2, &entry3] {
+        rt.expect_send_simple(
+            entry.receiver,
+            entry.method_num,
+            None,
+            TokenAmount::zero(),
+            None,
+            ExitCode::OK,
+        );
+    }
+    epoch_tick_and_verify(&rt);
+
+    deregister_entry(&rt, &entry1).unwrap();
+    let state: State = rt.get_state();
+    assert_eq!(vec![entry2.clone(), entry3.clone()], state.entries);
+    check_state(&rt);
+
+    expect_abort(ExitCode::USR_NOT_FOUND, deregister_entry(&rt, &entry1));
+    rt.reset();
+}
+
+#[test]
+fn register_entry_rejects_invalid_entries() {
+    let rt = construct_runtime();
+
+    let entry = Entry { receiver: Address::new_id(1001), method_num: 1001 };
+    construct_and_verify(&rt, &ConstructorParams { entries: vec![entry.clone()] });
+
+    // Duplicate entry.
+    expect_abort(ExitCode::USR_ILLEGAL_ARGUMENT, register_entry(&rt, &entry));
+    rt.reset();
+
+    // Non-ID receiver.
+    let non_id = Entry { receiver: Address::new_actor(b"cron"), method_num: 1001 };
+    expect_abort(ExitCode::USR_ILLEGAL_ARGUMENT, register_entry(&rt, &non_id));
+    rt.reset();
+
+    // Only the system actor may register entries.
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(1234));
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    let params = RegisterCronEntryParams { receiver: Address::new_id(1002), method_num: 1002 };
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        rt.call::<CronActor>(
+            Method::RegisterCronEntry as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        ),
+    );
+    rt.reset();
+    rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
+
+    // The number of entries is bounded.
+    for i in 1..MAX_CRON_ENTRIES as u64 {
+        register_entry(&rt, &Entry { receiver: Address::new_id(2000 + i), method_num: 1 }).unwrap();
+    }
+    let extra = Entry { receiver: Address::new_id(3000), method_num: 1 };
+    expect_abort(ExitCode::USR_FORBIDDEN, register_entry(&rt, &extra));
+    rt.reset();
+
+    let state: State = rt.get_state();
+    assert_eq!(MAX_CRON_ENTRIES, state.entries.len());
+    check_state(&rt);
+}
+
+fn register_entry(rt: &MockRuntime, entry: &Entry) -> Result<(), ActorError> {
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    let params = RegisterCronEntryParams { receiver: entry.receiver, method_num: entry.method_num };
+    let ret = rt.call::<CronActor>(
+        Method::RegisterCronEntry as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    )?;
+    assert!(ret.is_none());
+    rt.verify();
+    Ok(())
+}
+
+fn deregister_entry(rt: &MockRuntime, entry: &Entry) -> Result<(), ActorError> {
+    rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+    let params =
+        DeregisterCronEntryParams { receiver: entry.receiver, method_num: entry.method_num };
+    let ret = rt.call::<CronActor>(
+        Method::DeregisterCronEntry as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    )?;
+    assert!(ret.is_none());
+    rt.verify();
+    Ok(())
+}
+
 fn construct_and_verify(rt: &MockRuntime, params: &ConstructorParams) {
     rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
     rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
@@ -13,16 +13,19 @@ use fvm_shared::address::Address;
 use fvm_shared::bigint::BigInt;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::{ErrorNumber, ExitCode};
+use fvm_shared::sys::SendFlags;
 use fvm_shared::Response;
 use fvm_shared::{ActorID, MethodNum, METHOD_CONSTRUCTOR};
 use lazy_static::lazy_static;
 use log::info;
 use num_derive::FromPrimitive;
+use num_traits::{Signed, Zero};
 
+use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::runtime::{ActorCode, Runtime};
 use fil_actors_runtime::{
     actor_dispatch, actor_error, extract_send_result, ActorContext, ActorError, AsActorError,
-    SYSTEM_ACTOR_ADDR,
+    SendError, SYSTEM_ACTOR_ADDR, VERIFIED_REGISTRY_ACTOR_ID,
 };
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 
@@ -38,6 +41,9 @@ mod types;
 
 pub const DATACAP_GRANULARITY: u64 = TOKEN_PRECISION;
 
+/// Gas limit for the receiver hook invoked on the recipient of minted tokens.
+pub const MINT_RECEIVER_HOOK_GAS_LIMIT: u64 = 100_000_000;
+
 lazy_static! {
     // > 800 EiB
     pub static ref INFINITE_ALLOWANCE: TokenAmount = TokenAmount::from_atto(
@@ -82,6 +88,7 @@ pub enum Method {
     BurnExported = frc42_dispatch::method_hash!("Burn"),
     BurnFromExported = frc42_dispatch::method_hash!("BurnFrom"),
     AllowanceExported = frc42_dispatch::method_hash!("Allowance"),
+    ApproveExported = frc42_dispatch::method_hash!("Approve"),
 }
 
 pub struct Actor;
@@ -152,6 +159,9 @@ impl Actor {
 
     /// Mints new data cap tokens for an address (a verified client).
     /// Simultaneously sets the allowance for any specified operators to effectively infinite.
+    /// The FRC-46 receiver hook is invoked on the recipient with a bounded gas limit, and the
+    /// mint aborts if the recipient rejects the tokens. Accounts, multisigs and the verified
+    /// registry are not notified.
     /// Only the governor can call this method.
     /// This method is not part of the fungible token standard.
     pub fn mint(rt: &impl Runtime, params: MintParams) -> Result<MintReturn, ActorError> {
@@ -187,8 +197,13 @@ impl Actor {
             .context("state transaction failed")?;
 
         let mut st: State = rt.state()?;
+        let hook_syscalls = MintHookSyscalls {
+            inner: SyscallProvider { rt },
+            exempt: is_mint_hook_exempt(rt, &params.to),
+        };
+        let intermediate =
+            hook.call(&ActorRuntime::new(&hook_syscalls, rt.store())).actor_result()?;
         let syscalls = SyscallProvider { rt };
-        let intermediate = hook.call(&as_actor_runtime(&syscalls)).actor_result()?;
         let runtime = ActorRuntime::new(&syscalls, syscalls.rt.store());
         as_token(&mut st, &runtime).mint_return(intermediate).actor_result()
     }
@@ -375,6 +390,39 @@ impl Actor {
         .context("state transaction failed")
     }
 
+    /// Sets the allowance of an operator to spend the caller's tokens to an absolute amount,
+    /// returning the previous allowance.
+    /// Amounts above the infinite allowance are capped to it.
+    /// Approving a zero amount removes the allowance entirely.
+    /// This method is not part of the fungible token standard.
+    pub fn approve(rt: &impl Runtime, params: ApproveParams) -> Result<ApproveReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let owner = &rt.message().caller();
+        let operator = &params.operator;
+        if params.amount.is_negative() {
+            return Err(actor_error!(
+                illegal_argument,
+                "approved amount {} must not be negative",
+                params.amount
+            ));
+        }
+        let amount = std::cmp::min(&params.amount, &*INFINITE_ALLOWANCE);
+
+        rt.transaction(|st: &mut State, rt| {
+            let syscalls = SyscallProvider { rt };
+            let runtime = ActorRuntime::new(&syscalls, syscalls.rt.store());
+            let mut token = as_token(st, &runtime);
+            let old_allowance = token.allowance(owner, operator).actor_result()?;
+            if amount.is_zero() {
+                token.revoke_allowance(owner, operator).actor_result()?;
+            } else {
+                token.set_allowance(owner, operator, amount).actor_result()?;
+            }
+            Ok(ApproveReturn { old_allowance })
+        })
+        .context("state transaction failed")
+    }
+
     pub fn burn(rt: &impl Runtime, params: BurnParams) -> Result<BurnReturn, ActorError> {
         rt.validate_immediate_caller_accept_any()?;
         let owner = &rt.message().caller();
@@ -434,17 +482,7 @@ where
         params: Option<IpldBlock>,
         value: TokenAmount,
     ) -> Result<Response, ErrorNumber> {
-        // The Runtime discards some of the information from the syscall :-(
-        let res = extract_send_result(self.rt.send_simple(to, method, params, value));
-
-        let rec = match res {
-            Ok(ret) => Response { exit_code: ExitCode::OK, return_data: ret },
-            Err(ae) => {
-                info!("datacap messenger failed: {}", ae.msg());
-                Response { exit_code: ae.exit_code(), return_data: None }
-            }
-        };
-        Ok(rec)
+        Ok(as_response(self.rt.send_simple(to, method, params, value)))
     }
 
     fn resolve_address(&self, addr: &Address) -> Option<ActorID> {
@@ -460,6 +498,83 @@ where
     }
 }
 
+// The Runtime discards some of the information from the syscall :-(
+fn as_response(res: Result<Response, SendError>) -> Response {
+    match extract_send_result(res) {
+        Ok(ret) => Response { exit_code: ExitCode::OK, return_data: ret },
+        Err(ae) => {
+            info!("datacap messenger failed: {}", ae.msg());
+            Response { exit_code: ae.exit_code(), return_data: None }
+        }
+    }
+}
+
+/// Syscalls for delivering the receiver hook to the recipient of minted tokens.
+/// The hook is sent with a bounded gas limit, or skipped entirely for exempt recipients.
+struct MintHookSyscalls<'a, RT> {
+    inner: SyscallProvider<'a, RT>,
+    exempt: bool,
+}
+
+impl<'a, RT> Syscalls for &MintHookSyscalls<'a, RT>
+where
+    RT: Runtime,
+{
+    fn root(&self) -> Result<Cid, NoStateError> {
+        (&self.inner).root()
+    }
+
+    fn receiver(&self) -> ActorID {
+        (&self.inner).receiver()
+    }
+
+    fn send(
+        &self,
+        to: &Address,
+        method: MethodNum,
+        params: Option<IpldBlock>,
+        value: TokenAmount,
+    ) -> Result<Response, ErrorNumber> {
+        if self.exempt {
+            return Ok(Response { exit_code: ExitCode::OK, return_data: None });
+        }
+        Ok(as_response(self.inner.rt.send(
+            to,
+            method,
+            params,
+            value,
+            Some(MINT_RECEIVER_HOOK_GAS_LIMIT),
+            SendFlags::empty(),
+        )))
+    }
+
+    fn resolve_address(&self, addr: &Address) -> Option<ActorID> {
+        (&self.inner).resolve_address(addr)
+    }
+
+    fn set_root(&self, cid: &Cid) -> Result<(), NoStateError> {
+        (&self.inner).set_root(cid)
+    }
+
+    fn caller(&self) -> ActorID {
+        (&self.inner).caller()
+    }
+}
+
+// Returns whether the recipient of minted tokens is exempt from the receiver hook.
+fn is_mint_hook_exempt(rt: &impl Runtime, to: &Address) -> bool {
+    let Some(id) = rt.resolve_address(to) else {
+        return false;
+    };
+    if id == VERIFIED_REGISTRY_ACTOR_ID {
+        return true;
+    }
+    matches!(
+        rt.get_actor_code_cid(&id).and_then(|code| rt.resolve_builtin_actor_type(&code)),
+        Some(Type::Account | Type::EthAccount | Type::Multisig)
+    )
+}
+
 // Returns a token instance wrapping the token state.
 fn as_token<'st, RT>(
     st: &'st mut State,
@@ -521,5 +636,6 @@ impl ActorCode for Actor {
         BurnExported => burn,
         BurnFromExported => burn_from,
         AllowanceExported => allowance,
+        ApproveExported => approve,
     }
 }
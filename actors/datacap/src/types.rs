@@ -62,6 +62,20 @@ pub struct RevokeAllowanceReturn {
     pub old_allowance: TokenAmount,
 }
 
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ApproveParams {
+    // Address permitted to spend the caller's tokens.
+    pub operator: Address,
+    // Absolute allowance to set, replacing any existing allowance.
+    pub amount: TokenAmount,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ApproveReturn {
+    pub old_allowance: TokenAmount,
+}
+
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct MintParams {
     // Recipient of the newly minted tokens.
@@ -65,10 +65,14 @@ mod mint {
     use fvm_shared::MethodNum;
 
     use fil_actor_datacap::{Actor, Method, MintParams, INFINITE_ALLOWANCE};
-    use fil_actors_runtime::test_utils::{expect_abort_contains_message, MARKET_ACTOR_CODE_ID};
+    use fil_actors_runtime::test_utils::{
+        expect_abort_contains_message, ACCOUNT_ACTOR_CODE_ID, MARKET_ACTOR_CODE_ID,
+        MULTISIG_ACTOR_CODE_ID,
+    };
     use fil_actors_runtime::{STORAGE_MARKET_ACTOR_ADDR, VERIFIED_REGISTRY_ACTOR_ADDR};
     use fvm_ipld_encoding::ipld_block::IpldBlock;
     use fvm_ipld_encoding::RawBytes;
+    use num_traits::Zero;
     use std::ops::Sub;
 
     use crate::*;
@@ -152,6 +156,32 @@ mod mint {
 
         h.check_state(&rt);
     }
+
+    #[test]
+    fn mint_aborts_if_receiver_rejects() {
+        let (rt, h) = make_harness();
+        let amt = TokenAmount::from_whole(1);
+        h.mint_with_hook_result(&rt, &ALICE, &amt, vec![], ExitCode::USR_FORBIDDEN).unwrap_err();
+        rt.reset();
+        assert!(h.get_supply(&rt).is_zero());
+        assert!(h.get_balance(&rt, &ALICE).is_zero());
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn mint_skips_hook_for_accounts_and_multisigs() {
+        let (rt, h) = make_harness();
+        let amt = TokenAmount::from_whole(1);
+        rt.set_address_actor_type(*ALICE, *ACCOUNT_ACTOR_CODE_ID);
+        rt.set_address_actor_type(*BOB, *MULTISIG_ACTOR_CODE_ID);
+
+        // The harness expects no receiver hook for these recipients.
+        h.mint(&rt, &ALICE, &amt, vec![]).unwrap();
+        h.mint(&rt, &BOB, &amt, vec![]).unwrap();
+        assert_eq!(amt, h.get_balance(&rt, &ALICE));
+        assert_eq!(amt, h.get_balance(&rt, &BOB));
+        h.check_state(&rt);
+    }
 }
 
 mod transfer {
@@ -220,6 +250,90 @@ mod transfer {
     }
 }
 
+mod approve {
+    use crate::{make_harness, ALICE, BOB};
+    use fil_actor_datacap::INFINITE_ALLOWANCE;
+    use fil_actors_runtime::test_utils::{expect_abort, expect_abort_contains_message};
+    use fvm_ipld_encoding::RawBytes;
+    use fvm_shared::econ::TokenAmount;
+    use fvm_shared::error::ExitCode;
+    use num_traits::Zero;
+
+    #[test]
+    fn approve_sets_ceiling_for_transfer_from() {
+        let (rt, h) = make_harness();
+        let operator_data = RawBytes::new(vec![1, 2, 3, 4]);
+        let amt = TokenAmount::from_whole(1);
+        h.mint(&rt, &ALICE, &(10 * amt.clone()), vec![]).unwrap();
+
+        let ret = h.approve(&rt, &ALICE, &BOB, &(3 * amt.clone())).unwrap();
+        assert!(ret.old_allowance.is_zero());
+        assert_eq!(3 * amt.clone(), h.get_allowance_between(&rt, &ALICE, &BOB));
+
+        // Transfers decrement the approved amount.
+        h.transfer_from(&rt, &BOB, &ALICE, &h.governor, &(2 * amt.clone()), operator_data.clone())
+            .unwrap();
+        assert_eq!(amt, h.get_allowance_between(&rt, &ALICE, &BOB));
+
+        // Transfers can't exceed the remaining allowance.
+        expect_abort(
+            ExitCode::USR_FORBIDDEN,
+            h.transfer_from(
+                &rt,
+                &BOB,
+                &ALICE,
+                &h.governor,
+                &(2 * amt.clone()),
+                operator_data.clone(),
+            ),
+        );
+        rt.reset();
+
+        // Approving again replaces rather than adds to the allowance.
+        let ret = h.approve(&rt, &ALICE, &BOB, &(5 * amt.clone())).unwrap();
+        assert_eq!(amt, ret.old_allowance);
+        assert_eq!(5 * amt.clone(), h.get_allowance_between(&rt, &ALICE, &BOB));
+        h.transfer_from(&rt, &BOB, &ALICE, &h.governor, &(2 * amt.clone()), operator_data).unwrap();
+        assert_eq!(3 * amt, h.get_allowance_between(&rt, &ALICE, &BOB));
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn approve_zero_clears_allowance() {
+        let (rt, h) = make_harness();
+        let amt = TokenAmount::from_whole(1);
+        h.mint(&rt, &ALICE, &amt, vec![*BOB]).unwrap();
+
+        let ret = h.approve(&rt, &ALICE, &BOB, &TokenAmount::zero()).unwrap();
+        assert_eq!(*INFINITE_ALLOWANCE, ret.old_allowance);
+        assert!(h.get_allowance_between(&rt, &ALICE, &BOB).is_zero());
+
+        // Clearing an absent allowance is a no-op.
+        let ret = h.approve(&rt, &ALICE, &BOB, &TokenAmount::zero()).unwrap();
+        assert!(ret.old_allowance.is_zero());
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn approve_caps_at_infinite_allowance() {
+        let (rt, h) = make_harness();
+        let amt = TokenAmount::from_whole(1);
+        h.mint(&rt, &ALICE, &amt, vec![]).unwrap();
+
+        h.approve(&rt, &ALICE, &BOB, &(INFINITE_ALLOWANCE.clone() + amt)).unwrap();
+        assert_eq!(*INFINITE_ALLOWANCE, h.get_allowance_between(&rt, &ALICE, &BOB));
+
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "must not be negative",
+            h.approve(&rt, &ALICE, &BOB, &TokenAmount::from_atto(-1)),
+        );
+        rt.reset();
+        assert_eq!(*INFINITE_ALLOWANCE, h.get_allowance_between(&rt, &ALICE, &BOB));
+        h.check_state(&rt);
+    }
+}
+
 mod destroy {
     use crate::{make_harness, ALICE, BOB};
     use fil_actor_datacap::DestroyParams;
@@ -261,6 +375,45 @@ mod destroy {
     }
 }
 
+mod burn {
+    use crate::{make_harness, ALICE, BOB};
+    use fvm_shared::econ::TokenAmount;
+    use num_traits::Zero;
+
+    #[test]
+    fn burn_returns_remaining_balance() {
+        let (rt, h) = make_harness();
+
+        let amt = TokenAmount::from_whole(1);
+        h.mint(&rt, &ALICE, &(3 * amt.clone()), vec![]).unwrap();
+
+        let ret = h.burn(&rt, &ALICE, &amt).unwrap();
+        assert_eq!(2 * amt.clone(), ret.balance);
+        assert_eq!(ret.balance, h.get_balance(&rt, &ALICE));
+
+        let ret = h.burn(&rt, &ALICE, &(2 * amt)).unwrap();
+        assert!(ret.balance.is_zero());
+        assert_eq!(TokenAmount::zero(), h.get_supply(&rt));
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn burn_from_returns_remaining_balance_and_allowance() {
+        let (rt, h) = make_harness();
+
+        let amt = TokenAmount::from_whole(1);
+        h.mint(&rt, &ALICE, &(3 * amt.clone()), vec![]).unwrap();
+        h.approve(&rt, &ALICE, &BOB, &(2 * amt.clone())).unwrap();
+
+        let ret = h.burn_from(&rt, &BOB, &ALICE, &amt).unwrap();
+        assert_eq!(2 * amt.clone(), ret.owner_balance);
+        assert_eq!(amt, ret.allowance);
+        assert_eq!(ret.owner_balance, h.get_balance(&rt, &ALICE));
+        assert_eq!(ret.allowance, h.get_allowance_between(&rt, &ALICE, &BOB));
+        h.check_state(&rt);
+    }
+}
+
 fn make_harness() -> (MockRuntime, Harness) {
     let rt = new_runtime();
     let h = Harness { governor: VERIFIED_REGISTRY_ACTOR_ADDR };
@@ -2,18 +2,23 @@ use std::cell::RefCell;
 
 use frc46_token::receiver::{FRC46TokenReceived, FRC46_TOKEN_TYPE};
 use frc46_token::token::types::{
-    BurnReturn, MintReturn, TransferFromParams, TransferFromReturn, TransferParams, TransferReturn,
+    BurnFromParams, BurnFromReturn, BurnParams, BurnReturn, MintReturn, TransferFromParams,
+    TransferFromReturn, TransferParams, TransferReturn,
 };
 use fvm_actor_utils::receiver::UniversalReceiverParams;
 use fvm_ipld_encoding::RawBytes;
 use fvm_shared::address::Address;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
+use fvm_shared::sys::SendFlags;
 use fvm_shared::MethodNum;
 use num_traits::Zero;
 
 use fil_actor_datacap::testing::check_state_invariants;
-use fil_actor_datacap::{Actor as DataCapActor, DestroyParams, Method, MintParams, State};
+use fil_actor_datacap::{
+    Actor as DataCapActor, ApproveParams, ApproveReturn, DestroyParams, Method, MintParams, State,
+    MINT_RECEIVER_HOOK_GAS_LIMIT,
+};
 use fil_actors_runtime::cbor::serialize;
 use fil_actors_runtime::runtime::Runtime;
 use fil_actors_runtime::test_utils::*;
@@ -67,33 +72,57 @@ impl Harness {
         to: &Address,
         amount: &TokenAmount,
         operators: Vec<Address>,
+    ) -> Result<MintReturn, ActorError> {
+        self.mint_with_hook_result(rt, to, amount, operators, ExitCode::OK)
+    }
+
+    /// Mints tokens, expecting the receiver hook on a non-exempt recipient to
+    /// return the given exit code.
+    pub fn mint_with_hook_result(
+        &self,
+        rt: &MockRuntime,
+        to: &Address,
+        amount: &TokenAmount,
+        operators: Vec<Address>,
+        hook_exit_code: ExitCode,
     ) -> Result<MintReturn, ActorError> {
         rt.expect_validate_caller_addr(vec![VERIFIED_REGISTRY_ACTOR_ADDR]);
 
-        // Expect the token receiver hook to be called.
-        let hook_params = UniversalReceiverParams {
-            type_: FRC46_TOKEN_TYPE,
-            payload: serialize(
-                &FRC46TokenReceived {
-                    from: DATACAP_TOKEN_ACTOR_ADDR.id().unwrap(),
-                    to: to.id().unwrap(),
-                    operator: VERIFIED_REGISTRY_ACTOR_ADDR.id().unwrap(),
-                    amount: amount.clone(),
-                    operator_data: Default::default(),
-                    token_data: Default::default(),
-                },
-                "hook payload",
-            )?,
-        };
-        // UniversalReceiverParams
-        rt.expect_send_simple(
-            *to,
-            frc42_dispatch::method_hash!("Receive"),
-            IpldBlock::serialize_cbor(&hook_params).unwrap(),
-            TokenAmount::zero(),
-            None,
-            ExitCode::OK,
-        );
+        // Expect the token receiver hook to be called, unless the recipient is exempt.
+        let recipient_code = rt.actor_code_cids.borrow().get(to).cloned();
+        let exempt = *to == VERIFIED_REGISTRY_ACTOR_ADDR
+            || recipient_code.map_or(false, |code| {
+                code == *ACCOUNT_ACTOR_CODE_ID
+                    || code == *ETHACCOUNT_ACTOR_CODE_ID
+                    || code == *MULTISIG_ACTOR_CODE_ID
+            });
+        if !exempt {
+            let hook_params = UniversalReceiverParams {
+                type_: FRC46_TOKEN_TYPE,
+                payload: serialize(
+                    &FRC46TokenReceived {
+                        from: DATACAP_TOKEN_ACTOR_ADDR.id().unwrap(),
+                        to: to.id().unwrap(),
+                        operator: VERIFIED_REGISTRY_ACTOR_ADDR.id().unwrap(),
+                        amount: amount.clone(),
+                        operator_data: Default::default(),
+                        token_data: Default::default(),
+                    },
+                    "hook payload",
+                )?,
+            };
+            rt.expect_send(
+                *to,
+                frc42_dispatch::method_hash!("Receive"),
+                IpldBlock::serialize_cbor(&hook_params).unwrap(),
+                TokenAmount::zero(),
+                Some(MINT_RECEIVER_HOOK_GAS_LIMIT),
+                SendFlags::empty(),
+                None,
+                hook_exit_code,
+                None,
+            );
+        }
 
         let params = MintParams { to: *to, amount: amount.clone(), operators };
         rt.set_caller(*VERIFREG_ACTOR_CODE_ID, VERIFIED_REGISTRY_ACTOR_ADDR);
@@ -126,6 +155,45 @@ impl Harness {
         Ok(ret.unwrap().deserialize().unwrap())
     }
 
+    pub fn burn(
+        &self,
+        rt: &MockRuntime,
+        owner: &Address,
+        amount: &TokenAmount,
+    ) -> Result<BurnReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *owner);
+
+        let params = BurnParams { amount: amount.clone() };
+        let ret = rt.call::<DataCapActor>(
+            Method::BurnExported as MethodNum,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+
+        rt.verify();
+        Ok(ret.unwrap().deserialize().unwrap())
+    }
+
+    pub fn burn_from(
+        &self,
+        rt: &MockRuntime,
+        operator: &Address,
+        owner: &Address,
+        amount: &TokenAmount,
+    ) -> Result<BurnFromReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *operator);
+
+        let params = BurnFromParams { owner: *owner, amount: amount.clone() };
+        let ret = rt.call::<DataCapActor>(
+            Method::BurnFromExported as MethodNum,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+
+        rt.verify();
+        Ok(ret.unwrap().deserialize().unwrap())
+    }
+
     pub fn transfer(
         &self,
         rt: &MockRuntime,
@@ -220,6 +288,26 @@ impl Harness {
         Ok(ret.unwrap().deserialize().unwrap())
     }
 
+    pub fn approve(
+        &self,
+        rt: &MockRuntime,
+        owner: &Address,
+        operator: &Address,
+        amount: &TokenAmount,
+    ) -> Result<ApproveReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *owner);
+
+        let params = ApproveParams { operator: *operator, amount: amount.clone() };
+        let ret = rt.call::<DataCapActor>(
+            Method::ApproveExported as MethodNum,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+
+        rt.verify();
+        Ok(ret.unwrap().deserialize().unwrap())
+    }
+
     // Reads the total supply from state directly.
     pub fn get_supply(&self, rt: &MockRuntime) -> TokenAmount {
         rt.get_state::<State>().token.supply
@@ -151,6 +151,8 @@ pub mod opcodes {
         0x46: CHAINID,
         0x47: SELFBALANCE,
         0x48: BASEFEE,
+        0x49: BLOBHASH,
+        0x4a: BLOBBASEFEE,
         0x50: POP,
         0x51: MLOAD,
         0x52: MSTORE,
@@ -163,6 +165,8 @@ pub mod opcodes {
         0x59: MSIZE,
         0x5a: GAS,
         0x5b: JUMPDEST,
+        0x5c: TLOAD,
+        0x5d: TSTORE,
         0x5F: PUSH0,
         0x60: PUSH1,
         0x61: PUSH2,
@@ -394,11 +398,14 @@ mod tests {
             EXTCODEHASH,
             RETURNDATACOPY,
             BLOCKHASH,
+            BLOBHASH,
             MLOAD,
             MSTORE,
             MSTORE8,
             SLOAD,
             SSTORE,
+            TLOAD,
+            TSTORE,
             LOG0,
             LOG1,
             LOG2,
@@ -464,6 +471,7 @@ mod tests {
             GASLIMIT,
             CHAINID,
             BASEFEE,
+            BLOBBASEFEE,
             SELFBALANCE,
             MSIZE,
             CODESIZE,
@@ -131,6 +131,27 @@ pub fn gas_limit(
     Ok(U256::from(BLOCK_GAS_LIMIT))
 }
 
+/// EIP-4844: BLOBHASH
+#[inline]
+pub fn blob_hash(
+    _state: &mut ExecutionState,
+    _system: &System<impl Runtime>,
+    _index: U256,
+) -> Result<U256, ActorError> {
+    // Filecoin has no blob transactions, so there is never a versioned hash at any index.
+    Ok(U256::ZERO)
+}
+
+/// EIP-7516: BLOBBASEFEE
+#[inline]
+pub fn blob_base_fee(
+    _state: &mut ExecutionState,
+    _system: &System<impl Runtime>,
+) -> Result<U256, ActorError> {
+    // With no blob transactions the blob base fee stays at its minimum of 1 (EIP-4844).
+    Ok(U256::ONE)
+}
+
 #[inline]
 pub fn chain_id(
     _state: &mut ExecutionState,
@@ -271,6 +292,43 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_blobhash() {
+        for index in [0u64, 1, u64::MAX] {
+            let [a, b, c, d, e, f, g, h] = index.to_be_bytes();
+            evm_unit_test! {
+                (m) {
+                    PUSH8;
+                    {a};
+                    {b};
+                    {c};
+                    {d};
+                    {e};
+                    {f};
+                    {g};
+                    {h};
+                    BLOBHASH;
+                }
+                m.step().expect("execution step failed");
+                m.step().expect("execution step failed");
+                assert_eq!(m.state.stack.len(), 1);
+                assert_eq!(m.state.stack.pop().unwrap(), U256::ZERO);
+            };
+        }
+    }
+
+    #[test]
+    fn test_blobbasefee() {
+        evm_unit_test! {
+            (m) {
+                BLOBBASEFEE;
+            }
+            m.step().expect("execution step failed");
+            assert_eq!(m.state.stack.len(), 1);
+            assert_eq!(m.state.stack.pop().unwrap(), U256::ONE);
+        };
+    }
+
     #[test]
     fn test_coinbase() {
         evm_unit_test! {
@@ -332,12 +332,16 @@ def_stdfun! { PREVRANDAO() => context::prevrandao }
 def_stdfun! { GASLIMIT() => context::gas_limit }
 def_stdfun! { CHAINID() => context::chain_id }
 def_stdfun! { BASEFEE() => context::base_fee }
+def_stdfun! { BLOBHASH(a) => context::blob_hash }
+def_stdfun! { BLOBBASEFEE() => context::blob_base_fee }
 def_stdfun! { SELFBALANCE() => state::selfbalance }
 def_stdfun! { MLOAD(a) => memory::mload }
 def_stdproc! { MSTORE(a, b) => memory::mstore }
 def_stdproc! { MSTORE8(a, b) => memory::mstore8 }
 def_stdfun! { SLOAD(a) => storage::sload }
 def_stdproc! { SSTORE(a, b) => storage::sstore }
+def_stdfun! { TLOAD(a) => storage::tload }
+def_stdproc! { TSTORE(a, b) => storage::tstore }
 def_stdfun! { MSIZE() => memory::msize }
 def_stdfun! { GAS() => context::gas }
 def_stdlog! { LOG0(0, ()) }
@@ -30,6 +30,30 @@ pub fn sstore(
     system.set_storage(key, value)
 }
 
+#[inline]
+pub fn tload(
+    _state: &mut ExecutionState,
+    system: &mut System<impl Runtime>,
+    location: U256,
+) -> Result<U256, ActorError> {
+    Ok(system.get_transient_storage(location))
+}
+
+#[inline]
+pub fn tstore(
+    _state: &mut ExecutionState,
+    system: &mut System<impl Runtime>,
+    key: U256,
+    value: U256,
+) -> Result<(), ActorError> {
+    if system.readonly {
+        return Err(ActorError::read_only("transient store called while read-only".into()));
+    }
+
+    system.set_transient_storage(key, value);
+    Ok(())
+}
+
 #[cfg(test)]
 mod tests {
     use fil_actors_evm_shared::uints::U256;
@@ -82,4 +106,78 @@ mod tests {
             assert_eq!(m.system.get_storage(U256::from(0)).unwrap(), U256::from(0x42));
         };
     }
+
+    #[test]
+    fn test_tstore_tload() {
+        evm_unit_test! {
+            (m) {
+                TSTORE;
+                TLOAD;
+            }
+
+            m.state.stack.push(U256::from(0x42)).unwrap();
+            m.state.stack.push(U256::from(0)).unwrap();
+            assert!(m.step().is_ok(), "execution step failed");
+            assert_eq!(m.state.stack.len(), 0);
+
+            m.state.stack.push(U256::from(0)).unwrap();
+            assert!(m.step().is_ok(), "execution step failed");
+            assert_eq!(m.state.stack.pop().unwrap(), U256::from(0x42));
+
+            // Transient storage is separate from persistent storage.
+            assert_eq!(m.system.get_storage(U256::from(0)).unwrap(), U256::from(0));
+        };
+    }
+
+    #[test]
+    fn test_tstore_read_only() {
+        evm_unit_test! {
+            (m) {
+                TSTORE;
+            }
+            m.system.readonly = true;
+            m.state.stack.push(U256::from(0x42)).unwrap();
+            m.state.stack.push(U256::from(0)).unwrap();
+            let result = m.step();
+            assert!(result.is_err());
+            assert_eq!(result.unwrap_err().exit_code(), fvm_shared::error::ExitCode::USR_READ_ONLY);
+        };
+    }
+
+    #[test]
+    fn test_transient_storage_across_frames() {
+        evm_unit_test! {
+            (rt) {
+                rt.set_origin(fvm_shared::address::Address::new_id(100));
+            }
+            (m) {}
+            let key = U256::from(1);
+            m.system.set_transient_storage(key, U256::from(0x42));
+            m.system.flush().unwrap();
+            let parent_root = rt.get_state_root().unwrap();
+
+            // A nested frame in the same transaction reads the parent's transient storage.
+            let mut child = System::load(&rt).unwrap();
+            assert_eq!(child.get_transient_storage(key), U256::from(0x42));
+
+            // If the nested frame reverts, its writes are rolled back with the rest of its state.
+            child.set_transient_storage(key, U256::from(0x99));
+            child.flush().unwrap();
+            rt.set_state_root(&parent_root).unwrap();
+            m.system.reload().unwrap();
+            assert_eq!(m.system.get_transient_storage(key), U256::from(0x42));
+
+            // Otherwise its writes are visible to the parent.
+            let mut child = System::load(&rt).unwrap();
+            child.set_transient_storage(key, U256::from(0x99));
+            child.flush().unwrap();
+            m.system.reload().unwrap();
+            assert_eq!(m.system.get_transient_storage(key), U256::from(0x99));
+
+            // Transient storage is discarded once the transaction ends.
+            rt.set_origin(fvm_shared::address::Address::new_id(101));
+            let next = System::load(&rt).unwrap();
+            assert_eq!(next.get_transient_storage(key), U256::from(0));
+        };
+    }
 }
@@ -1,4 +1,5 @@
 use std::borrow::Cow;
+use std::collections::BTreeMap;
 
 use cid::multihash::Code;
 use fil_actors_evm_shared::{address::EthAddress, uints::U256};
@@ -16,7 +17,7 @@ use fvm_shared::error::{ErrorNumber, ExitCode};
 use fvm_shared::sys::SendFlags;
 use fvm_shared::{MethodNum, Response, IPLD_RAW, METHOD_SEND};
 
-use crate::state::{State, Tombstone};
+use crate::state::{State, Tombstone, TransientData};
 use crate::BytecodeHash;
 
 use cid::Cid;
@@ -91,6 +92,8 @@ pub struct System<'r, RT: Runtime> {
     bytecode: Option<EvmBytecode>,
     /// The contract's EVM storage slots.
     slots: StateKamt<RT::Blockstore>,
+    /// The contract's transient storage slots, live for the current top-level transaction.
+    transient_slots: BTreeMap<U256, U256>,
     /// The contracts "nonce" (incremented when creating new actors).
     pub(crate) nonce: u64,
     /// The last saved state root. None if the current state hasn't been saved yet.
@@ -114,6 +117,7 @@ impl<'r, RT: Runtime> System<'r, RT> {
         Self {
             rt,
             slots: StateKamt::new_with_config(store, KAMT_CONFIG.clone()),
+            transient_slots: BTreeMap::new(),
             nonce: 1,
             saved_state_root: None,
             bytecode: None,
@@ -182,6 +186,7 @@ impl<'r, RT: Runtime> System<'r, RT> {
             rt,
             slots: StateKamt::load_with_config(&state.contract_state, store, KAMT_CONFIG.clone())
                 .context_code(ExitCode::USR_ILLEGAL_STATE, "state not in blockstore")?,
+            transient_slots: live_transient_slots(rt, &state),
             nonce: state.nonce,
             saved_state_root: Some(state_root),
             bytecode: Some(EvmBytecode::new(state.bytecode, state.bytecode_hash)),
@@ -283,6 +288,10 @@ impl<'r, RT: Runtime> System<'r, RT> {
                     )?,
                     nonce: self.nonce,
                     tombstone: self.tombstone,
+                    transient_data: (!self.transient_slots.is_empty()).then(|| TransientData {
+                        slots: self.transient_slots.iter().map(|(k, v)| (*k, *v)).collect(),
+                        lifespan: crate::current_transient_data_lifespan(self.rt),
+                    }),
                 },
                 Code::Blake2b256,
             )
@@ -314,6 +323,7 @@ impl<'r, RT: Runtime> System<'r, RT> {
         self.slots
             .set_root(&state.contract_state)
             .context_code(ExitCode::USR_ILLEGAL_STATE, "state not in blockstore")?;
+        self.transient_slots = live_transient_slots(self.rt, &state);
         self.nonce = state.nonce;
         self.saved_state_root = Some(root);
         self.bytecode = Some(EvmBytecode::new(state.bytecode, state.bytecode_hash));
@@ -385,6 +395,24 @@ impl<'r, RT: Runtime> System<'r, RT> {
         Ok(())
     }
 
+    /// Get value of a transient storage key.
+    pub fn get_transient_storage(&self, key: U256) -> U256 {
+        self.transient_slots.get(&key).copied().unwrap_or_default()
+    }
+
+    /// Set value of a transient storage key.
+    pub fn set_transient_storage(&mut self, key: U256, value: U256) {
+        let changed = if value.is_zero() {
+            self.transient_slots.remove(&key).is_some()
+        } else {
+            self.transient_slots.insert(key, value) != Some(value)
+        };
+
+        if changed {
+            self.saved_state_root = None; // dirty.
+        }
+    }
+
     /// Resolve the address to the ethereum equivalent, if possible.
     ///
     /// - Eth f4 maps directly to an Eth address.
@@ -446,3 +474,14 @@ impl<'r, RT: Runtime> System<'r, RT> {
         self.tombstone = Some(crate::current_tombstone(self.rt));
     }
 }
+
+/// Returns the transient storage slots recorded in state, if they were written by the currently
+/// executing top-level transaction. Transient storage from earlier transactions is discarded.
+fn live_transient_slots(rt: &impl Runtime, state: &State) -> BTreeMap<U256, U256> {
+    match &state.transient_data {
+        Some(data) if data.lifespan == crate::current_transient_data_lifespan(rt) => {
+            data.slots.iter().copied().collect()
+        }
+        _ => BTreeMap::new(),
+    }
+}
@@ -75,6 +75,14 @@ pub(crate) fn current_tombstone(rt: &impl Runtime) -> Tombstone {
     Tombstone { origin: rt.message().origin().id().unwrap(), nonce: rt.message().nonce() }
 }
 
+/// Returns the lifespan of transient storage written by the currently executing message.
+pub(crate) fn current_transient_data_lifespan(rt: &impl Runtime) -> TransientDataLifespan {
+    TransientDataLifespan {
+        origin: rt.message().origin().id().unwrap(),
+        nonce: rt.message().nonce(),
+    }
+}
+
 /// Returns true if the contract is "dead". A contract is dead if:
 ///
 /// 1. It has a tombstone.
@@ -314,6 +322,9 @@ impl EvmContractActor {
         }
     }
 
+    /// Returns the value of a storage slot, as for eth_getStorageAt.
+    /// Slots that have never been written (including on contracts whose constructor
+    /// wrote no storage) read as zero.
     pub fn storage_at<RT>(
         rt: &RT,
         params: GetStorageAtParams,
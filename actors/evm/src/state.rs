@@ -17,6 +17,24 @@ pub struct Tombstone {
     pub nonce: u64,
 }
 
+/// Identifies the top-level transaction in which transient storage was written.
+#[derive(Copy, Clone, Debug, Eq, PartialEq, Serialize_tuple, Deserialize_tuple)]
+pub struct TransientDataLifespan {
+    /// The origin of the message in which the transient storage was written.
+    pub origin: ActorID,
+    /// The nonce of the message in which the transient storage was written.
+    pub nonce: u64,
+}
+
+/// Transient storage (EIP-1153) written by a contract during a top-level transaction.
+#[derive(Clone, Debug, Eq, PartialEq, Serialize_tuple, Deserialize_tuple)]
+pub struct TransientData {
+    /// The non-zero transient storage slots, in key order.
+    pub slots: Vec<(U256, U256)>,
+    /// The transaction in which the slots were written.
+    pub lifespan: TransientDataLifespan,
+}
+
 /// A Keccak256 digest of EVM bytecode.
 #[derive(Deserialize, Serialize, Clone, Copy, Eq, PartialEq)]
 #[serde(transparent)]
@@ -127,6 +145,14 @@ pub struct State {
     ///
     /// See https://github.com/filecoin-project/ref-fvm/issues/1174 for some context.
     pub tombstone: Option<Tombstone>,
+
+    /// Transient storage written during the current top-level transaction, if any.
+    ///
+    /// Transient storage is held in memory while the contract executes. It's recorded here,
+    /// and never in the contract state KAMT, only so that it's visible to re-entrant calls in
+    /// the same transaction. Like the tombstone, it's ignored once the transaction that wrote it
+    /// has ended.
+    pub transient_data: Option<TransientData>,
 }
 
 #[cfg(test)]
@@ -50,6 +50,16 @@ pub fn new_contract(name: &str, init: &str, body: &str) -> Result<Vec<u8>, etk_a
     let mut init_code = Vec::new();
     let mut ingest_init = Ingest::new(&mut init_code);
     ingest_init.ingest(name, init)?;
+    Ok(new_contract_from_code(init_code, body_code))
+}
+
+#[allow(dead_code)]
+/// Creates a new EVM contract constructor bytecode (initcode) from already assembled code,
+/// for opcodes the assembler does not support.
+/// Arguments:
+/// - init_code is the initializer code, which will run first at contract construction.
+/// - body_code is the actual contract code.
+pub fn new_contract_from_code(mut init_code: Vec<u8>, mut body_code: Vec<u8>) -> Vec<u8> {
     // synthesize contract constructor
     let body_code_len = body_code.len();
     let body_code_offset = init_code.len()
@@ -89,5 +99,5 @@ pub fn new_contract(name: &str, init: &str, body: &str) -> Result<Vec<u8>, etk_a
     contract_code.append(&mut init_code);
     contract_code.append(&mut constructor_code);
     contract_code.append(&mut body_code);
-    Ok(contract_code)
+    contract_code
 }
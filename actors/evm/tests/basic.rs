@@ -160,6 +160,32 @@ sstore";
     rt.verify();
 }
 
+#[test]
+fn get_storage_at_without_initialized_storage() {
+    // A contract whose constructor never writes to storage.
+    let init_code = asm::new_contract("get_storage_at_empty", "", "return").unwrap();
+    let rt = util::construct_and_verify(init_code);
+    rt.reset();
+
+    let sender = Address::new_id(0);
+    rt.caller.replace(sender);
+    for key in [0u64, 0x8965] {
+        let params = evm::GetStorageAtParams { storage_key: key.into() };
+        rt.expect_validate_caller_addr(vec![sender]);
+        let value: U256 = rt
+            .call::<evm::EvmContractActor>(
+                evm::Method::GetStorageAt as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )
+            .unwrap()
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        assert_eq!(U256::from(0), value);
+    }
+}
+
 #[test]
 fn test_push_last_byte() {
     // 60 01 # len
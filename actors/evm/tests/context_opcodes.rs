@@ -95,3 +95,47 @@ fn test_prevrandao() {
         rt.reset();
     }
 }
+
+#[allow(dead_code)]
+pub fn blob_contract() -> Vec<u8> {
+    use fil_actor_evm::interpreter::opcodes::*;
+    // Assembled by hand, as the assembler predates the blob opcodes.
+    let body = vec![
+        // blobhash(0) at 0x00
+        PUSH1,
+        0x00,
+        BLOBHASH,
+        PUSH1,
+        0x00,
+        MSTORE,
+        // blobbasefee at 0x20
+        BLOBBASEFEE,
+        PUSH1,
+        0x20,
+        MSTORE,
+        // return both values 0x00-0x40
+        PUSH1,
+        0x40,
+        PUSH1,
+        0x00,
+        RETURN,
+        // unreachable blob opcodes must not affect deployment
+        PUSH1,
+        0x01,
+        BLOBHASH,
+        BLOBBASEFEE,
+        STOP,
+    ];
+    asm::new_contract_from_code(Vec::new(), body)
+}
+
+#[test]
+fn test_blob_opcodes() {
+    let rt = util::construct_and_verify(blob_contract());
+
+    let result = util::invoke_contract(&rt, &[]);
+    rt.verify();
+    let mut expected = [0u8; 64];
+    expected[63] = 1;
+    assert_eq!(result, expected, "expected zero blob hash and minimum blob base fee");
+}
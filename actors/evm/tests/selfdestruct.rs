@@ -13,6 +13,7 @@ use fvm_shared::{
 };
 use num_traits::Zero;
 
+mod asm;
 mod util;
 
 #[test]
@@ -135,3 +136,46 @@ fn test_selfdestruct_missing_beneficiary() {
     assert_eq!(state.tombstone, None);
     rt.verify();
 }
+
+#[test]
+fn test_selfdestruct_to_placeholder_beneficiary() {
+    // A beneficiary with no actor behind its Ethereum address. Sending funds to it creates
+    // a placeholder actor, so the self-destruct succeeds.
+    let beneficiary_eth = EthAddress(hex_literal::hex!("00112233445566778899aabbccddeeff00112233"));
+    let beneficiary = Address::from(beneficiary_eth);
+    assert!(beneficiary_eth.as_id().is_none());
+
+    let bytecode = asm::new_contract(
+        "selfdestruct-placeholder",
+        "",
+        r#"
+push20 0x00112233445566778899aabbccddeeff00112233
+selfdestruct
+"#,
+    )
+    .unwrap();
+
+    let contract = Address::new_id(100);
+    let token_amount = TokenAmount::from_whole(2);
+    let rt = util::init_construct_and_verify(bytecode, |rt| {
+        rt.actor_code_cids.borrow_mut().insert(contract, *EVM_ACTOR_CODE_ID);
+        rt.set_origin(contract);
+        rt.set_balance(token_amount.clone());
+    });
+
+    rt.expect_send_simple(beneficiary, METHOD_SEND, None, token_amount, None, ExitCode::OK);
+    assert!(util::invoke_contract(&rt, &[]).is_empty());
+    rt.verify();
+    let state: State = rt.get_state();
+    assert_eq!(state.tombstone, Some(Tombstone { origin: 100, nonce: 0 }));
+
+    // Re-entering within the same message runs the code again, moving any remaining funds.
+    rt.expect_send_simple(beneficiary, METHOD_SEND, None, TokenAmount::zero(), None, ExitCode::OK);
+    assert!(util::invoke_contract(&rt, &[]).is_empty());
+    rt.verify();
+
+    // In a later message the contract is dead and calls do nothing.
+    rt.set_origin(Address::new_id(101));
+    assert!(util::invoke_contract(&rt, &[]).is_empty());
+    rt.verify();
+}
@@ -0,0 +1,112 @@
+use fil_actor_evm as evm;
+use fil_actor_evm::State;
+use fil_actors_evm_shared::uints::U256;
+use fvm_ipld_encoding::ipld_block::IpldBlock;
+use fvm_ipld_encoding::BytesSer;
+use fvm_shared::address::Address;
+
+mod asm;
+mod util;
+
+#[allow(dead_code)]
+pub fn transient_storage_contract() -> Vec<u8> {
+    use fil_actor_evm::interpreter::opcodes::*;
+    // Assembled by hand, as the assembler predates the transient storage opcodes.
+    // Returns the previous value of transient slot 0. If called with input, first stores the
+    // first input word in slot 0, then reverts (with the previous value) if the second word is
+    // non-zero.
+    let body = vec![
+        // mem[0x00] = tload(0)
+        PUSH1,
+        0x00,
+        TLOAD,
+        PUSH1,
+        0x00,
+        MSTORE,
+        // return it if there's no input
+        CALLDATASIZE,
+        ISZERO,
+        PUSH1,
+        0x1d,
+        JUMPI,
+        // tstore(0, calldata[0x00])
+        PUSH1,
+        0x00,
+        CALLDATALOAD,
+        PUSH1,
+        0x00,
+        TSTORE,
+        // return if calldata[0x20] is zero, otherwise revert
+        PUSH1,
+        0x20,
+        CALLDATALOAD,
+        ISZERO,
+        PUSH1,
+        0x1d,
+        JUMPI,
+        PUSH1,
+        0x20,
+        PUSH1,
+        0x00,
+        REVERT,
+        // 0x1d: return mem[0x00..0x20]
+        JUMPDEST,
+        PUSH1,
+        0x20,
+        PUSH1,
+        0x00,
+        RETURN,
+    ];
+    asm::new_contract_from_code(Vec::new(), body)
+}
+
+fn store_params(value: u64, revert: bool) -> Vec<u8> {
+    let mut params = [0u8; 64];
+    params[24..32].copy_from_slice(&value.to_be_bytes());
+    params[63] = revert as u8;
+    params.to_vec()
+}
+
+#[test]
+fn test_transient_storage_visible_to_nested_call() {
+    let rt = util::construct_and_verify(transient_storage_contract());
+    let state: State = rt.get_state();
+    let contract_state = state.contract_state;
+
+    // The outer frame stores a transient value.
+    let result = util::invoke_contract(&rt, &store_params(42, false));
+    assert_eq!(U256::from_big_endian(&result), U256::zero());
+
+    // A nested call into the contract in the same transaction reads it.
+    let result = util::invoke_contract(&rt, &[]);
+    assert_eq!(U256::from_big_endian(&result), U256::from(42));
+
+    // Transient storage is never written to the contract storage.
+    let state: State = rt.get_state();
+    assert_eq!(contract_state, state.contract_state);
+
+    // The value is gone in the next transaction.
+    rt.set_origin(Address::new_id(1000));
+    let result = util::invoke_contract(&rt, &[]);
+    assert_eq!(U256::from_big_endian(&result), U256::zero());
+}
+
+#[test]
+fn test_transient_storage_discarded_on_revert() {
+    let rt = util::construct_and_verify(transient_storage_contract());
+
+    let result = util::invoke_contract(&rt, &store_params(1, false));
+    assert_eq!(U256::from_big_endian(&result), U256::zero());
+
+    // A reverted call's transient store is rolled back.
+    rt.expect_validate_caller_any();
+    let result = rt.call::<evm::EvmContractActor>(
+        evm::Method::InvokeContract as u64,
+        IpldBlock::serialize_cbor(&BytesSer(&store_params(2, true))).unwrap(),
+    );
+    assert_eq!(result.unwrap_err().exit_code(), evm::EVM_CONTRACT_REVERTED);
+    rt.verify();
+
+    let result = util::invoke_contract(&rt, &[]);
+    assert_eq!(U256::from_big_endian(&result), U256::from(1));
+}
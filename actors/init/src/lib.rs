@@ -9,8 +9,9 @@ use fil_actors_runtime::{
     actor_dispatch, actor_error, extract_send_result, ActorContext, ActorError, AsActorError,
     EAM_ACTOR_ADDR, SYSTEM_ACTOR_ADDR,
 };
-use fvm_shared::address::Address;
+use fvm_shared::address::{Address, Protocol};
 use fvm_shared::error::ExitCode;
+use fvm_shared::version::NetworkVersion;
 use fvm_shared::{ActorID, METHOD_CONSTRUCTOR};
 use num_derive::FromPrimitive;
 
@@ -24,6 +25,11 @@ mod types;
 #[cfg(feature = "fil-actor")]
 fil_actors_runtime::wasm_trampoline!(Actor);
 
+/// The network version from which account actors may change their mapped key address.
+/// Account key rotation activates with network version 24; actors running at network
+/// version 23 (v14) keep the previous behaviour.
+pub const CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION: NetworkVersion = NetworkVersion::V24;
+
 /// Init actor methods available
 #[derive(FromPrimitive)]
 #[repr(u64)]
@@ -31,6 +37,9 @@ pub enum Method {
     Constructor = METHOD_CONSTRUCTOR,
     Exec = 2,
     Exec4 = 3,
+    ChangeAccountAddress = 4,
+    // Method numbers derived from FRC-0042 standards
+    ResolveAddressExported = frc42_dispatch::method_hash!("ResolveAddress"),
 }
 
 /// Init actor
@@ -107,7 +116,13 @@ impl Actor {
         Ok(ExecReturn { id_address: Address::new_id(id_address), robust_address })
     }
 
-    /// Exec4 init actor
+    /// Exec4 creates a new actor with a deterministic f4 address, derived from the caller's
+    /// ID (as the address namespace) and the provided subaddress.
+    /// Fails with USR_FORBIDDEN if the f4 address is already bound to an actor other than
+    /// a placeholder, or to a deleted actor. An existing placeholder is replaced in place.
+    /// Only the EAM may call this method, as it is the only active f4 address manager.
+    /// Returns the new actor's ID address and its (non-deterministic) robust address;
+    /// the f4 address is known to the caller in advance.
     pub fn exec4(rt: &impl Runtime, params: Exec4Params) -> Result<Exec4Return, ActorError> {
         rt.validate_immediate_caller_is(std::iter::once(&EAM_ACTOR_ADDR))?;
         // Compute the f4 address.
@@ -162,6 +177,56 @@ impl Actor {
 
         Ok(Exec4Return { id_address: Address::new_id(id_address), robust_address })
     }
+
+    /// Re-maps the calling account actor from its old public key address to a new one.
+    /// Called by an account actor when its key is changed, so that the new key address resolves
+    /// to the existing actor ID. The old key address remains mapped to the same actor, so funds
+    /// later sent to it reach the rotated account rather than creating a new account controlled
+    /// by the old key.
+    /// Not available before CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION.
+    pub fn change_account_address(
+        rt: &impl Runtime,
+        params: ChangeAccountAddressParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_type(std::iter::once(&Type::Account))?;
+        if rt.network_version() < CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION {
+            return Err(actor_error!(forbidden;
+                "changing account address not allowed before network version {}",
+                CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION));
+        }
+        match params.new_address.protocol() {
+            Protocol::Secp256k1 | Protocol::BLS => {}
+            protocol => {
+                return Err(actor_error!(illegal_argument;
+                    "new address must use BLS or SECP protocol, got {}", protocol));
+            }
+        }
+        let caller_id = rt.message().caller().id().unwrap();
+        rt.transaction(|s: &mut State, rt| {
+            s.change_account_address(
+                rt.store(),
+                caller_id,
+                &params.old_address,
+                &params.new_address,
+            )
+        })
+        .context("failed to change account address")
+    }
+
+    /// Resolves an address to the ID address of the actor it is mapped to.
+    /// ID addresses are returned unchanged, without checking that the actor exists.
+    /// Fails with USR_NOT_FOUND if the address is not mapped to any actor.
+    pub fn resolve_address(
+        rt: &impl Runtime,
+        params: ResolveAddressParams,
+    ) -> Result<ResolveAddressReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let st: State = rt.state()?;
+        let id_address = st
+            .resolve_address(rt.store(), &params.address)?
+            .ok_or_else(|| actor_error!(not_found, "address {} not found", params.address))?;
+        Ok(ResolveAddressReturn { id_address })
+    }
 }
 
 impl ActorCode for Actor {
@@ -175,6 +240,8 @@ impl ActorCode for Actor {
         Constructor => constructor,
         Exec => exec,
         Exec4 => exec4,
+        ChangeAccountAddress => change_account_address,
+        ResolveAddressExported => resolve_address,
     }
 }
 
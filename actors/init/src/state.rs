@@ -71,6 +71,42 @@ impl State {
         Ok((id, existing))
     }
 
+    /// Maps a new public key address to an account actor whose key has changed from the old address.
+    /// The old address is left mapped to the actor, so that sends to it still resolve to the
+    /// account instead of creating a fresh one for the old (possibly compromised) key.
+    /// Fails if the old address is not mapped to the given actor ID, or if the new address is
+    /// already mapped to any actor.
+    pub fn change_account_address<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        id: ActorID,
+        old_addr: &Address,
+        new_addr: &Address,
+    ) -> Result<(), ActorError> {
+        let mut map = AddressMap::load(store, &self.address_map, DEFAULT_HAMT_CONFIG, "addresses")?;
+        match map.get(old_addr)? {
+            Some(existing) if *existing == id => {}
+            _ => {
+                return Err(actor_error!(
+                    forbidden,
+                    "address {} is not mapped to actor {}",
+                    old_addr,
+                    id
+                ));
+            }
+        }
+        let is_new = map.set_if_absent(new_addr, id)?;
+        if !is_new {
+            return Err(actor_error!(
+                forbidden,
+                "address {} is already allocated in the address map",
+                new_addr
+            ));
+        }
+        self.address_map = map.flush()?;
+        Ok(())
+    }
+
     /// ResolveAddress resolves an address to an ID-address, if possible.
     /// If the provided address is an ID address, it is returned as-is.
     /// This means that mapped ID-addresses (which should only appear as values, not keys) and
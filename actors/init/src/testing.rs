@@ -32,6 +32,9 @@ pub fn check_state_invariants<BS: Blockstore>(
     let mut init_summary = StateSummary { ids_by_address: HashMap::new(), next_id: state.next_id };
 
     let mut stable_address_by_id = HashMap::<ActorID, Address>::new();
+    // An account that has rotated its key stays mapped from every key address it has used,
+    // so key addresses may repeat an ID but must not share it with an actor address.
+    let mut key_address_by_id = HashMap::<ActorID, Address>::new();
     let mut delegated_address_by_id = HashMap::<ActorID, Address>::new();
 
     match AddressMap::load(store, &state.address_map, DEFAULT_HAMT_CONFIG, "addresses") {
@@ -54,6 +57,9 @@ pub fn check_state_invariants<BS: Blockstore>(
                             ));
                         }
                     }
+                    Protocol::Secp256k1 | Protocol::BLS => {
+                        key_address_by_id.insert(*actor_id, key);
+                    }
                     _ => {
                         if let Some(duplicate) = stable_address_by_id.insert(*actor_id, key) {
                             acc.add(format!(
@@ -69,6 +75,12 @@ pub fn check_state_invariants<BS: Blockstore>(
             });
 
             acc.require_no_error(ret, "error iterating address map");
+
+            for (actor_id, key) in &key_address_by_id {
+                if let Some(stable) = stable_address_by_id.get(actor_id) {
+                    acc.add(format!("duplicate mapping to ID {actor_id}: {key} {stable}"));
+                }
+            }
         }
         Err(e) => acc.add(format!("error loading address map: {e}")),
     }
@@ -38,3 +38,25 @@ pub struct Exec4Params {
 
 /// Init actor Exec4 Return value
 pub type Exec4Return = ExecReturn;
+
+/// Init actor ChangeAccountAddress Params
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct ChangeAccountAddressParams {
+    pub old_address: Address,
+    pub new_address: Address,
+}
+
+/// Init actor ResolveAddress Params
+#[derive(Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ResolveAddressParams {
+    pub address: Address,
+}
+
+/// Init actor ResolveAddress Return value
+#[derive(Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ResolveAddressReturn {
+    /// ID based address the queried address is mapped to
+    pub id_address: Address,
+}
@@ -6,9 +6,11 @@ use std::cell::RefCell;
 use cid::Cid;
 use fil_actor_init::testing::check_state_invariants;
 use fil_actor_init::{
-    Actor as InitActor, ConstructorParams, Exec4Params, Exec4Return, ExecParams, ExecReturn,
-    Method, State,
+    Actor as InitActor, ChangeAccountAddressParams, ConstructorParams, Exec4Params, Exec4Return,
+    ExecParams, ExecReturn, Method, ResolveAddressParams, ResolveAddressReturn, State,
+    CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION,
 };
+use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::runtime::Runtime;
 use fil_actors_runtime::{test_utils::*, EAM_ACTOR_ADDR, EAM_ACTOR_ID};
 use fil_actors_runtime::{
@@ -19,6 +21,7 @@ use fvm_ipld_encoding::RawBytes;
 use fvm_shared::address::Address;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
+use fvm_shared::version::NetworkVersion;
 use fvm_shared::{ActorID, HAMT_BIT_WIDTH, METHOD_CONSTRUCTOR};
 use num_traits::Zero;
 use serde::Serialize;
@@ -411,6 +414,155 @@ fn call_exec4_placeholder() {
     assert_eq!(expected_id_addr, resolved_id, "f4 address not assigned to the right actor");
 }
 
+#[test]
+fn change_account_address() {
+    let rt = MockRuntime {
+        network_version: CHANGE_ACCOUNT_ADDRESS_NETWORK_VERSION,
+        ..construct_runtime()
+    };
+    construct_and_verify(&rt);
+
+    let old_addr = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    let new_addr = Address::new_bls(&[3; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    let taken_addr = Address::new_secp256k1(&[4; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    let mut st: State = rt.get_state();
+    let (account_id, _) = st.map_addresses_to_id(rt.store(), &old_addr, None).unwrap();
+    let (other_id, _) = st.map_addresses_to_id(rt.store(), &taken_addr, None).unwrap();
+    rt.replace_state(&st);
+
+    let change = |caller_code: Cid, caller: ActorID, old_address: Address, new_address: Address| {
+        rt.set_caller(caller_code, Address::new_id(caller));
+        rt.expect_validate_caller_type(vec![Type::Account]);
+        let ret = rt.call::<InitActor>(
+            Method::ChangeAccountAddress as u64,
+            IpldBlock::serialize_cbor(&ChangeAccountAddressParams { old_address, new_address })
+                .unwrap(),
+        );
+        rt.verify();
+        ret
+    };
+
+    // Only account actors may call.
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        change(*MULTISIG_ACTOR_CODE_ID, account_id, old_addr, new_addr),
+    );
+    // The new address must be a key address.
+    expect_abort(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        change(*ACCOUNT_ACTOR_CODE_ID, account_id, old_addr, Address::new_actor(b"x")),
+    );
+    // The old address must belong to the caller.
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        change(*ACCOUNT_ACTOR_CODE_ID, other_id, old_addr, new_addr),
+    );
+    // The new address must not already be allocated.
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        change(*ACCOUNT_ACTOR_CODE_ID, account_id, old_addr, taken_addr),
+    );
+
+    change(*ACCOUNT_ACTOR_CODE_ID, account_id, old_addr, new_addr).unwrap();
+    let st: State = rt.get_state();
+    assert_eq!(
+        Some(Address::new_id(account_id)),
+        st.resolve_address(rt.store(), &new_addr).unwrap()
+    );
+    // The old key address still resolves to the account, so funds sent to it reach the rotated
+    // account instead of creating a new account controlled by the old key.
+    assert_eq!(
+        Some(Address::new_id(account_id)),
+        st.resolve_address(rt.store(), &old_addr).unwrap()
+    );
+    assert_eq!(
+        Some(Address::new_id(other_id)),
+        st.resolve_address(rt.store(), &taken_addr).unwrap()
+    );
+    check_state(&rt);
+
+    // The account can't rotate back to its old key, which is still mapped.
+    expect_abort(
+        ExitCode::USR_FORBIDDEN,
+        change(*ACCOUNT_ACTOR_CODE_ID, account_id, new_addr, old_addr),
+    );
+    check_state(&rt);
+}
+
+#[test]
+fn change_account_address_not_allowed_before_activation() {
+    let rt = MockRuntime { network_version: NetworkVersion::V23, ..construct_runtime() };
+    construct_and_verify(&rt);
+
+    let old_address = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    let new_address = Address::new_bls(&[3; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    let mut st: State = rt.get_state();
+    let (account_id, _) = st.map_addresses_to_id(rt.store(), &old_address, None).unwrap();
+    rt.replace_state(&st);
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(account_id));
+    rt.expect_validate_caller_type(vec![Type::Account]);
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "not allowed before network version",
+        rt.call::<InitActor>(
+            Method::ChangeAccountAddress as u64,
+            IpldBlock::serialize_cbor(&ChangeAccountAddressParams { old_address, new_address })
+                .unwrap(),
+        ),
+    );
+    rt.verify();
+    let st: State = rt.get_state();
+    assert_eq!(None, st.resolve_address(rt.store(), &new_address).unwrap());
+}
+
+#[test]
+fn resolve_address() {
+    let rt = construct_runtime();
+    construct_and_verify(&rt);
+
+    let key_addr = Address::new_secp256k1(&[2; fvm_shared::address::SECP_PUB_LEN]).unwrap();
+    let mut st: State = rt.get_state();
+    let (account_id, _) = st.map_addresses_to_id(rt.store(), &key_addr, None).unwrap();
+    rt.replace_state(&st);
+
+    let resolve = |address: Address| {
+        rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(2000));
+        rt.expect_validate_caller_any();
+        let ret = rt.call::<InitActor>(
+            Method::ResolveAddressExported as u64,
+            IpldBlock::serialize_cbor(&ResolveAddressParams { address }).unwrap(),
+        );
+        rt.verify();
+        ret.map(|v| v.unwrap().deserialize::<ResolveAddressReturn>().unwrap().id_address)
+    };
+
+    // Mapped addresses resolve to their actor ID.
+    assert_eq!(Address::new_id(account_id), resolve(key_addr).unwrap());
+    // ID addresses pass through unchanged.
+    assert_eq!(Address::new_id(1234), resolve(Address::new_id(1234)).unwrap());
+    // Unknown addresses are not found.
+    expect_abort(ExitCode::USR_NOT_FOUND, resolve(Address::new_actor(b"unknown")));
+
+    // Robust addresses are resolvable after exec.
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(1001));
+    let unique_address = Address::new_actor(b"multisig");
+    rt.new_actor_addr.replace(Some(unique_address));
+    let expected_id = account_id + 1;
+    rt.expect_create_actor(*MULTISIG_ACTOR_CODE_ID, expected_id, None);
+    rt.expect_send_simple(
+        Address::new_id(expected_id),
+        METHOD_CONSTRUCTOR,
+        IpldBlock::serialize_cbor(&"").unwrap(),
+        TokenAmount::zero(),
+        None,
+        ExitCode::OK,
+    );
+    let exec_ret = exec_and_verify(&rt, *MULTISIG_ACTOR_CODE_ID, &"").unwrap();
+    assert_eq!(exec_ret.id_address, resolve(unique_address).unwrap());
+    check_state(&rt);
+}
+
 fn construct_and_verify(rt: &MockRuntime) {
     rt.set_caller(*SYSTEM_ACTOR_CODE_ID, SYSTEM_ACTOR_ADDR);
     rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
@@ -1,3 +1,4 @@
+use cid::Cid;
 use fil_actors_runtime::runtime::Runtime;
 use fil_actors_runtime::{ActorError, EventBuilder};
 use fvm_shared::deal::DealID;
@@ -6,14 +7,16 @@ use fvm_shared::ActorID;
 /// Indicates a deal has been published.
 pub fn deal_published(
     rt: &impl Runtime,
+    deal_id: DealID,
     client: ActorID,
     provider: ActorID,
-    deal_id: DealID,
+    piece_cid: &Cid,
 ) -> Result<(), ActorError> {
     rt.emit_event(
         &EventBuilder::new()
             .typ("deal-published")
             .with_parties(deal_id, client, provider)
+            .with_piece(piece_cid)
             .build()?,
     )
 }
@@ -24,11 +27,13 @@ pub fn deal_activated(
     deal_id: DealID,
     client: ActorID,
     provider: ActorID,
+    piece_cid: &Cid,
 ) -> Result<(), ActorError> {
     rt.emit_event(
         &EventBuilder::new()
             .typ("deal-activated")
             .with_parties(deal_id, client, provider)
+            .with_piece(piece_cid)
             .build()?,
     )
 }
@@ -39,11 +44,13 @@ pub fn deal_terminated(
     deal_id: DealID,
     client: ActorID,
     provider: ActorID,
+    piece_cid: &Cid,
 ) -> Result<(), ActorError> {
     rt.emit_event(
         &EventBuilder::new()
             .typ("deal-terminated")
             .with_parties(deal_id, client, provider)
+            .with_piece(piece_cid)
             .build()?,
     )
 }
@@ -54,11 +61,13 @@ pub fn deal_completed(
     deal_id: DealID,
     client: ActorID,
     provider: ActorID,
+    piece_cid: &Cid,
 ) -> Result<(), ActorError> {
     rt.emit_event(
         &EventBuilder::new()
             .typ("deal-completed")
             .with_parties(deal_id, client, provider)
+            .with_piece(piece_cid)
             .build()?,
     )
 }
@@ -74,3 +83,13 @@ impl WithParties for EventBuilder {
             .field_indexed("provider", &provider)
     }
 }
+
+trait WithPiece {
+    fn with_piece(self, piece_cid: &Cid) -> EventBuilder;
+}
+
+impl WithPiece for EventBuilder {
+    fn with_piece(self, piece_cid: &Cid) -> EventBuilder {
+        self.field_indexed("piece-cid", piece_cid)
+    }
+}
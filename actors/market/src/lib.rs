@@ -99,6 +99,10 @@ pub enum Method {
     GetDealSectorExported = frc42_dispatch::method_hash!("GetDealSector"),
     SettleDealPaymentsExported = frc42_dispatch::method_hash!("SettleDealPayments"),
     SectorContentChangedExported = ext::miner::SECTOR_CONTENT_CHANGED,
+    GetBalancesExported = frc42_dispatch::method_hash!("GetBalances"),
+    ReassignDealsExported = frc42_dispatch::method_hash!("ReassignDeals"),
+    CancelUnactivatedDealExported = frc42_dispatch::method_hash!("CancelUnactivatedDeal"),
+    CheckDealsForActivationExported = frc42_dispatch::method_hash!("CheckDealsForActivation"),
 }
 
 /// Market Actor
@@ -190,6 +194,39 @@ impl Actor {
         Ok(GetBalanceReturn { balance, locked })
     }
 
+    /// Returns the escrow balance and locked amount for each of a list of addresses,
+    /// in the order given. Addresses that cannot be resolved have zero balances.
+    fn get_balances(
+        rt: &impl Runtime,
+        params: GetBalancesParams,
+    ) -> Result<GetBalancesReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+
+        let store = rt.store();
+        let st: State = rt.state()?;
+        let balances = BalanceTable::from_root(store, &st.escrow_table, "escrow table")?;
+        let locks = BalanceTable::from_root(store, &st.locked_table, "locked table")?;
+        let results = params
+            .accounts
+            .iter()
+            .map(|account| match rt.resolve_address(account) {
+                Some(id) => {
+                    let account = Address::new_id(id);
+                    Ok(GetBalanceReturn {
+                        balance: balances.get(&account)?,
+                        locked: locks.get(&account)?,
+                    })
+                }
+                None => Ok(GetBalanceReturn {
+                    balance: TokenAmount::zero(),
+                    locked: TokenAmount::zero(),
+                }),
+            })
+            .collect::<Result<_, ActorError>>()?;
+
+        Ok(GetBalancesReturn { balances: results })
+    }
+
     /// Publish a new set of storage deals (not yet included in a sector).
     fn publish_storage_deals(
         rt: &impl Runtime,
@@ -218,16 +255,7 @@ impl Actor {
         }
 
         let caller = rt.message().caller();
-        let caller_status: ext::miner::IsControllingAddressReturn =
-            deserialize_block(extract_send_result(rt.send_simple(
-                &Address::new_id(provider_id),
-                ext::miner::IS_CONTROLLING_ADDRESS_EXPORTED,
-                IpldBlock::serialize_cbor(&ext::miner::IsControllingAddressParam {
-                    address: caller,
-                })?,
-                TokenAmount::zero(),
-            ))?)?;
-        if !caller_status.is_controlling {
+        if !is_controlling_address(rt, provider_id, caller)? {
             return Err(actor_error!(
                 forbidden,
                 "caller {} is not worker or control address of provider {}",
@@ -487,9 +515,10 @@ impl Actor {
 
             emit::deal_published(
                 rt,
+                deal_id,
                 valid_deal.proposal.client.id().unwrap(),
                 valid_deal.proposal.provider.id().unwrap(),
-                deal_id,
+                &valid_deal.proposal.piece_cid,
             )?;
         }
 
@@ -538,6 +567,83 @@ impl Actor {
         Ok(VerifyDealsForActivationReturn { unsealed_cids })
     }
 
+    /// Checks whether a set of deals, grouped by sector, could be activated now by a provider,
+    /// returning the deal weights for each sector and a result for each deal.
+    /// Client funds need not be checked here: a deal's payment and collateral are locked in the
+    /// client's escrow when it is published, and remain locked until it is activated or removed.
+    /// This is a dry run of activation for external callers and does not modify state.
+    fn check_deals_for_activation(
+        rt: &impl Runtime,
+        params: CheckDealsForActivationParams,
+    ) -> Result<CheckDealsForActivationReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let provider = rt.resolve_address(&params.provider).ok_or_else(|| {
+            actor_error!(illegal_argument, "failed to resolve address {}", params.provider)
+        })?;
+        let provider = Address::new_id(provider);
+        let curr_epoch = rt.curr_epoch();
+
+        let st: State = rt.state()?;
+        let proposals = st.load_proposals(rt.store())?;
+        let states = st.load_deal_states(rt.store())?;
+        let pending_deals = st.load_pending_deals(rt.store())?;
+
+        let mut sectors = Vec::with_capacity(params.sectors.len());
+        for sector in params.sectors {
+            let sector_size = sector
+                .sector_type
+                .sector_size()
+                .map_err(|e| actor_error!(illegal_argument, "sector size unknown: {}", e))?;
+            let duration = sector.sector_expiry - curr_epoch;
+
+            let mut batch_gen = BatchReturnGen::new(sector.deal_ids.len());
+            let mut seen_deal_ids = BTreeSet::new();
+            let mut deal_space = BigInt::zero();
+            let mut verified_deal_space = BigInt::zero();
+            for &deal_id in &sector.deal_ids {
+                if !seen_deal_ids.insert(deal_id) {
+                    batch_gen.add_fail(ExitCode::USR_ILLEGAL_ARGUMENT);
+                    continue;
+                }
+                let proposal = match preactivate_deal(
+                    rt,
+                    deal_id,
+                    &proposals,
+                    &states,
+                    &pending_deals,
+                    &provider,
+                    sector.sector_expiry,
+                    curr_epoch,
+                    st.next_id,
+                )? {
+                    Ok(p) => p,
+                    Err(e) => {
+                        batch_gen.add_fail(e.exit_code());
+                        continue;
+                    }
+                };
+
+                if proposal.verified_deal {
+                    verified_deal_space += proposal.piece_size.0;
+                } else {
+                    deal_space += proposal.piece_size.0;
+                }
+                batch_gen.add_success();
+            }
+
+            let deal_results = batch_gen.gen();
+            let fits = &deal_space + &verified_deal_space <= BigInt::from(sector_size as u64);
+            sectors.push(SectorDealsCheck {
+                valid: deal_results.all_ok() && fits,
+                deal_weight: deal_space * duration,
+                verified_deal_weight: verified_deal_space * duration,
+                deal_results,
+            });
+        }
+
+        Ok(CheckDealsForActivationReturn { sectors })
+    }
+
     /// Activate a set of deals grouped by sector, returning the size and
     /// extra info about verified deals.
     /// Sectors' deals are activated in parameter-defined order.
@@ -650,6 +756,7 @@ impl Actor {
                         *deal_id,
                         proposal.client.id().unwrap(),
                         proposal.provider.id().unwrap(),
+                        &proposal.piece_cid,
                     )?;
                 }
 
@@ -750,6 +857,7 @@ impl Actor {
                         deal_id,
                         proposal.client.id().unwrap(),
                         proposal.provider.id().unwrap(),
+                        &proposal.piece_cid,
                     )?;
 
                     // Remove any verified allocation ID for the pending deal.
@@ -858,6 +966,7 @@ impl Actor {
                     id,
                     deal.client.id().unwrap(),
                     deal.provider.id().unwrap(),
+                    &deal.piece_cid,
                 )?;
             }
 
@@ -971,6 +1080,7 @@ impl Actor {
                                 deal_id,
                                 deal_proposal.client.id().unwrap(),
                                 deal_proposal.provider.id().unwrap(),
+                                &deal_proposal.piece_cid,
                             )?;
                         }
                     } else {
@@ -1003,6 +1113,7 @@ impl Actor {
                             deal_id,
                             deal_proposal.client.id().unwrap(),
                             deal_proposal.provider.id().unwrap(),
+                            &deal_proposal.piece_cid,
                         )?;
                     }
                 }
@@ -1127,6 +1238,9 @@ impl Actor {
     /// the deal finishes (either normally or by termination).
     /// Returns USR_NOT_FOUND if the deal doesn't exist (yet), or EX_DEAL_EXPIRED if the deal
     /// has been removed from state.
+    /// If the proposal exists but there is no deal state entry, the deal has been published
+    /// but not yet activated, and both returned epochs are EPOCH_UNDEFINED.
+    /// A deal that was terminated but not yet cleaned up from state also reports EX_DEAL_EXPIRED.
     fn get_deal_activation(
         rt: &impl Runtime,
         params: GetDealActivationParams,
@@ -1205,6 +1319,12 @@ impl Actor {
         }
     }
 
+    /// Settles payments for the given deals up to the current epoch, transferring the amount
+    /// owed from client to provider escrow and advancing each deal's last updated epoch.
+    /// Completed deals are cleaned up and their collateral unlocked. Deals which missed their
+    /// activation window are slashed and cleaned up.
+    /// Settling a deal more than once in the same epoch makes no further payment.
+    /// May be called by anyone.
     fn settle_deal_payments(
         rt: &impl Runtime,
         params: SettleDealPaymentsParams,
@@ -1309,6 +1429,7 @@ impl Actor {
                             deal_id,
                             deal_proposal.client.id().unwrap(),
                             deal_proposal.provider.id().unwrap(),
+                            &deal_proposal.piece_cid,
                         )?;
                     }
                 } else {
@@ -1328,6 +1449,7 @@ impl Actor {
                         deal_id,
                         deal_proposal.client.id().unwrap(),
                         deal_proposal.provider.id().unwrap(),
+                        &deal_proposal.piece_cid,
                     )?;
                 }
             }
@@ -1348,6 +1470,187 @@ impl Actor {
 
         Ok(SettleDealPaymentsReturn { results: batch_gen.gen(), settlements })
     }
+
+    /// Reassigns published deals that are not yet activated from one storage provider to
+    /// another, e.g. when a miner is sold.
+    /// A controlling address of the old provider proposes the reassignment, and a controlling
+    /// address of the new provider confirms it by calling with identical parameters.
+    /// The deals' provider collateral moves from the old provider's escrow to the new provider's.
+    /// Client terms are otherwise unchanged, and each client must consent to the new provider
+    /// by signing the deal proposal that names it. Both calls must carry the clients' signatures.
+    /// Deals that have been activated (including any since terminated) cannot be reassigned,
+    /// since their data is sealed in a sector of the old provider.
+    /// Verified deals cannot be reassigned, since their allocations name the old provider.
+    fn reassign_deals(rt: &impl Runtime, params: ReassignDealsParams) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        if params.deal_ids.is_empty() {
+            return Err(actor_error!(illegal_argument, "no deals specified"));
+        }
+        if params.client_signatures.len() != params.deal_ids.len() {
+            return Err(actor_error!(
+                illegal_argument,
+                "{} client signatures for {} deals",
+                params.client_signatures.len(),
+                params.deal_ids.len()
+            ));
+        }
+        let old_provider = resolve_provider_id(rt, &params.old_provider)?;
+        let new_provider = resolve_provider_id(rt, &params.new_provider)?;
+        if old_provider == new_provider {
+            return Err(actor_error!(illegal_argument, "old and new provider are the same"));
+        }
+
+        let caller = rt.message().caller();
+        let is_old_provider = is_controlling_address(rt, old_provider, caller)?;
+        let is_new_provider = is_controlling_address(rt, new_provider, caller)?;
+        if !is_old_provider && !is_new_provider {
+            return Err(actor_error!(
+                forbidden,
+                "caller {} is not worker or control address of provider {} or {}",
+                caller,
+                old_provider,
+                new_provider
+            ));
+        }
+
+        // Each client authorizes the new provider by signing the reassigned proposal.
+        let curr_epoch = rt.curr_epoch();
+        let st: State = rt.state()?;
+        for (deal_id, client_signature) in params.deal_ids.iter().zip(params.client_signatures) {
+            let mut proposal =
+                validate_deal_can_reassign(&st, rt.store(), *deal_id, old_provider, curr_epoch)?;
+            proposal.provider = Address::new_id(new_provider);
+            deal_proposal_is_internally_valid(
+                rt,
+                &ClientDealProposal { proposal, client_signature },
+            )
+            .with_context(|| {
+                format!("client did not authorize reassignment of deal {}", deal_id)
+            })?;
+        }
+
+        let reassignment = DealReassignment { new_provider, deal_ids: params.deal_ids };
+        rt.transaction(|st: &mut State, rt| {
+            let store = rt.store();
+            let mut proposals = Vec::with_capacity(reassignment.deal_ids.len());
+            let mut seen = BTreeSet::new();
+            for deal_id in &reassignment.deal_ids {
+                if !seen.insert(*deal_id) {
+                    return Err(actor_error!(illegal_argument, "duplicate deal {}", deal_id));
+                }
+                let proposal =
+                    validate_deal_can_reassign(st, store, *deal_id, old_provider, curr_epoch)?;
+                proposals.push((*deal_id, proposal));
+            }
+
+            if !is_new_provider {
+                // Await confirmation by the new provider.
+                return st.put_pending_deal_reassignment(store, old_provider, reassignment.clone());
+            }
+            if !is_old_provider {
+                let pending = st.take_pending_deal_reassignment(store, old_provider)?;
+                if pending.as_ref() != Some(&reassignment) {
+                    return Err(actor_error!(
+                        forbidden,
+                        "no matching reassignment proposed by provider {}",
+                        old_provider
+                    ));
+                }
+            }
+
+            let old_addr = Address::new_id(old_provider);
+            let new_addr = Address::new_id(new_provider);
+            let mut pending_deals = st.load_pending_deals(store)?;
+            for (deal_id, proposal) in proposals.iter_mut() {
+                // The pending proposals set is keyed by proposal CID, which includes the provider.
+                pending_deals.delete(&deal_cid(rt, proposal)?)?;
+                proposal.provider = new_addr;
+                if pending_deals.put(&deal_cid(rt, proposal)?)?.is_some() {
+                    return Err(actor_error!(
+                        illegal_argument,
+                        "deal {} duplicates a proposal already published by provider {}",
+                        deal_id,
+                        new_provider
+                    ));
+                }
+                st.transfer_provider_collateral(
+                    store,
+                    &old_addr,
+                    &new_addr,
+                    &proposal.provider_collateral,
+                )?;
+            }
+            st.pending_proposals = pending_deals.flush()?;
+            st.put_deal_proposals(store, &proposals)
+        })
+    }
+
+    /// Cancels a published deal that has not yet been activated, before its start epoch.
+    /// May be called by the deal's client, or a controlling address of its provider.
+    /// The deal is removed, and the client's payment and collateral are returned to its escrow
+    /// balance. When the client cancels, the provider's collateral is returned without penalty.
+    /// When the provider cancels, it pays the same penalty as if the deal had timed out without
+    /// activation, so that cancelling is never cheaper than letting the deal lapse.
+    /// A verified deal's DataCap allocation is not removed, and may be reclaimed by the client
+    /// once it expires.
+    fn cancel_unactivated_deal(
+        rt: &impl Runtime,
+        params: CancelUnactivatedDealParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let deal_id = params.deal_id;
+        let curr_epoch = rt.curr_epoch();
+
+        let st: State = rt.state()?;
+        let proposal = st
+            .find_proposal(rt.store(), deal_id)?
+            .ok_or_else(|| actor_error!(not_found, "no such deal {}", deal_id))?;
+        let caller = rt.message().caller();
+        let provider = proposal.provider.id().unwrap();
+        let by_client = caller == proposal.client;
+        if !by_client && !is_controlling_address(rt, provider, caller)? {
+            return Err(actor_error!(
+                forbidden,
+                "caller {} is not the client or a controlling address of provider {} of deal {}",
+                caller,
+                provider,
+                deal_id
+            ));
+        }
+
+        let amount_slashed = rt.transaction(|st: &mut State, rt| {
+            if st.find_deal_state(rt.store(), deal_id)?.is_some() {
+                return Err(actor_error!(forbidden, "deal {} has been activated", deal_id));
+            }
+            if proposal.start_epoch <= curr_epoch {
+                return Err(actor_error!(
+                    forbidden,
+                    "deal {} start epoch {} has passed",
+                    deal_id,
+                    proposal.start_epoch
+                ));
+            }
+            let dcid = deal_cid(rt, &proposal)?;
+            st.cancel_unactivated_deal(rt.store(), deal_id, &proposal, &dcid, !by_client)
+        })?;
+
+        if !amount_slashed.is_zero() {
+            extract_send_result(rt.send_simple(
+                &BURNT_FUNDS_ACTOR_ADDR,
+                METHOD_SEND,
+                None,
+                amount_slashed,
+            ))?;
+        }
+
+        emit::deal_terminated(
+            rt,
+            deal_id,
+            proposal.client.id().unwrap(),
+            provider,
+            &proposal.piece_cid,
+        )
+    }
 }
 
 fn get_proposals<BS: Blockstore>(
@@ -1718,6 +2021,85 @@ pub(crate) fn serialized_deal_cid(rt: &impl Runtime, data: &[u8]) -> Result<Cid,
     Ok(Cid::new_v1(DAG_CBOR, hash))
 }
 
+/// Resolves an address to the ID of a storage miner actor.
+fn resolve_provider_id(rt: &impl Runtime, addr: &Address) -> Result<ActorID, ActorError> {
+    let provider_id = rt
+        .resolve_address(addr)
+        .ok_or_else(|| actor_error!(not_found, "failed to resolve provider address {}", addr))?;
+    let code_id = rt
+        .get_actor_code_cid(&provider_id)
+        .ok_or_else(|| actor_error!(not_found, "no code ID for address {}", provider_id))?;
+    if rt.resolve_builtin_actor_type(&code_id) != Some(Type::Miner) {
+        return Err(actor_error!(
+            illegal_argument,
+            "provider {} is not a storage miner actor",
+            provider_id
+        ));
+    }
+    Ok(provider_id)
+}
+
+/// Checks whether an address is the worker or a control address of a storage provider.
+fn is_controlling_address(
+    rt: &impl Runtime,
+    provider_id: ActorID,
+    address: Address,
+) -> Result<bool, ActorError> {
+    let ret: ext::miner::IsControllingAddressReturn =
+        deserialize_block(extract_send_result(rt.send_simple(
+            &Address::new_id(provider_id),
+            ext::miner::IS_CONTROLLING_ADDRESS_EXPORTED,
+            IpldBlock::serialize_cbor(&ext::miner::IsControllingAddressParam { address })?,
+            TokenAmount::zero(),
+        ))?)?;
+    Ok(ret.is_controlling)
+}
+
+/// Checks that a deal is published by a provider and may be reassigned to another,
+/// returning the deal proposal.
+fn validate_deal_can_reassign<BS: Blockstore>(
+    st: &State,
+    store: &BS,
+    deal_id: DealID,
+    provider: ActorID,
+    curr_epoch: ChainEpoch,
+) -> Result<DealProposal, ActorError> {
+    let proposal = st
+        .find_proposal(store, deal_id)?
+        .ok_or_else(|| actor_error!(not_found, "no such deal {}", deal_id))?;
+    if proposal.provider != Address::new_id(provider) {
+        return Err(actor_error!(forbidden, "deal {} is not with provider {}", deal_id, provider));
+    }
+    if let Some(state) = st.find_deal_state(store, deal_id)? {
+        if state.slash_epoch != EPOCH_UNDEFINED {
+            return Err(actor_error!(illegal_argument, "deal {} is terminated", deal_id));
+        }
+        return Err(actor_error!(
+            illegal_argument,
+            "deal {} is activated in a sector of provider {}",
+            deal_id,
+            provider
+        ));
+    }
+    if proposal.start_epoch <= curr_epoch {
+        return Err(actor_error!(
+            illegal_argument,
+            "deal {} start epoch {} has passed",
+            deal_id,
+            proposal.start_epoch
+        ));
+    }
+    if proposal.verified_deal {
+        return Err(actor_error!(
+            illegal_argument,
+            "verified deal {} allocation is bound to provider {}",
+            deal_id,
+            provider
+        ));
+    }
+    Ok(proposal)
+}
+
 fn request_miner_control_addrs(
     rt: &impl Runtime,
     miner_id: ActorID,
@@ -1825,5 +2207,9 @@ impl ActorCode for Actor {
         GetDealSectorExported => get_deal_sector,
         SettleDealPaymentsExported => settle_deal_payments,
         SectorContentChangedExported => sector_content_changed,
+        GetBalancesExported => get_balances,
+        ReassignDealsExported => reassign_deals,
+        CancelUnactivatedDealExported => cancel_unactivated_deal,
+        CheckDealsForActivationExported => check_deals_for_activation,
     }
 }
@@ -86,6 +86,17 @@ pub struct State {
     /// of multiple sectors all belonging to the same provider.
     /// HAMT[ActorID]HAMT[SectorNumber][]DealID
     pub provider_sectors: Cid,
+
+    /// Deal reassignments proposed by a provider and awaiting confirmation by the new provider.
+    /// HAMT[ActorID]DealReassignment
+    pub pending_deal_reassignments: Cid,
+}
+
+/// A reassignment of deals to a new provider, proposed by the deals' current provider.
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct DealReassignment {
+    pub new_provider: ActorID,
+    pub deal_ids: Vec<DealID>,
 }
 
 pub type PendingProposalsSet<BS> = Set<BS, Cid>;
@@ -106,6 +117,10 @@ pub const PROVIDER_SECTORS_CONFIG: Config =
 pub type SectorDealsMap<BS> = Map2<BS, SectorNumber, Vec<DealID>>;
 pub const SECTOR_DEALS_CONFIG: Config = Config { bit_width: HAMT_BIT_WIDTH, ..DEFAULT_HAMT_CONFIG };
 
+pub type DealReassignmentsMap<BS> = Map2<BS, ActorID, DealReassignment>;
+pub const DEAL_REASSIGNMENTS_CONFIG: Config =
+    Config { bit_width: HAMT_BIT_WIDTH, ..DEFAULT_HAMT_CONFIG };
+
 impl State {
     pub fn new<BS: Blockstore>(store: &BS) -> Result<Self, ActorError> {
         let empty_proposals_array =
@@ -137,6 +152,10 @@ impl State {
         let empty_sector_deals_hamt =
             ProviderSectorsMap::empty(store, PROVIDER_SECTORS_CONFIG, "sector deals").flush()?;
 
+        let empty_deal_reassignments =
+            DealReassignmentsMap::empty(store, DEAL_REASSIGNMENTS_CONFIG, "deal reassignments")
+                .flush()?;
+
         Ok(Self {
             proposals: empty_proposals_array,
             states: empty_states_array,
@@ -152,6 +171,7 @@ impl State {
             total_client_storage_fee: TokenAmount::default(),
             pending_deal_allocation_ids: empty_pending_deal_allocation_map,
             provider_sectors: empty_sector_deals_hamt,
+            pending_deal_reassignments: empty_deal_reassignments,
         })
     }
 
@@ -715,6 +735,56 @@ impl State {
         Ok(())
     }
 
+    ////////////////////////////////////////////////////////////////////////////////
+    // Deal reassignment operations
+    ////////////////////////////////////////////////////////////////////////////////
+
+    pub fn load_pending_deal_reassignments<BS>(
+        &self,
+        store: BS,
+    ) -> Result<DealReassignmentsMap<BS>, ActorError>
+    where
+        BS: Blockstore,
+    {
+        DealReassignmentsMap::load(
+            store,
+            &self.pending_deal_reassignments,
+            DEAL_REASSIGNMENTS_CONFIG,
+            "deal reassignments",
+        )
+    }
+
+    // Records a reassignment proposed by a provider, replacing any previous proposal.
+    pub fn put_pending_deal_reassignment<BS>(
+        &mut self,
+        store: &BS,
+        provider: ActorID,
+        reassignment: DealReassignment,
+    ) -> Result<(), ActorError>
+    where
+        BS: Blockstore,
+    {
+        let mut reassignments = self.load_pending_deal_reassignments(store)?;
+        reassignments.set(&provider, reassignment)?;
+        self.pending_deal_reassignments = reassignments.flush()?;
+        Ok(())
+    }
+
+    // Removes and returns the reassignment proposed by a provider, if any.
+    pub fn take_pending_deal_reassignment<BS>(
+        &mut self,
+        store: &BS,
+        provider: ActorID,
+    ) -> Result<Option<DealReassignment>, ActorError>
+    where
+        BS: Blockstore,
+    {
+        let mut reassignments = self.load_pending_deal_reassignments(store)?;
+        let reassignment = reassignments.delete(&provider)?;
+        self.pending_deal_reassignments = reassignments.flush()?;
+        Ok(reassignment)
+    }
+
     /// Delete proposal and state simultaneously.
     pub fn remove_completed_deal<BS>(
         &mut self,
@@ -996,6 +1066,69 @@ impl State {
         Ok(amount_slashed)
     }
 
+    /// Removes a published deal that has not been activated, unlocking the client's payment and
+    /// collateral and the provider's collateral.
+    /// If the provider is penalized, it pays the same penalty as for a deal that timed out
+    /// without activation. Returns the amount slashed from the provider.
+    pub fn cancel_unactivated_deal<BS>(
+        &mut self,
+        store: &BS,
+        deal_id: DealID,
+        deal: &DealProposal,
+        dcid: &Cid,
+        penalize_provider: bool,
+    ) -> Result<TokenAmount, ActorError>
+    where
+        BS: Blockstore,
+    {
+        let amount_slashed = if penalize_provider {
+            self.process_deal_init_timed_out(store, deal)?
+        } else {
+            self.unlock_balance(
+                store,
+                &deal.client,
+                &deal.total_storage_fee(),
+                Reason::ClientStorageFee,
+            )
+            .context("unlocking client storage fee")?;
+
+            self.unlock_balance(
+                store,
+                &deal.client,
+                &deal.client_collateral,
+                Reason::ClientCollateral,
+            )
+            .context("unlocking client collateral")?;
+
+            self.unlock_balance(
+                store,
+                &deal.provider,
+                &deal.provider_balance_requirement(),
+                Reason::ProviderCollateral,
+            )
+            .context("unlocking deal provider balance")?;
+            TokenAmount::zero()
+        };
+
+        self.remove_proposal(store, deal_id)?.ok_or_else(|| {
+            actor_error!(
+                illegal_state,
+                "failed to delete deal {} proposal: does not exist",
+                deal_id
+            )
+        })?;
+        self.remove_pending_deal(store, *dcid)?.ok_or_else(|| {
+            actor_error!(
+                illegal_state,
+                "failed to delete pending deal {}: cid {} does not exist",
+                deal_id,
+                dcid
+            )
+        })?;
+        self.remove_pending_deal_allocation_id(store, deal_id)?;
+        Ok(amount_slashed)
+    }
+
     /// Normal expiration. Unlock collaterals for both miner and client.
     fn process_deal_expired<BS>(
         &mut self,
@@ -1158,6 +1291,35 @@ impl State {
         Ok(())
     }
 
+    /// Moves locked provider collateral from one provider's escrow to another's,
+    /// where it remains locked.
+    pub fn transfer_provider_collateral<BS>(
+        &mut self,
+        store: &BS,
+        from_addr: &Address,
+        to_addr: &Address,
+        amount: &TokenAmount,
+    ) -> Result<(), ActorError>
+    where
+        BS: Blockstore,
+    {
+        if amount.is_negative() {
+            return Err(actor_error!(illegal_state, "transfer negative amount: {}", amount));
+        }
+
+        let mut escrow_table = BalanceTable::from_root(store, &self.escrow_table, "escrow table")?;
+        let mut locked_table = BalanceTable::from_root(store, &self.locked_table, "locked table")?;
+
+        escrow_table.must_subtract(from_addr, amount)?;
+        locked_table.must_subtract(from_addr, amount).context("unlocking provider collateral")?;
+        escrow_table.add(to_addr, amount)?;
+        locked_table.add(to_addr, amount)?;
+
+        self.escrow_table = escrow_table.root()?;
+        self.locked_table = locked_table.root()?;
+        Ok(())
+    }
+
     fn slash_balance<BS>(
         &mut self,
         store: &BS,
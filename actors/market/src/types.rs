@@ -5,12 +5,14 @@ use super::ext::verifreg::AllocationID;
 use cid::Cid;
 use fil_actors_runtime::Array;
 use fil_actors_runtime::BatchReturn;
+use fil_actors_runtime::DealWeight;
 use fvm_ipld_bitfield::BitField;
 use fvm_ipld_encoding::strict_bytes;
 use fvm_ipld_encoding::tuple::*;
 use fvm_shared::address::Address;
 use fvm_shared::bigint::{bigint_ser, BigInt};
 use fvm_shared::clock::ChainEpoch;
+use fvm_shared::crypto::signature::Signature;
 use fvm_shared::deal::DealID;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::piece::PaddedPieceSize;
@@ -54,6 +56,19 @@ pub struct GetBalanceReturn {
     pub locked: TokenAmount,
 }
 
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct GetBalancesParams {
+    pub accounts: Vec<Address>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct GetBalancesReturn {
+    /// Balances for each account, in the order requested.
+    pub balances: Vec<GetBalanceReturn>,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, PartialEq)] // Add Eq when BitField does
 pub struct OnMinerSectorsTerminateParams {
     pub epoch: ChainEpoch,
@@ -92,6 +107,35 @@ pub struct VerifyDealsForActivationReturn {
     pub unsealed_cids: Vec<Option<Cid>>,
 }
 
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+pub struct CheckDealsForActivationParams {
+    /// The provider whose sectors would activate the deals.
+    pub provider: Address,
+    /// Deals to check, grouped by sector.
+    pub sectors: Vec<SectorDeals>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct CheckDealsForActivationReturn {
+    /// Results for each sector, in the order given.
+    pub sectors: Vec<SectorDealsCheck>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+pub struct SectorDealsCheck {
+    /// Whether all the sector's deals could be activated now.
+    pub valid: bool,
+    /// Weight of the unverified deals that could be activated, for the sector's expiration.
+    #[serde(with = "bigint_ser")]
+    pub deal_weight: DealWeight,
+    /// Weight of the verified deals that could be activated, for the sector's expiration.
+    #[serde(with = "bigint_ser")]
+    pub verified_deal_weight: DealWeight,
+    /// Result for each of the sector's deals, in the order given.
+    pub deal_results: BatchReturn,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
 pub struct BatchActivateDealsParams {
     /// Deals to activate, grouped by sector.
@@ -279,3 +323,20 @@ pub struct DealSettlementSummary {
     /// Whether the deal has settled for the final time
     pub completed: bool,
 }
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct CancelUnactivatedDealParams {
+    pub deal_id: DealID,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+pub struct ReassignDealsParams {
+    /// The provider with which the deals were made.
+    pub old_provider: Address,
+    /// The provider to which the deals are reassigned.
+    pub new_provider: Address,
+    pub deal_ids: Vec<DealID>,
+    /// For each deal, the client's signature over the deal proposal naming the new provider.
+    pub client_signatures: Vec<Signature>,
+}
@@ -0,0 +1,108 @@
+use fvm_shared::address::Address;
+use fvm_shared::clock::ChainEpoch;
+use fvm_shared::error::ExitCode;
+
+use fil_actors_runtime::network::EPOCHS_IN_DAY;
+use fil_actors_runtime::test_utils::expect_abort_contains_message;
+use harness::*;
+
+mod harness;
+
+const START_EPOCH: ChainEpoch = 10;
+const END_EPOCH: ChainEpoch = START_EPOCH + 200 * EPOCHS_IN_DAY;
+
+#[test]
+fn client_cancels_unactivated_deal() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let (deal_id, proposal) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, START_EPOCH, END_EPOCH);
+    let client_before = get_balance(&rt, &CLIENT_ADDR);
+    let provider_before = get_balance(&rt, &addrs.provider);
+
+    cancel_unactivated_deal(&rt, CLIENT_ADDR, deal_id, None).unwrap();
+    assert_deal_deleted(&rt, deal_id, &proposal, 0, true);
+
+    // All locked funds are returned to escrow, and nothing is slashed.
+    let client_after = get_balance(&rt, &CLIENT_ADDR);
+    let provider_after = get_balance(&rt, &addrs.provider);
+    assert_eq!(client_before.balance, client_after.balance);
+    assert_eq!(
+        &client_before.locked - proposal.total_storage_fee() - &proposal.client_collateral,
+        client_after.locked
+    );
+    assert_eq!(provider_before.balance, provider_after.balance);
+    assert_eq!(&provider_before.locked - &proposal.provider_collateral, provider_after.locked);
+    check_state(&rt);
+}
+
+#[test]
+fn provider_cancels_unactivated_deal() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let (deal_id, proposal) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, START_EPOCH, END_EPOCH);
+    let client_before = get_balance(&rt, &CLIENT_ADDR);
+    let provider_before = get_balance(&rt, &addrs.provider);
+
+    cancel_unactivated_deal(&rt, addrs.worker, deal_id, Some((addrs.provider, true))).unwrap();
+    assert_deal_deleted(&rt, deal_id, &proposal, 0, true);
+
+    // The client's funds are returned, while the provider's collateral is slashed as if the
+    // deal had timed out without activation.
+    let client_after = get_balance(&rt, &CLIENT_ADDR);
+    let provider_after = get_balance(&rt, &addrs.provider);
+    assert_eq!(client_before.balance, client_after.balance);
+    assert_eq!(
+        &client_before.locked - proposal.total_storage_fee() - &proposal.client_collateral,
+        client_after.locked
+    );
+    assert_eq!(&provider_before.balance - &proposal.provider_collateral, provider_after.balance);
+    assert_eq!(&provider_before.locked - &proposal.provider_collateral, provider_after.locked);
+    check_state(&rt);
+}
+
+#[test]
+fn cancel_requires_client_or_provider() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let (deal_id, _) = generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, START_EPOCH, END_EPOCH);
+
+    let other = Address::new_id(999);
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "is not the client or a controlling address",
+        cancel_unactivated_deal(&rt, other, deal_id, Some((addrs.provider, false))),
+    );
+    check_state(&rt);
+}
+
+#[test]
+fn cannot_cancel_activated_deal() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let (deal_id, _) = generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, START_EPOCH, END_EPOCH);
+    activate_deals(&rt, END_EPOCH + 1, addrs.provider, 0, 1, &[deal_id]);
+
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "has been activated",
+        cancel_unactivated_deal(&rt, CLIENT_ADDR, deal_id, None),
+    );
+    check_state(&rt);
+}
+
+#[test]
+fn cannot_cancel_after_start_epoch() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let (deal_id, _) = generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, START_EPOCH, END_EPOCH);
+    rt.set_epoch(START_EPOCH);
+
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "start epoch 10 has passed",
+        cancel_unactivated_deal(&rt, CLIENT_ADDR, deal_id, None),
+    );
+    check_state(&rt);
+}
@@ -187,6 +187,7 @@ fn expired_deal_should_unlock_the_remaining_client_and_provider_locked_balance_a
         deal_id,
         CLIENT_ADDR.id().unwrap(),
         PROVIDER_ADDR.id().unwrap(),
+        &get_deal_proposal(&rt, deal_id).piece_cid,
     );
     rt.set_epoch(END_EPOCH + 1000);
     cron_tick(&rt);
@@ -230,6 +231,7 @@ fn all_payments_are_made_for_a_deal_client_withdraws_collateral_and_client_accou
         _deal_id,
         CLIENT_ADDR.id().unwrap(),
         PROVIDER_ADDR.id().unwrap(),
+        &get_deal_proposal(&rt, _deal_id).piece_cid,
     );
     cron_tick(&rt);
     assert_eq!(deal_proposal.client_collateral, get_balance(&rt, &CLIENT_ADDR).balance);
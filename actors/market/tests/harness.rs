@@ -36,11 +36,13 @@ use fil_actor_market::{
 };
 use fil_actor_market::{
     ext, ext::miner::GetControlAddressesReturnParams, next_update_epoch,
-    testing::check_state_invariants, Actor as MarketActor, ClientDealProposal, DealArray,
-    DealMetaArray, DealProposal, DealState, GetBalanceReturn, Label, MarketNotifyDealParams,
-    Method, OnMinerSectorsTerminateParams, PublishStorageDealsParams, PublishStorageDealsReturn,
-    SectorDeals, State, VerifyDealsForActivationParams, VerifyDealsForActivationReturn,
-    WithdrawBalanceParams, WithdrawBalanceReturn, MARKET_NOTIFY_DEAL_METHOD, NO_ALLOCATION_ID,
+    testing::check_state_invariants, Actor as MarketActor, CancelUnactivatedDealParams,
+    CheckDealsForActivationParams, CheckDealsForActivationReturn, ClientDealProposal, DealArray,
+    DealMetaArray, DealProposal, DealState, GetBalanceReturn, GetBalancesParams, GetBalancesReturn,
+    Label, MarketNotifyDealParams, Method, OnMinerSectorsTerminateParams,
+    PublishStorageDealsParams, PublishStorageDealsReturn, ReassignDealsParams, SectorDeals, State,
+    VerifyDealsForActivationParams, VerifyDealsForActivationReturn, WithdrawBalanceParams,
+    WithdrawBalanceReturn, MARKET_NOTIFY_DEAL_METHOD, NO_ALLOCATION_ID,
 };
 use fil_actor_power::{CurrentTotalPowerReturn, Method as PowerMethod};
 use fil_actor_reward::Method as RewardMethod;
@@ -190,6 +192,134 @@ pub fn get_balance(rt: &MockRuntime, addr: &Address) -> GetBalanceReturn {
     ret
 }
 
+pub fn get_balances(rt: &MockRuntime, accounts: Vec<Address>) -> Vec<GetBalanceReturn> {
+    rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+    rt.expect_validate_caller_any();
+    let ret: GetBalancesReturn = rt
+        .call::<MarketActor>(
+            Method::GetBalancesExported as u64,
+            IpldBlock::serialize_cbor(&GetBalancesParams { accounts }).unwrap(),
+        )
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .unwrap();
+    rt.verify();
+    ret.balances
+}
+
+/// Calls ReassignDeals from the given caller, which is a controlling address of the old
+/// and/or new provider as specified.
+/// If client_auth is set, expects each client to authenticate its signature over the reassigned
+/// proposal with that result, stopping at the first rejection.
+#[allow(clippy::too_many_arguments)]
+pub fn reassign_deals(
+    rt: &MockRuntime,
+    caller: Address,
+    old: &MinerAddresses,
+    new: &MinerAddresses,
+    deal_ids: &[DealID],
+    controls_old: bool,
+    controls_new: bool,
+    client_auth: Option<bool>,
+) -> Result<(), ActorError> {
+    rt.set_address_actor_type(new.provider, *MINER_ACTOR_CODE_ID);
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, caller);
+    rt.expect_validate_caller_any();
+    expect_provider_is_control_address(rt, old.provider, caller, controls_old);
+    expect_provider_is_control_address(rt, new.provider, caller, controls_new);
+
+    let mut client_signatures = vec![];
+    let mut expect_auth = client_auth.is_some();
+    for deal_id in deal_ids {
+        let proposal = DealProposal { provider: new.provider, ..get_deal_proposal(rt, *deal_id) };
+        let proposal_bytes = RawBytes::serialize(&proposal).unwrap();
+        if expect_auth {
+            let authorized = client_auth.unwrap();
+            rt.expect_send(
+                proposal.client,
+                AUTHENTICATE_MESSAGE_METHOD,
+                IpldBlock::serialize_cbor(&AuthenticateMessageParams {
+                    signature: proposal_bytes.to_vec(),
+                    message: proposal_bytes.to_vec(),
+                })
+                .unwrap(),
+                TokenAmount::zero(),
+                None,
+                SendFlags::READ_ONLY,
+                IpldBlock::serialize_cbor(&authorized).unwrap(),
+                ExitCode::OK,
+                None,
+            );
+            expect_auth = authorized;
+        }
+        client_signatures.push(Signature::new_bls(proposal_bytes.to_vec()));
+    }
+
+    let params = ReassignDealsParams {
+        old_provider: old.provider,
+        new_provider: new.provider,
+        deal_ids: deal_ids.to_vec(),
+        client_signatures,
+    };
+    let ret = rt.call::<MarketActor>(
+        Method::ReassignDealsExported as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    );
+    rt.verify();
+    ret.map(|r| assert!(r.is_none()))
+}
+
+pub fn cancel_unactivated_deal(
+    rt: &MockRuntime,
+    caller: Address,
+    deal_id: DealID,
+    provider_control: Option<(Address, bool)>,
+) -> Result<(), ActorError> {
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, caller);
+    rt.expect_validate_caller_any();
+    if let Some((provider, is_controlling)) = provider_control {
+        expect_provider_is_control_address(rt, provider, caller, is_controlling);
+    }
+    let deal = get_deal_proposal(rt, deal_id);
+    // A provider that cancels pays the penalty for a missed activation.
+    if caller != deal.client && deal.provider_collateral.is_positive() {
+        rt.expect_send_simple(
+            BURNT_FUNDS_ACTOR_ADDR,
+            METHOD_SEND,
+            None,
+            deal.provider_collateral.clone(),
+            None,
+            ExitCode::OK,
+        );
+    }
+    expect_emitted(
+        rt,
+        "deal-terminated",
+        deal_id,
+        deal.client.id().unwrap(),
+        deal.provider.id().unwrap(),
+        &deal.piece_cid,
+    );
+
+    let params = CancelUnactivatedDealParams { deal_id };
+    let ret = rt.call::<MarketActor>(
+        Method::CancelUnactivatedDealExported as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    );
+    match ret {
+        Ok(ret) => {
+            rt.verify();
+            assert!(ret.is_none());
+            Ok(())
+        }
+        Err(e) => {
+            rt.reset();
+            Err(e)
+        }
+    }
+}
+
 pub fn expect_get_control_addresses(
     rt: &MockRuntime,
     provider: Address,
@@ -480,6 +610,7 @@ pub fn batch_activate_deals_raw(
             *deal_id,
             dp.client.id().unwrap(),
             dp.provider.id().unwrap(),
+            &dp.piece_cid,
         );
     }
     let ret = rt.call::<MarketActor>(
@@ -668,6 +799,7 @@ pub fn cron_tick_and_assert_balances(
             deal_id,
             client_addr.id().unwrap(),
             provider_addr.id().unwrap(),
+            &d.piece_cid,
         );
     }
 
@@ -867,6 +999,7 @@ pub fn publish_deals(
             deal_id,
             deal.client.id().unwrap(),
             deal.provider.id().unwrap(),
+            &deal.piece_cid,
         );
         deal_id += 1;
     }
@@ -969,6 +1102,7 @@ pub fn settle_deal_payments(
             *deal_id,
             deal.client.id().unwrap(),
             deal.provider.id().unwrap(),
+            &deal.piece_cid,
         );
     }
 
@@ -980,6 +1114,7 @@ pub fn settle_deal_payments(
             *deal_id,
             deal.client.id().unwrap(),
             deal.provider.id().unwrap(),
+            &deal.piece_cid,
         );
     }
 
@@ -1687,6 +1822,7 @@ pub fn terminate_deals_raw(
             *deal_id,
             d.client.id().unwrap(),
             d.provider.id().unwrap(),
+            &d.piece_cid,
         )
     }
 
@@ -1743,6 +1879,27 @@ where
     ret
 }
 
+pub fn check_deals_for_activation(
+    rt: &MockRuntime,
+    provider: Address,
+    sector_deals: Vec<SectorDeals>,
+) -> CheckDealsForActivationReturn {
+    rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+    rt.expect_validate_caller_any();
+    let param = CheckDealsForActivationParams { provider, sectors: sector_deals };
+    let ret: CheckDealsForActivationReturn = rt
+        .call::<MarketActor>(
+            Method::CheckDealsForActivationExported as u64,
+            IpldBlock::serialize_cbor(&param).unwrap(),
+        )
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .expect("CheckDealsForActivation failed!");
+    rt.verify();
+    ret
+}
+
 // market cron tick uses last_updated_epoch == EPOCH_UNDEFINED to determine if a deal is new
 // it will not process such deals
 // however, for testing we need to simulate deals that are already in the system that should be
@@ -1774,13 +1931,21 @@ pub fn piece_info_from_deal(id: DealID, deal: &DealProposal) -> PieceChange {
     }
 }
 
-pub fn expect_emitted(rt: &MockRuntime, typ: &str, id: DealID, client: ActorID, provider: ActorID) {
+pub fn expect_emitted(
+    rt: &MockRuntime,
+    typ: &str,
+    id: DealID,
+    client: ActorID,
+    provider: ActorID,
+    piece_cid: &Cid,
+) {
     rt.expect_emitted_event(
         EventBuilder::new()
             .typ(typ)
             .field_indexed("id", &id)
             .field_indexed("client", &client)
             .field_indexed("provider", &provider)
+            .field_indexed("piece-cid", piece_cid)
             .build()
             .unwrap(),
     );
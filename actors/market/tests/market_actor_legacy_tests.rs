@@ -387,7 +387,14 @@ fn locked_fund_tracking_states() {
     clc = TokenAmount::zero();
     plc = TokenAmount::zero();
 
-    expect_emitted(&rt, "deal-completed", deal_id2, d2.client.id().unwrap(), p2.id().unwrap());
+    expect_emitted(
+        &rt,
+        "deal-completed",
+        deal_id2,
+        d2.client.id().unwrap(),
+        p2.id().unwrap(),
+        &d2.piece_cid,
+    );
 
     cron_tick(&rt);
     assert_locked_fund_states(&rt, csf, plc, clc);
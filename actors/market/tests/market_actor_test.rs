@@ -27,7 +27,7 @@ use fil_actor_market::ext::verifreg::{AllocationRequest, AllocationsResponse};
 use fil_actor_market::policy::detail::DEAL_MAX_LABEL_SIZE;
 use fil_actor_market::{
     ext, Actor as MarketActor, BatchActivateDealsResult, ClientDealProposal, DealArray,
-    DealMetaArray, DealOpsByEpoch, Label, MarketNotifyDealParams, Method,
+    DealMetaArray, DealOpsByEpoch, GetBalanceReturn, Label, MarketNotifyDealParams, Method,
     PendingDealAllocationsMap, PendingProposalsSet, PublishStorageDealsParams,
     PublishStorageDealsReturn, SectorDeals, State, WithdrawBalanceParams, DEAL_OPS_BY_EPOCH_CONFIG,
     EX_DEAL_EXPIRED, MARKET_NOTIFY_DEAL_METHOD, PENDING_ALLOCATIONS_CONFIG,
@@ -252,6 +252,42 @@ fn fails_if_withdraw_from_non_provider_funds_is_not_initiated_by_the_recipient()
     check_state(&rt);
 }
 
+#[test]
+fn get_balances_for_many_accounts() {
+    let start_epoch = ChainEpoch::from(10);
+    let end_epoch = start_epoch + 200 * EPOCHS_IN_DAY;
+    let publish_epoch = ChainEpoch::from(5);
+
+    let rt = setup();
+    rt.set_epoch(publish_epoch);
+    let (_, deal) = generate_and_publish_deal(
+        &rt,
+        CLIENT_ADDR,
+        &MinerAddresses::default(),
+        start_epoch,
+        end_epoch,
+    );
+
+    // Unresolvable and unfunded addresses report zero balances.
+    let unknown = Address::new_secp256k1(&[3; 65]).unwrap();
+    let unfunded = Address::new_id(999);
+    let balances = get_balances(&rt, vec![PROVIDER_ADDR, unknown, CLIENT_ADDR, unfunded]);
+    assert_eq!(
+        vec![
+            get_balance(&rt, &PROVIDER_ADDR),
+            GetBalanceReturn { balance: TokenAmount::zero(), locked: TokenAmount::zero() },
+            get_balance(&rt, &CLIENT_ADDR),
+            GetBalanceReturn { balance: TokenAmount::zero(), locked: TokenAmount::zero() },
+        ],
+        balances
+    );
+    assert_eq!(deal.provider_collateral, balances[0].locked);
+    assert_eq!(deal.client_balance_requirement(), balances[2].locked);
+
+    assert!(get_balances(&rt, vec![]).is_empty());
+    check_state(&rt);
+}
+
 #[test]
 fn balance_after_withdrawal_must_always_be_greater_than_or_equal_to_locked_amount() {
     let start_epoch = ChainEpoch::from(10);
@@ -950,6 +986,7 @@ fn provider_and_client_addresses_are_resolved_before_persisting_state_and_sent_t
         deal_id,
         client_resolved.id().unwrap(),
         provider_resolved.id().unwrap(),
+        &normalized_deal.piece_cid,
     );
 
     let ret: PublishStorageDealsReturn = rt
@@ -1963,6 +2000,7 @@ fn insufficient_client_balance_in_a_batch() {
         next_deal_id,
         deal2.client.id().unwrap(),
         deal2.provider.id().unwrap(),
+        &deal2.piece_cid,
     );
 
     rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, WORKER_ADDR);
@@ -2113,6 +2151,7 @@ fn insufficient_provider_balance_in_a_batch() {
         next_deal_id,
         deal2.client.id().unwrap(),
         deal2.provider.id().unwrap(),
+        &deal2.piece_cid,
     );
 
     let ret: PublishStorageDealsReturn = rt
@@ -2262,6 +2301,7 @@ fn psd_restricted_correctly() {
         next_deal_id,
         deal.client.id().unwrap(),
         deal.provider.id().unwrap(),
+        &deal.piece_cid,
     );
 
     let ret: PublishStorageDealsReturn = rt
@@ -250,6 +250,7 @@ fn terminate_valid_deals_along_with_expired_and_cleaned_up_deal() {
         deal_ids[1],
         CLIENT_ADDR.id().unwrap(),
         PROVIDER_ADDR.id().unwrap(),
+        &get_deal_proposal(&rt, deal_ids[1]).piece_cid,
     );
 
     cron_tick(&rt);
@@ -272,6 +273,34 @@ fn terminate_valid_deals_along_with_expired_and_cleaned_up_deal() {
 }
 
 // Converted from: https://github.com/filecoin-project/specs-actors/blob/d56b240af24517443ce1f8abfbdab7cb22d331f1/actors/builtin/market/market_test.go#L1415
+#[test]
+fn terminated_deal_emits_single_event_through_cleanup() {
+    let start_epoch = 10;
+    let end_epoch = start_epoch + 200 * EPOCHS_IN_DAY;
+    let sector_expiry = end_epoch + 100;
+    let current_epoch = 5;
+
+    let rt = setup();
+    rt.set_epoch(current_epoch);
+
+    let addrs = MinerAddresses::default();
+    let (id, deal) = generate_and_publish_deal(&rt, CLIENT_ADDR, &addrs, start_epoch, end_epoch);
+    activate_deals_legacy(&rt, sector_expiry, PROVIDER_ADDR, current_epoch, id, &[id]);
+
+    // Termination emits exactly one deal-terminated event.
+    terminate_deals(&rt, PROVIDER_ADDR, &[id], &[id]);
+    assert_deal_deleted(&rt, id, &deal, id, true);
+
+    // Cleaning up the dangling deal op in cron, before and after the deal's end,
+    // emits no further events.
+    rt.set_epoch(start_epoch + 2 * EPOCHS_IN_DAY);
+    cron_tick(&rt);
+    rt.set_epoch(end_epoch + 1);
+    cron_tick(&rt);
+    assert_deal_deleted(&rt, id, &deal, id, true);
+    check_state(&rt);
+}
+
 #[test]
 fn do_not_terminate_deal_if_end_epoch_is_equal_to_or_less_than_current_epoch() {
     let start_epoch = 10;
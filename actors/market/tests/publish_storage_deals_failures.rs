@@ -380,6 +380,7 @@ fn fail_when_deals_have_different_providers() {
         next_deal_id,
         deal1.client.id().unwrap(),
         deal1.provider.id().unwrap(),
+        &deal1.piece_cid,
     );
 
     let psd_ret: PublishStorageDealsReturn = rt
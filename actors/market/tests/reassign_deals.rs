@@ -0,0 +1,123 @@
+use fvm_shared::address::Address;
+use fvm_shared::clock::ChainEpoch;
+use fvm_shared::error::ExitCode;
+
+use fil_actor_market::{deal_cid, DealProposal, State};
+use fil_actors_runtime::network::EPOCHS_IN_DAY;
+use fil_actors_runtime::test_utils::expect_abort_contains_message;
+use harness::*;
+
+mod harness;
+
+const START_EPOCH: ChainEpoch = 10;
+const END_EPOCH: ChainEpoch = START_EPOCH + 200 * EPOCHS_IN_DAY;
+
+fn new_provider() -> MinerAddresses {
+    MinerAddresses {
+        owner: Address::new_id(301),
+        worker: Address::new_id(302),
+        provider: Address::new_id(300),
+        control: vec![],
+    }
+}
+
+#[test]
+fn reassign_unactivated_deals() {
+    let rt = setup();
+    let old = MinerAddresses::default();
+    let new = new_provider();
+    let (deal_id, proposal) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &old, START_EPOCH, END_EPOCH);
+    let client_before = get_balance(&rt, &CLIENT_ADDR);
+    let old_before = get_balance(&rt, &old.provider);
+
+    // The old provider proposes the reassignment, which changes nothing until confirmed.
+    reassign_deals(&rt, old.worker, &old, &new, &[deal_id], true, false, Some(true)).unwrap();
+    assert_eq!(proposal, get_deal_proposal(&rt, deal_id));
+    assert_eq!(old_before, get_balance(&rt, &old.provider));
+
+    // The new provider confirms.
+    reassign_deals(&rt, new.worker, &old, &new, &[deal_id], false, true, Some(true)).unwrap();
+    let expected = DealProposal { provider: new.provider, ..proposal.clone() };
+    assert_eq!(expected, get_deal_proposal(&rt, deal_id));
+
+    // Provider collateral moves between escrow accounts, and the client is unaffected.
+    let old_after = get_balance(&rt, &old.provider);
+    let new_after = get_balance(&rt, &new.provider);
+    assert_eq!(&old_before.balance - &proposal.provider_collateral, old_after.balance);
+    assert_eq!(&old_before.locked - &proposal.provider_collateral, old_after.locked);
+    assert_eq!(proposal.provider_collateral, new_after.balance);
+    assert_eq!(proposal.provider_collateral, new_after.locked);
+    assert_eq!(client_before, get_balance(&rt, &CLIENT_ADDR));
+
+    let st: State = rt.get_state();
+    assert!(st.has_pending_deal(&rt.store, &deal_cid(&rt, &expected).unwrap()).unwrap());
+    assert!(!st.has_pending_deal(&rt.store, &deal_cid(&rt, &proposal).unwrap()).unwrap());
+    check_state(&rt);
+}
+
+#[test]
+fn confirmation_must_match_proposal() {
+    let rt = setup();
+    let old = MinerAddresses::default();
+    let new = new_provider();
+    let (deal_id, _) = generate_and_publish_deal(&rt, CLIENT_ADDR, &old, START_EPOCH, END_EPOCH);
+
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "no matching reassignment",
+        reassign_deals(&rt, new.worker, &old, &new, &[deal_id], false, true, Some(true)),
+    );
+
+    // A proposal to a different provider doesn't authorise this one.
+    let other = MinerAddresses { provider: Address::new_id(400), ..new_provider() };
+    reassign_deals(&rt, old.worker, &old, &other, &[deal_id], true, false, Some(true)).unwrap();
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "no matching reassignment",
+        reassign_deals(&rt, new.worker, &old, &new, &[deal_id], false, true, Some(true)),
+    );
+
+    // Neither provider's controlling address.
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "is not worker or control address",
+        reassign_deals(&rt, CLIENT_ADDR, &old, &new, &[deal_id], false, false, None),
+    );
+    check_state(&rt);
+}
+
+#[test]
+fn activated_deal_cannot_be_reassigned() {
+    let rt = setup();
+    let old = MinerAddresses::default();
+    let new = new_provider();
+    let (deal_id, _) = generate_and_publish_deal(&rt, CLIENT_ADDR, &old, START_EPOCH, END_EPOCH);
+    activate_deals(&rt, END_EPOCH + 1, old.provider, 0, 1, &[deal_id]);
+
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "is activated in a sector",
+        reassign_deals(&rt, old.worker, &old, &new, &[deal_id], true, false, None),
+    );
+    check_state(&rt);
+}
+
+#[test]
+fn client_must_authorize_reassignment() {
+    let rt = setup();
+    let old = MinerAddresses::default();
+    let new = new_provider();
+    let (deal_id, proposal) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &old, START_EPOCH, END_EPOCH);
+
+    // Both providers agree, but the client hasn't signed the proposal naming the new provider.
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "client did not authorize reassignment",
+        reassign_deals(&rt, old.worker, &old, &new, &[deal_id], true, true, Some(false)),
+    );
+    rt.reset();
+    assert_eq!(proposal, get_deal_proposal(&rt, deal_id));
+    check_state(&rt);
+}
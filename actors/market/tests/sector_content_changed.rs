@@ -80,6 +80,7 @@ fn simple_one_sector() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     let ret = sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -133,6 +134,7 @@ fn simple_multiple_sectors() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     let ret = sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -167,6 +169,7 @@ fn new_deal_existing_sector() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -183,6 +186,7 @@ fn new_deal_existing_sector() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -288,6 +292,7 @@ fn failures_isolated() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     let ret = sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -331,6 +336,7 @@ fn rejects_duplicates_in_same_sector() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     let ret = sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
@@ -385,6 +391,7 @@ fn rejects_duplicates_across_sectors() {
             *deal_id,
             CLIENT_ADDR.id().unwrap(),
             MINER_ADDRESSES.provider.id().unwrap(),
+            &get_deal_proposal(&rt, *deal_id).piece_cid,
         );
     }
     let ret = sector_content_changed(&rt, PROVIDER_ADDR, changes).unwrap();
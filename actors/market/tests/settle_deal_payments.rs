@@ -95,6 +95,7 @@ fn can_manually_settle_deals_in_the_cron_queue() {
         deal_id,
         deal_proposal.client.id().unwrap(),
         deal_proposal.provider.id().unwrap(),
+        &deal_proposal.piece_cid,
     );
 
     // advance to deal end epoch and call cron
@@ -115,6 +116,36 @@ fn can_manually_settle_deals_in_the_cron_queue() {
     assert_deal_deleted(&rt, deal_id, &deal_proposal, sector_number, true)
 }
 
+#[test]
+fn settling_twice_in_the_same_epoch_is_idempotent() {
+    let rt = setup();
+    let addrs = MinerAddresses::default();
+    let sector_number = 7;
+    let (deal_id, deal_proposal) = publish_and_activate_deal(
+        &rt,
+        CLIENT_ADDR,
+        &addrs,
+        sector_number,
+        START_EPOCH,
+        END_EPOCH,
+        0,
+        END_EPOCH,
+    );
+
+    rt.set_epoch(START_EPOCH + 100);
+    let ret = settle_deal_payments(&rt, addrs.provider, &[deal_id], &[], &[]);
+    assert_eq!(&deal_proposal.storage_price_per_epoch * 100, ret.settlements[0].payment);
+    assert_eq!(START_EPOCH + 100, get_deal_state(&rt, deal_id).last_updated_epoch);
+
+    // a second settlement in the same epoch pays nothing
+    settle_deal_payments_no_change(&rt, addrs.provider, CLIENT_ADDR, addrs.provider, &[deal_id]);
+    let ret = settle_deal_payments(&rt, addrs.provider, &[deal_id], &[], &[]);
+    assert!(ret.settlements[0].payment.is_zero());
+    assert!(!ret.settlements[0].completed);
+
+    check_state(&rt);
+}
+
 #[test]
 fn settling_payments_before_activation_epoch_results_in_no_payment_or_slashing() {
     let rt = setup();
@@ -98,6 +98,7 @@ fn deal_scheduled_for_termination_cannot_be_settled_manually() {
         slashed_deal,
         slashed_prop.client.id().unwrap(),
         slashed_prop.provider.id().unwrap(),
+        &slashed_prop.piece_cid,
     );
     cron_tick(&rt);
 
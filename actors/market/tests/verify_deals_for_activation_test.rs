@@ -3,7 +3,9 @@
 
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_shared::address::Address;
+use fvm_shared::bigint::{BigInt, Zero};
 use fvm_shared::clock::ChainEpoch;
+use fvm_shared::deal::DealID;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
 use fvm_shared::piece::PieceInfo;
@@ -11,7 +13,7 @@ use fvm_shared::sector::RegisteredSealProof;
 
 use fil_actor_market::{
     ActivatedDeal, Actor as MarketActor, Method, SectorDeals, VerifyDealsForActivationParams,
-    NO_ALLOCATION_ID,
+    EX_DEAL_EXPIRED, NO_ALLOCATION_ID,
 };
 use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::test_utils::{
@@ -369,3 +371,99 @@ fn fail_when_the_same_deal_id_is_passed_multiple_times() {
     rt.verify();
     check_state(&rt);
 }
+
+#[test]
+fn check_deals_for_activation_returns_weights_without_changing_state() {
+    let rt = setup();
+    let (deal_id, deal_proposal) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &MINER_ADDRESSES, START_EPOCH, END_EPOCH);
+    let verified_deal_id = generate_and_publish_verified_deal(
+        &rt,
+        CLIENT_ADDR,
+        &MINER_ADDRESSES,
+        START_EPOCH,
+        END_EPOCH + 1,
+        1,
+    );
+    let verified_proposal = get_deal_proposal(&rt, verified_deal_id);
+
+    let state_before = *rt.state.borrow();
+    let ret = check_deals_for_activation(
+        &rt,
+        PROVIDER_ADDR,
+        vec![
+            SectorDeals {
+                sector_number: 7,
+                sector_type: RegisteredSealProof::StackedDRG8MiBV1,
+                sector_expiry: SECTOR_EXPIRY,
+                deal_ids: vec![deal_id, verified_deal_id],
+            },
+            SectorDeals {
+                sector_number: 8,
+                sector_type: RegisteredSealProof::StackedDRG8MiBV1,
+                sector_expiry: SECTOR_EXPIRY,
+                deal_ids: vec![],
+            },
+        ],
+    );
+    assert_eq!(state_before, *rt.state.borrow());
+
+    assert_eq!(2, ret.sectors.len());
+    let duration = SECTOR_EXPIRY - CURR_EPOCH;
+    let check = &ret.sectors[0];
+    assert!(check.valid);
+    assert!(check.deal_results.all_ok());
+    assert_eq!(BigInt::from(deal_proposal.piece_size.0) * duration, check.deal_weight);
+    assert_eq!(BigInt::from(verified_proposal.piece_size.0) * duration, check.verified_deal_weight);
+
+    let empty = &ret.sectors[1];
+    assert!(empty.valid);
+    assert_eq!(BigInt::zero(), empty.deal_weight);
+    assert_eq!(BigInt::zero(), empty.verified_deal_weight);
+
+    // The checked deals can still be activated.
+    activate_deals(&rt, SECTOR_EXPIRY, PROVIDER_ADDR, CURR_EPOCH, 7, &[deal_id, verified_deal_id]);
+    check_state(&rt);
+}
+
+#[test]
+fn check_deals_for_activation_reports_invalid_deals() {
+    let rt = setup();
+    let (deal_id, _) =
+        generate_and_publish_deal(&rt, CLIENT_ADDR, &MINER_ADDRESSES, START_EPOCH, END_EPOCH);
+    let sector = |deal_ids: Vec<DealID>| SectorDeals {
+        sector_number: 7,
+        sector_type: RegisteredSealProof::StackedDRG8MiBV1,
+        sector_expiry: SECTOR_EXPIRY,
+        deal_ids,
+    };
+
+    let ret = check_deals_for_activation(
+        &rt,
+        PROVIDER_ADDR,
+        vec![sector(vec![deal_id, deal_id]), sector(vec![deal_id, deal_id + 1])],
+    );
+    // Duplicate deal.
+    assert!(!ret.sectors[0].valid);
+    assert_eq!(1, ret.sectors[0].deal_results.success_count);
+    assert_eq!(
+        vec![ExitCode::OK, ExitCode::USR_ILLEGAL_ARGUMENT],
+        ret.sectors[0].deal_results.codes()
+    );
+    // Missing deal.
+    assert!(!ret.sectors[1].valid);
+    assert_eq!(vec![ExitCode::OK, ExitCode::USR_NOT_FOUND], ret.sectors[1].deal_results.codes());
+
+    // Wrong provider.
+    let ret = check_deals_for_activation(&rt, Address::new_id(501), vec![sector(vec![deal_id])]);
+    assert!(!ret.sectors[0].valid);
+    assert_eq!(vec![ExitCode::USR_FORBIDDEN], ret.sectors[0].deal_results.codes());
+    assert_eq!(BigInt::zero(), ret.sectors[0].deal_weight);
+
+    // Start epoch elapsed.
+    rt.set_epoch(START_EPOCH + 1);
+    let ret = check_deals_for_activation(&rt, PROVIDER_ADDR, vec![sector(vec![deal_id])]);
+    assert!(!ret.sectors[0].valid);
+    assert_eq!(vec![EX_DEAL_EXPIRED], ret.sectors[0].deal_results.codes());
+    check_state(&rt);
+}
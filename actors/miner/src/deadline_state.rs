@@ -325,6 +325,49 @@ impl Deadline {
         Ok(())
     }
 
+    /// Defers the early expiration of faulty sectors in all partitions due to expire at or before
+    /// an epoch to a new epoch, unless they expire on-time before then.
+    pub fn defer_fault_expirations<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        sectors: &Sectors<'_, BS>,
+        until: ChainEpoch,
+        new_expiration: ChainEpoch,
+        sector_size: SectorSize,
+        quant: QuantSpec,
+    ) -> anyhow::Result<()> {
+        if self.faulty_power.is_zero() {
+            return Ok(());
+        }
+
+        let mut partitions = self.partitions_amt(store)?;
+        let mut deferred_partitions = Vec::<u64>::new();
+        for partition_idx in 0..partitions.count() {
+            let mut partition = partitions
+                .get(partition_idx)?
+                .cloned()
+                .ok_or_else(|| anyhow!("missing expected partition {}", partition_idx))?;
+
+            let deferred = partition
+                .defer_fault_expirations(store, sectors, until, new_expiration, sector_size, quant)
+                .map_err(|e| {
+                    e.downcast_wrap(format!(
+                        "failed to defer fault expirations in partition {}",
+                        partition_idx
+                    ))
+                })?;
+            if !deferred {
+                continue;
+            }
+
+            deferred_partitions.push(partition_idx);
+            partitions.set(partition_idx, partition)?;
+        }
+
+        self.partitions = partitions.flush()?;
+        self.add_expiration_partitions(store, new_expiration, &deferred_partitions, quant)
+    }
+
     /// PopExpiredSectors terminates expired sectors from all partitions.
     /// Returns the expired sector aggregates.
     pub fn pop_expired_sectors<BS: Blockstore>(
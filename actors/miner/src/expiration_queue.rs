@@ -434,6 +434,70 @@ impl<'db, BS: Blockstore> ExpirationQueue<'db, BS> {
         Ok(recovered_power)
     }
 
+    /// Re-schedules sectors due to expire early at or before some epoch to expire early at a later
+    /// epoch (quantized) instead, if they wouldn't expire on-time before then anyway.
+    /// The sectors remain faulty. Sectors not found among the early expirations are ignored.
+    /// Returns the re-scheduled sectors.
+    pub fn reschedule_early_expirations(
+        &mut self,
+        until: ChainEpoch,
+        new_expiration: ChainEpoch,
+        sectors: &[SectorOnChainInfo],
+        sector_size: SectorSize,
+    ) -> anyhow::Result<BitField> {
+        let infos: BTreeMap<SectorNumber, &SectorOnChainInfo> =
+            sectors.iter().map(|sector| (sector.sector_number, sector)).collect();
+        let quant = self.quant;
+        let new_quantized_expiration = quant.quantize_up(new_expiration);
+
+        let mut rescheduled = Vec::<SectorNumber>::new();
+        let mut rescheduled_power = PowerPair::zero();
+        self.iter_while_mut(|epoch, expiration_set| {
+            if epoch > until {
+                return Ok(false);
+            }
+
+            let mut early_unset = Vec::new();
+            let mut power = PowerPair::zero();
+            for sector_number in expiration_set.early_sectors.iter() {
+                let sector = match infos.get(&sector_number) {
+                    Some(s) => s,
+                    None => continue,
+                };
+                // Leave sectors that would expire on-time before the new epoch to expire now.
+                if quant.quantize_up(sector.expiration) <= new_quantized_expiration {
+                    continue;
+                }
+                early_unset.push(sector_number);
+                power += &power_for_sector(sector_size, sector);
+            }
+
+            if !early_unset.is_empty() {
+                expiration_set.early_sectors -=
+                    BitField::try_from_bits(early_unset.iter().copied())?;
+                expiration_set.faulty_power -= &power;
+                expiration_set.validate_state()?;
+
+                rescheduled.extend(early_unset);
+                rescheduled_power += &power;
+            }
+            Ok(true)
+        })?;
+
+        let rescheduled = BitField::try_from_bits(rescheduled)?;
+        if !rescheduled.is_empty() {
+            self.add(
+                new_expiration,
+                &BitField::new(),
+                &rescheduled,
+                &PowerPair::zero(),
+                &rescheduled_power,
+                &TokenAmount::zero(),
+            )?;
+        }
+        Ok(rescheduled)
+    }
+
     /// Removes some sectors and adds some others.
     /// The sectors being replaced must not be faulty, so must be scheduled for on-time rather than early expiration.
     /// The sectors added are assumed to be not faulty.
@@ -18,6 +18,7 @@ use fvm_ipld_encoding::{from_slice, BytesDe, CborStore, RawBytes};
 use fvm_shared::address::{Address, Payload, Protocol};
 use fvm_shared::bigint::{BigInt, Integer};
 use fvm_shared::clock::ChainEpoch;
+use fvm_shared::consensus::ConsensusFault;
 use fvm_shared::deal::DealID;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::*;
@@ -139,6 +140,16 @@ pub enum Method {
     ProveCommitSectors3 = 34,
     ProveReplicaUpdates3 = 35,
     ProveCommitSectorsNI = 36,
+    BatchTerminateSectors = 37,
+    RepayDebtFromVesting = 38,
+    DisputeWindowedPoStBatch = 39,
+    ProveReplicaUpdatesWithRecoveries = 40,
+    WithdrawBalanceTo = 41,
+    PrepayFaultFees = 42,
+    WithdrawFaultFeeEscrow = 43,
+    ExtendSectorExpiration2Batched = 44,
+    ReportConsensusFaultBatch = 45,
+    SubmitWindowedPoStWithRecoveries = 46,
     // Method numbers derived from FRC-0042 standards
     ChangeWorkerAddressExported = frc42_dispatch::method_hash!("ChangeWorkerAddress"),
     ChangePeerIDExported = frc42_dispatch::method_hash!("ChangePeerID"),
@@ -156,6 +167,14 @@ pub enum Method {
     GetVestingFundsExported = frc42_dispatch::method_hash!("GetVestingFunds"),
     GetPeerIDExported = frc42_dispatch::method_hash!("GetPeerID"),
     GetMultiaddrsExported = frc42_dispatch::method_hash!("GetMultiaddrs"),
+    ProvingDeadlineInfoExported = frc42_dispatch::method_hash!("ProvingDeadlineInfo"),
+    RepayDebtFromVestingExported = frc42_dispatch::method_hash!("RepayDebtFromVesting"),
+    AvailableSectorNumbersExported = frc42_dispatch::method_hash!("AvailableSectorNumbers"),
+    TerminateSectorsDryRunExported = frc42_dispatch::method_hash!("TerminateSectorsDryRun"),
+    WithdrawBalanceToExported = frc42_dispatch::method_hash!("WithdrawBalanceTo"),
+    PrepayFaultFeesExported = frc42_dispatch::method_hash!("PrepayFaultFees"),
+    WithdrawFaultFeeEscrowExported = frc42_dispatch::method_hash!("WithdrawFaultFeeEscrow"),
+    SectorExpirationsExported = frc42_dispatch::method_hash!("SectorExpirations"),
 }
 
 pub const SECTOR_CONTENT_CHANGED: MethodNum = frc42_dispatch::method_hash!("SectorContentChanged");
@@ -315,9 +334,98 @@ impl Actor {
         Ok(GetVestingFundsReturn { vesting_funds: ret })
     }
 
+    /// Returns the miner's current proving deadline, computed at the current epoch.
+    /// The deadline is derived from the proving period offset rather than the deadline index
+    /// recorded in state, so it is correct even if the deadline cron has not yet run
+    /// for the current epoch (e.g. after null rounds).
+    fn proving_deadline_info(rt: &impl Runtime) -> Result<ProvingDeadlineInfoReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let state: State = rt.state()?;
+        let dl_info = state.deadline_info(rt.policy(), rt.curr_epoch());
+        Ok(ProvingDeadlineInfoReturn {
+            current_epoch: dl_info.current_epoch,
+            period_start: dl_info.period_start,
+            index: dl_info.index,
+            open: dl_info.open,
+            close: dl_info.close,
+            challenge: dl_info.challenge,
+            fault_cutoff: dl_info.fault_cutoff,
+        })
+    }
+
+    /// Returns the lowest `count` sector numbers that have been neither allocated nor masked
+    /// with CompactSectorNumbers, and so are available for new pre-commitments.
+    /// Fewer numbers are returned if there are not enough available up to MAX_SECTOR_NUMBER.
+    fn available_sector_numbers(
+        rt: &impl Runtime,
+        params: AvailableSectorNumbersParams,
+    ) -> Result<AvailableSectorNumbersReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let state: State = rt.state()?;
+        let sector_numbers = state.available_sector_numbers(rt.store(), params.count)?;
+        Ok(AvailableSectorNumbersReturn { sector_numbers })
+    }
+
+    /// Returns the expiration, deal weights and power of each of the given sectors,
+    /// in ascending sector number order.
+    /// Sectors that are not live (never committed, terminated or expired) fail with USR_NOT_FOUND.
+    fn sector_expirations(
+        rt: &impl Runtime,
+        params: SectorExpirationsParams,
+    ) -> Result<SectorExpirationsReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let policy = rt.policy();
+        let count = params.sectors.len();
+        if count > policy.addressed_sectors_max {
+            return Err(actor_error!(
+                illegal_argument,
+                "too many sectors {}, max {}",
+                count,
+                policy.addressed_sectors_max
+            ));
+        }
+
+        let state: State = rt.state()?;
+        let store = rt.store();
+        let sector_size = get_miner_info(store, &state)?.sector_size;
+
+        // Collect the requested sectors that are live in some partition.
+        let mut live = BitField::new();
+        let deadlines = state.load_deadlines(store)?;
+        deadlines
+            .for_each(store, |_, deadline| {
+                deadline.for_each(store, |_, partition| {
+                    live |= &(&partition.live_sectors() & &params.sectors);
+                    Ok(())
+                })
+            })
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to load partitions")?;
+
+        let sectors = Sectors::load(store, &state.sectors)
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors array")?;
+        let mut batch_gen = BatchReturnGen::new(count as usize);
+        let mut infos = Vec::new();
+        for sector_number in params.sectors.iter() {
+            if !live.get(sector_number) {
+                batch_gen.add_fail(ExitCode::USR_NOT_FOUND);
+                continue;
+            }
+            let sector = sectors.must_get(sector_number)?;
+            infos.push(SectorExpirationInfo {
+                expiration: sector.expiration,
+                power: power_for_sector(sector_size, &sector),
+                deal_weight: sector.deal_weight,
+                verified_deal_weight: sector.verified_deal_weight,
+            });
+            batch_gen.add_success();
+        }
+        Ok(SectorExpirationsReturn { results: batch_gen.gen(), sectors: infos })
+    }
+
     /// Will ALWAYS overwrite the existing control addresses with the control addresses passed in the params.
     /// If an empty addresses vector is passed, the control addresses will be cleared.
     /// A worker change will be scheduled if the worker passed in the params is different from the existing worker.
+    /// If the miner has no live or pre-committed sectors, the worker change takes effect immediately.
     fn change_worker_address(
         rt: &impl Runtime,
         params: ChangeWorkerAddressParams,
@@ -347,10 +455,16 @@ impl Actor {
 
             // save new_worker addr key change request
             if new_worker != info.worker && info.pending_worker_key.is_none() {
-                info.pending_worker_key = Some(WorkerKeyChange {
-                    new_worker,
-                    effective_at: rt.curr_epoch() + rt.policy().worker_key_change_delay,
-                })
+                if state.has_no_sectors(rt.store())? {
+                    // Without sectors there are no proofs to disrupt, so the change
+                    // takes effect immediately.
+                    info.worker = new_worker;
+                } else {
+                    info.pending_worker_key = Some(WorkerKeyChange {
+                        new_worker,
+                        effective_at: rt.curr_epoch() + rt.policy().worker_key_change_delay,
+                    })
+                }
             }
 
             state.save_info(rt.store(), &info).map_err(|e| {
@@ -500,8 +614,26 @@ impl Actor {
 
     /// Invoked by miner's worker address to submit their fallback post
     fn submit_windowed_post(
+        rt: &impl Runtime,
+        params: SubmitWindowedPoStParams,
+    ) -> Result<(), ActorError> {
+        Self::process_windowed_post(rt, params, &[])
+    }
+
+    /// Submits a Window PoSt together with recovery declarations for faulty sectors in the
+    /// partitions being proven. The proof must cover the recovered sectors, whose power is
+    /// restored immediately rather than at the deadline's next challenge window.
+    fn submit_windowed_post_with_recoveries(
+        rt: &impl Runtime,
+        params: SubmitWindowedPoStWithRecoveriesParams,
+    ) -> Result<(), ActorError> {
+        Self::process_windowed_post(rt, params.post, &params.recoveries)
+    }
+
+    fn process_windowed_post(
         rt: &impl Runtime,
         mut params: SubmitWindowedPoStParams,
+        recoveries: &[RecoveryDeclaration],
     ) -> Result<(), ActorError> {
         let current_epoch = rt.curr_epoch();
 
@@ -542,7 +674,7 @@ impl Actor {
             }
         }
 
-        let post_result = rt.transaction(|state: &mut State, rt| {
+        let (post_result, fee_to_burn) = rt.transaction(|state: &mut State, rt| {
             let info = get_miner_info(rt.store(), state)?;
 
             let max_proof_size = info.window_post_proof_type.proof_size().map_err(|e| {
@@ -652,6 +784,28 @@ impl Actor {
 
             let mut deadline = deadlines.load_deadline(rt.store(), params.deadline)?;
 
+            // Declare inline recoveries before recording the proof, so they're proven with it.
+            // As for DeclareFaultsRecovered, fee debt must be repaid before recovering.
+            let mut fee_to_burn = TokenAmount::zero();
+            if !recoveries.is_empty() {
+                if consensus_fault_active(&info, current_epoch) {
+                    return Err(actor_error!(
+                        forbidden,
+                        "recovery not allowed during active consensus fault"
+                    ));
+                }
+                fee_to_burn = repay_debts_or_abort(rt, state)?;
+                record_post_recoveries(
+                    rt.policy(),
+                    rt.store(),
+                    &mut deadline,
+                    &sectors,
+                    info.sector_size,
+                    &params,
+                    recoveries,
+                )?;
+            }
+
             // Record proven sectors/partitions, returning updates to power and the final set of sectors
             // proven/skipped.
             //
@@ -742,7 +896,7 @@ impl Actor {
                 e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines")
             })?;
 
-            Ok(post_result)
+            Ok((post_result, fee_to_burn))
         })?;
 
         // Restore power for recovered sectors. Remove power for new faults.
@@ -750,6 +904,7 @@ impl Actor {
         // additional accounting state.
         // https://github.com/filecoin-project/specs-actors/issues/414
         request_update_power(rt, post_result.power_delta)?;
+        burn_funds(rt, fee_to_burn)?;
 
         let state: State = rt.state()?;
         state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
@@ -1009,6 +1164,23 @@ impl Actor {
     fn prove_replica_updates3(
         rt: &impl Runtime,
         params: ProveReplicaUpdates3Params,
+    ) -> Result<ProveReplicaUpdates3Return, ActorError> {
+        Self::process_replica_updates3(rt, params, &[])
+    }
+
+    /// Proves replica updates together with recovery declarations for faulty sectors in the
+    /// partitions being updated. Recovered sectors regain power when next proven in a Window PoSt.
+    fn prove_replica_updates_with_recoveries(
+        rt: &impl Runtime,
+        params: ProveReplicaUpdatesWithRecoveriesParams,
+    ) -> Result<ProveReplicaUpdates3Return, ActorError> {
+        Self::process_replica_updates3(rt, params.updates, &params.recoveries)
+    }
+
+    fn process_replica_updates3(
+        rt: &impl Runtime,
+        params: ProveReplicaUpdates3Params,
+        recoveries: &[RecoveryDeclaration],
     ) -> Result<ProveReplicaUpdates3Return, ActorError> {
         let state: State = rt.state()?;
         let store = rt.store();
@@ -1051,6 +1223,29 @@ impl Actor {
                 "exactly one of sector proofs or aggregate proof must be non-empty"
             ));
         }
+        let mut to_recover = recovery_declarations_to_map(rt.policy(), recoveries)?;
+        for update in &params.sector_updates {
+            if recoveries.iter().any(|r| r.sectors.get(update.sector)) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "sector {} cannot be both updated and declared recovered",
+                    update.sector
+                ));
+            }
+        }
+        // Each recovery must be in a partition with an update.
+        let updated_partitions: BTreeSet<(u64, u64)> =
+            params.sector_updates.iter().map(|u| (u.deadline, u.partition)).collect();
+        for r in recoveries {
+            if !updated_partitions.contains(&(r.deadline, r.partition)) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "recovery declaration for deadline {} partition {} not covered by an update",
+                    r.deadline,
+                    r.partition
+                ));
+            }
+        }
 
         // Load sector infos for validation, failing if any don't exist.
         let mut sectors = Sectors::load(&store, &state.sectors)
@@ -1209,6 +1404,18 @@ impl Actor {
             info.sector_size,
         )?;
 
+        // Declare recoveries.
+        // Recovered sectors gain no power until they are next proven in a Window PoSt,
+        // so the power delta is unchanged.
+        if !recoveries.is_empty() {
+            let fee_to_burn = rt.transaction(|state: &mut State, rt| {
+                let fee_to_burn = repay_debts_or_abort(rt, state)?;
+                record_declared_recoveries(rt, state, &info, &mut to_recover)?;
+                Ok(fee_to_burn)
+            })?;
+            burn_funds(rt, fee_to_burn)?;
+        }
+
         notify_pledge_changed(rt, &pledge_delta)?;
         request_update_power(rt, power_delta)?;
 
@@ -1264,184 +1471,97 @@ impl Actor {
         let epoch_reward = request_current_epoch_block_reward(rt)?;
         let power_total = request_current_total_power(rt)?;
 
-        let (pledge_delta, mut to_burn, power_delta, to_reward) =
+        let (pledge_delta, to_burn, power_delta, to_reward) =
             rt.transaction(|st: &mut State, rt| {
-                let policy = rt.policy();
-                let dl_info = st.deadline_info(policy, current_epoch);
-
-                if !deadline_available_for_optimistic_post_dispute(
-                    policy,
-                    dl_info.period_start,
-                    params.deadline,
-                    current_epoch,
-                ) {
-                    return Err(actor_error!(
-                        forbidden,
-                        "can only dispute window posts during the dispute window \
-                    ({} epochs after the challenge window closes)",
-                        policy.wpost_dispute_window
-                    ));
-                }
-
                 let info = get_miner_info(rt.store(), st)?;
-                // --- check proof ---
+                let (penalty_target, reward_target, power_delta) =
+                    dispute_post(rt, st, &info, &params, &epoch_reward, &power_total)?;
+                let (pledge_delta, to_burn, to_reward) =
+                    apply_dispute_penalty(rt, st, current_epoch, &penalty_target, &reward_target)?;
+                Ok((pledge_delta, to_burn, power_delta, to_reward))
+            })?;
 
-                // Find the proving period start for the deadline in question.
-                let mut pp_start = dl_info.period_start;
-                if dl_info.index < params.deadline {
-                    pp_start -= policy.wpost_proving_period
-                }
-                let target_deadline =
-                    new_deadline_info(policy, pp_start, params.deadline, current_epoch);
-                // Load the target deadline
-                let mut deadlines_current = st
-                    .load_deadlines(rt.store())
-                    .map_err(|e| e.wrap("failed to load deadlines"))?;
-
-                let mut dl_current =
-                    deadlines_current.load_deadline(rt.store(), params.deadline)?;
-
-                // Take the post from the snapshot for dispute.
-                // This operation REMOVES the PoSt from the snapshot so
-                // it can't be disputed again. If this method fails,
-                // this operation must be rolled back.
-                let (partitions, proofs) =
-                    dl_current.take_post_proofs(rt.store(), params.post_index).map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            "failed to load proof for dispute",
-                        )
-                    })?;
+        settle_dispute(rt, reporter, power_delta, pledge_delta, to_burn, to_reward)
+    }
 
-                // Load the partition info we need for the dispute.
-                let mut dispute_info = dl_current
-                    .load_partitions_for_dispute(rt.store(), partitions)
-                    .map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            "failed to load partition for dispute",
-                        )
-                    })?;
+    /// Disputes a batch of optimistically accepted Window PoSts, possibly from different deadlines.
+    /// Each dispute is processed as for DisputeWindowedPoSt. A dispute that fails (e.g. because
+    /// the proof was valid) doesn't prevent the others from succeeding.
+    /// Penalties are applied for all successful disputes, and the disputer's reward is the sum
+    /// of the rewards for each. Fails if no dispute succeeds.
+    fn dispute_windowed_post_batch(
+        rt: &impl Runtime,
+        params: DisputeWindowedPoStBatchParams,
+    ) -> Result<DisputeWindowedPoStBatchReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let reporter = rt.message().caller();
 
-                // This includes power that is no longer active (e.g., due to sector terminations).
-                // It must only be used for penalty calculations, not power adjustments.
-                let penalised_power = dispute_info.disputed_power.clone();
+        {
+            let policy = rt.policy();
+            if params.disputes.is_empty() {
+                return Err(actor_error!(illegal_argument, "no disputes specified"));
+            }
+            if params.disputes.len() as u64 > policy.wpost_period_deadlines {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "too many disputes {}, max {}",
+                    params.disputes.len(),
+                    policy.wpost_period_deadlines
+                ));
+            }
+        }
+        let current_epoch = rt.curr_epoch();
 
-                // Load sectors for the dispute.
-                let sectors =
-                    Sectors::load(rt.store(), &dl_current.sectors_snapshot).map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            "failed to load sectors array",
-                        )
-                    })?;
-                let sector_infos = sectors
-                    .load_for_proof(&dispute_info.all_sector_nos, &dispute_info.ignored_sector_nos)
-                    .map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            "failed to load sectors to dispute window post",
-                        )
-                    })?;
+        let epoch_reward = request_current_epoch_block_reward(rt)?;
+        let power_total = request_current_total_power(rt)?;
 
-                // Check proof, we fail if validation succeeds.
-                if verify_windowed_post(rt, target_deadline.challenge, &sector_infos, proofs)? {
-                    return Err(actor_error!(illegal_argument, "failed to dispute valid post"));
-                } else {
-                    info!("Successfully disputed post- window post was invalid");
+        let (results, pledge_delta, to_burn, power_delta, to_reward) =
+            rt.transaction(|st: &mut State, rt| {
+                let policy = rt.policy();
+                let info = get_miner_info(rt.store(), st)?;
+                let mut results = BatchReturnGen::new(params.disputes.len());
+                let mut penalty_target = TokenAmount::zero();
+                let mut reward_target = TokenAmount::zero();
+                let mut power_delta = PowerPair::zero();
+                for dispute in &params.disputes {
+                    if dispute.deadline >= policy.wpost_period_deadlines {
+                        info!(
+                            "invalid deadline {} of {}",
+                            dispute.deadline, policy.wpost_period_deadlines
+                        );
+                        results.add_fail(ExitCode::USR_ILLEGAL_ARGUMENT);
+                        continue;
+                    }
+                    // State is modified only if the dispute succeeds.
+                    match dispute_post(rt, st, &info, dispute, &epoch_reward, &power_total) {
+                        Ok((penalty, reward, power)) => {
+                            penalty_target += penalty;
+                            reward_target += reward;
+                            power_delta += &power;
+                            results.add_success();
+                        }
+                        Err(e) => {
+                            info!(
+                                "failed to dispute post {} at deadline {}: {}",
+                                dispute.post_index, dispute.deadline, e
+                            );
+                            results.add_fail(e.exit_code());
+                        }
+                    }
                 }
 
-                // Ok, now we record faults. This always works because
-                // we don't allow compaction/moving sectors during the
-                // challenge window.
-                //
-                // However, some of these sectors may have been
-                // terminated. That's fine, we'll skip them.
-                let fault_expiration_epoch = target_deadline.last() + policy.fault_max_age;
-                let power_delta = dl_current
-                    .record_faults(
-                        rt.store(),
-                        &sectors,
-                        info.sector_size,
-                        quant_spec_for_deadline(policy, &target_deadline),
-                        fault_expiration_epoch,
-                        &mut dispute_info.disputed_sectors,
-                    )
-                    .map_err(|e| {
-                        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to declare faults")
-                    })?;
-
-                deadlines_current
-                    .update_deadline(policy, rt.store(), params.deadline, &dl_current)
-                    .map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            format!("failed to update deadline {}", params.deadline),
-                        )
-                    })?;
-
-                st.save_deadlines(rt.store(), deadlines_current).map_err(|e| {
-                    e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines")
-                })?;
-
-                // --- penalties ---
-
-                // Calculate the base penalty.
-                let penalty_base = pledge_penalty_for_invalid_windowpost(
-                    &epoch_reward.this_epoch_reward_smoothed,
-                    &power_total.quality_adj_power_smoothed,
-                    &penalised_power.qa,
-                );
-
-                // Calculate the target reward.
-                let reward_target =
-                    reward_for_disputed_window_post(info.window_post_proof_type, penalised_power);
-
-                // Compute the target penalty by adding the
-                // base penalty to the target reward. We don't
-                // take reward out of the penalty as the miner
-                // could end up receiving a substantial
-                // portion of their fee back as a reward.
-                let penalty_target = &penalty_base + &reward_target;
-                st.apply_penalty(&penalty_target)
-                    .map_err(|e| actor_error!(illegal_state, "failed to apply penalty {}", e))?;
-                let (penalty_from_vesting, penalty_from_balance) = st
-                    .repay_partial_debt_in_priority_order(
-                        rt.store(),
-                        current_epoch,
-                        &rt.current_balance(),
-                    )
-                    .map_err(|e| {
-                        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to pay debt")
-                    })?;
-
-                let to_burn = &penalty_from_vesting + &penalty_from_balance;
-
-                // Now, move as much of the target reward as
-                // we can from the burn to the reward.
-                let to_reward = std::cmp::min(&to_burn, &reward_target);
-                let to_burn = &to_burn - to_reward;
-                let pledge_delta = penalty_from_vesting.neg();
+                let results = results.gen();
+                if results.success_count == 0 {
+                    return Err(actor_error!(illegal_argument, "failed to dispute any post"));
+                }
 
-                Ok((pledge_delta, to_burn, power_delta, to_reward.clone()))
+                let (pledge_delta, to_burn, to_reward) =
+                    apply_dispute_penalty(rt, st, current_epoch, &penalty_target, &reward_target)?;
+                Ok((results, pledge_delta, to_burn, power_delta, to_reward))
             })?;
 
-        request_update_power(rt, power_delta)?;
-        if !to_reward.is_zero() {
-            if let Err(e) =
-                extract_send_result(rt.send_simple(&reporter, METHOD_SEND, None, to_reward.clone()))
-            {
-                error!("failed to send reward: {}", e);
-                to_burn += to_reward;
-            }
-        }
-
-        burn_funds(rt, to_burn)?;
-        notify_pledge_changed(rt, &pledge_delta)?;
-
-        let st: State = rt.state()?;
-        st.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
-        Ok(())
+        settle_dispute(rt, reporter, power_delta, pledge_delta, to_burn, to_reward)?;
+        Ok(DisputeWindowedPoStBatchReturn { results })
     }
 
     /// Pledges the miner to seal and commit some new sectors.
@@ -2002,6 +2122,12 @@ impl Actor {
         Ok(())
     }
 
+    /// Commits and activates sectors with an aggregated non-interactive seal proof,
+    /// without a preceding pre-commitment. Only CC sectors (without data) may be committed.
+    /// Sectors that fail validation (e.g. an expiration too soon after activation) are skipped,
+    /// unless require_activation_success is set, in which case any failure aborts.
+    /// Duplicate sector numbers in the batch abort before any state is changed.
+    /// The aggregate fee is charged for sectors beyond NI_AGGREGATE_FEE_BASE_SECTOR_COUNT.
     fn prove_commit_sectors_ni(
         rt: &impl Runtime,
         params: ProveCommitSectorsNIParams,
@@ -2281,6 +2407,56 @@ impl Actor {
         )
     }
 
+    /// Processes the declarations of an ExtendSectorExpiration2 call in bounded chunks.
+    /// Starting at index `cursor` of `extensions`, declarations are extended in order until
+    /// their cost would exceed `max_partitions`. Each declaration costs one unit for the
+    /// partition it touches, plus one for each claim-bearing sector, whose claims are
+    /// fetched from the verified registry. At least one declaration is processed per call.
+    /// A declaration is either extended in full or left untouched, so partitions are never
+    /// left half-extended. If the return value is partial, the caller should resubmit the
+    /// same extensions with the returned cursor.
+    fn extend_sector_expiration2_batched(
+        rt: &impl Runtime,
+        params: ExtendSectorExpiration2BatchedParams,
+    ) -> Result<ExtendSectorExpiration2BatchedReturn, ActorError> {
+        if params.max_partitions == 0 {
+            return Err(actor_error!(illegal_argument, "max partitions must be positive"));
+        }
+        let mut extensions = params.extensions;
+        let total = extensions.len() as u64;
+        if params.cursor > total {
+            return Err(actor_error!(
+                illegal_argument,
+                "cursor {} exceeds extension count {}",
+                params.cursor,
+                total
+            ));
+        }
+
+        let start = params.cursor as usize;
+        let mut end = start;
+        let mut spent = 0u64;
+        for decl in &extensions[start..] {
+            let cost = 1 + decl.sectors_with_claims.len() as u64;
+            if end > start && spent + cost > params.max_partitions {
+                break;
+            }
+            spent += cost;
+            end += 1;
+        }
+
+        let batch: Vec<_> = extensions.drain(start..end).collect();
+        let extend_expiration_inner = validate_extension_declarations(rt, batch)?;
+        Self::extend_sector_expiration_inner(
+            rt,
+            extend_expiration_inner,
+            ExtensionKind::ExtendCommittment,
+        )?;
+
+        let next_cursor = end as u64;
+        Ok(ExtendSectorExpiration2BatchedReturn { partial: next_cursor < total, next_cursor })
+    }
+
     fn extend_sector_expiration_inner(
         rt: &impl Runtime,
         inner: ExtendExpirationsInner,
@@ -2538,115 +2714,214 @@ impl Actor {
             })?;
         }
 
-        {
-            let policy = rt.policy();
-            to_process
-                .check(policy.addressed_partitions_max, policy.addressed_sectors_max)
-                .map_err(|e| {
-                    actor_error!(illegal_argument, "cannot process requested parameters: {}", e)
-                })?;
-        }
+        let done = terminate_sectors_in_map(rt, to_process)?;
+        Ok(TerminateSectorsReturn { done })
+    }
 
-        let (had_early_terminations, power_delta) = rt.transaction(|state: &mut State, rt| {
-            let had_early_terminations = have_pending_early_terminations(state);
+    /// Terminates sectors across any number of deadlines, up to a maximum number of sectors.
+    /// Declarations are processed in order. If the limit is reached, the remaining declared
+    /// sectors are left live and the return value indicates a partial termination.
+    fn batch_terminate_sectors(
+        rt: &impl Runtime,
+        params: BatchTerminateSectorsParams,
+    ) -> Result<BatchTerminateSectorsReturn, ActorError> {
+        let policy = rt.policy();
+        if params.terminations.len() as u64 > policy.declarations_max {
+            return Err(actor_error!(
+                illegal_argument,
+                "too many declarations when terminating sectors: {} > {}",
+                params.terminations.len(),
+                policy.declarations_max
+            ));
+        }
 
-            let info = get_miner_info(rt.store(), state)?;
+        let max_sectors = params.max_sectors.unwrap_or(policy.addressed_sectors_max);
+        if max_sectors == 0 || max_sectors > policy.addressed_sectors_max {
+            return Err(actor_error!(
+                illegal_argument,
+                "max sectors {} must be between 1 and {}",
+                max_sectors,
+                policy.addressed_sectors_max
+            ));
+        }
 
-            rt.validate_immediate_caller_is(
-                info.control_addresses.iter().chain(&[info.worker, info.owner]),
-            )?;
+        let mut to_process = DeadlineSectorMap::new();
+        let mut remaining = max_sectors;
+        let mut partial = false;
 
-            let store = rt.store();
-            let curr_epoch = rt.curr_epoch();
-            let mut power_delta = PowerPair::zero();
+        for term in params.terminations {
+            let deadline = term.deadline;
+            let partition = term.partition;
 
-            let mut deadlines =
-                state.load_deadlines(store).map_err(|e| e.wrap("failed to load deadlines"))?;
+            let sectors = if term.sectors.len() > remaining {
+                partial = true;
+                term.sectors.slice(0, remaining).map_err(|e| {
+                    actor_error!(
+                        illegal_argument,
+                        "failed to limit sectors in deadline {}, partition {}: {}",
+                        deadline,
+                        partition,
+                        e
+                    )
+                })?
+            } else {
+                term.sectors
+            };
+            if sectors.is_empty() {
+                continue;
+            }
+            remaining -= sectors.len();
 
-            // We're only reading the sectors, so there's no need to save this back.
-            // However, we still want to avoid re-loading this array per-partition.
-            let sectors = Sectors::load(store, &state.sectors).map_err(|e| {
-                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors")
+            to_process.add(policy, deadline, partition, sectors).map_err(|e| {
+                actor_error!(
+                    illegal_argument,
+                    "failed to process deadline {}, partition {}: {}",
+                    deadline,
+                    partition,
+                    e
+                )
             })?;
+        }
 
-            for (deadline_idx, partition_sectors) in to_process.iter() {
-                // If the deadline is the current or next deadline to prove, don't allow terminating sectors.
-                // We assume that deadlines are immutable when being proven.
-                if !deadline_is_mutable(
-                    rt.policy(),
-                    state.current_proving_period_start(rt.policy(), curr_epoch),
-                    deadline_idx,
-                    curr_epoch,
-                ) {
-                    return Err(actor_error!(
-                        illegal_argument,
-                        "cannot terminate sectors in immutable deadline {}",
-                        deadline_idx
-                    ));
-                }
-
-                let quant = state.quant_spec_for_deadline(rt.policy(), deadline_idx);
-                let mut deadline = deadlines.load_deadline(store, deadline_idx)?;
+        let done = terminate_sectors_in_map(rt, to_process)?;
+        Ok(BatchTerminateSectorsReturn { done, partial })
+    }
 
-                let removed_power = deadline
-                    .terminate_sectors(
-                        rt.policy(),
-                        store,
-                        &sectors,
-                        curr_epoch,
-                        partition_sectors,
-                        info.sector_size,
-                        quant,
-                    )
-                    .map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            format!("failed to terminate sectors in deadline {}", deadline_idx),
-                        )
-                    })?;
-
-                state.early_terminations.set(deadline_idx);
-                power_delta -= &removed_power;
+    /// Computes the termination fee that would be charged for terminating sectors with
+    /// TerminateSectors at the current epoch, and the funds that would be burnt to pay it,
+    /// without changing state.
+    /// Deadlines are accounted in order, each repaying any fee debt left by the previous ones.
+    /// The result accounts only for the declared sectors, not any previously pending terminations.
+    fn terminate_sectors_dry_run(
+        rt: &impl Runtime,
+        params: TerminateSectorsParams,
+    ) -> Result<TerminateSectorsDryRunReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let policy = rt.policy();
+        if params.terminations.len() as u64 > policy.declarations_max {
+            return Err(actor_error!(
+                illegal_argument,
+                "too many declarations when terminating sectors: {} > {}",
+                params.terminations.len(),
+                policy.declarations_max
+            ));
+        }
 
-                deadlines.update_deadline(rt.policy(), store, deadline_idx, &deadline).map_err(
-                    |e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            format!("failed to update deadline {}", deadline_idx),
-                        )
-                    },
-                )?;
-            }
+        let mut to_process = DeadlineSectorMap::new();
+        for term in params.terminations {
+            let deadline = term.deadline;
+            let partition = term.partition;
 
-            state.save_deadlines(store, deadlines).map_err(|e| {
-                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines")
+            to_process.add(policy, deadline, partition, term.sectors).map_err(|e| {
+                actor_error!(
+                    illegal_argument,
+                    "failed to process deadline {}, partition {}: {}",
+                    deadline,
+                    partition,
+                    e
+                )
             })?;
+        }
+        to_process.check(policy.addressed_partitions_max, policy.addressed_sectors_max).map_err(
+            |e| actor_error!(illegal_argument, "cannot process requested parameters: {}", e),
+        )?;
 
-            Ok((had_early_terminations, power_delta))
-        })?;
         let epoch_reward = request_current_epoch_block_reward(rt)?;
         let pwr_total = request_current_total_power(rt)?;
 
-        // Now, try to process these sectors.
-        let more = process_early_terminations(
-            rt,
-            &epoch_reward.this_epoch_reward_smoothed,
-            &pwr_total.quality_adj_power_smoothed,
-        )?;
+        let state: State = rt.state()?;
+        let store = rt.store();
+        let curr_epoch = rt.curr_epoch();
+        let info = get_miner_info(store, &state)?;
+        let deadlines =
+            state.load_deadlines(store).map_err(|e| e.wrap("failed to load deadlines"))?;
+        let sectors = Sectors::load(store, &state.sectors).map_err(|e| {
+            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors")
+        })?;
 
-        if more && !had_early_terminations {
-            // We have remaining terminations, and we didn't _previously_
-            // have early terminations to process, schedule a cron job.
-            // NOTE: This isn't quite correct. If we repeatedly fill, empty,
-            // fill, and empty, the queue, we'll keep scheduling new cron
-            // jobs. However, in practice, that shouldn't be all that bad.
-            schedule_early_termination_work(rt)?;
+        // Fees are paid in a copy of the state, which is then discarded.
+        let mut simulated = state.clone();
+        let mut balance = rt.current_balance();
+        let mut penalties = Vec::new();
+        for (deadline_idx, partition_sectors) in to_process.iter() {
+            if !deadline_is_mutable(
+                policy,
+                state.current_proving_period_start(policy, curr_epoch),
+                deadline_idx,
+                curr_epoch,
+            ) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cannot terminate sectors in immutable deadline {}",
+                    deadline_idx
+                ));
+            }
+
+            // Terminate the sectors in a copy of the deadline, which is then discarded,
+            // so that the declaration is validated exactly as TerminateSectors would.
+            let quant = state.quant_spec_for_deadline(policy, deadline_idx);
+            let mut deadline = deadlines.load_deadline(store, deadline_idx)?;
+            deadline
+                .terminate_sectors(
+                    policy,
+                    store,
+                    &sectors,
+                    curr_epoch,
+                    partition_sectors,
+                    info.sector_size,
+                    quant,
+                )
+                .map_err(|e| {
+                    e.downcast_default(
+                        ExitCode::USR_ILLEGAL_STATE,
+                        format!("failed to terminate sectors in deadline {}", deadline_idx),
+                    )
+                })?;
+
+            let mut initial_pledge = TokenAmount::zero();
+            let mut termination_fee = TokenAmount::zero();
+            for (_, sector_numbers) in partition_sectors.iter() {
+                let infos = sectors
+                    .load_sector(sector_numbers)
+                    .map_err(|e| e.wrap("failed to load sector infos"))?;
+                for sector in &infos {
+                    initial_pledge += &sector.initial_pledge;
+                    termination_fee += termination_penalty_for_sector(
+                        info.sector_size,
+                        sector,
+                        curr_epoch,
+                        &epoch_reward.this_epoch_reward_smoothed,
+                        &pwr_total.quality_adj_power_smoothed,
+                    );
+                }
+            }
+
+            // Pay the fee as process_early_terminations would, releasing the pledge
+            // and then repaying fee debt from vesting funds and unlocked balance.
+            let fault_fee = simulated.fee_debt.clone();
+            simulated
+                .apply_penalty(&termination_fee)
+                .map_err(|e| actor_error!(illegal_state, "failed to apply penalty: {}", e))?;
+            simulated.add_initial_pledge(&-initial_pledge).map_err(|e| {
+                actor_error!(illegal_state, "failed to release initial pledge: {}", e)
+            })?;
+            let (from_vesting, from_balance) = simulated
+                .repay_partial_debt_in_priority_order(store, curr_epoch, &balance)
+                .map_err(|e| {
+                    e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to repay penalty")
+                })?;
+            let pledge_to_burn = from_vesting + from_balance;
+            balance -= &pledge_to_burn;
+
+            penalties.push(DeadlineTerminationPenalty {
+                deadline: deadline_idx,
+                pledge_to_burn,
+                fault_fee,
+                termination_fee,
+            });
         }
-        let state: State = rt.state()?;
-        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
 
-        request_update_power(rt, power_delta)?;
-        Ok(TerminateSectorsReturn { done: !more })
+        Ok(TerminateSectorsDryRunReturn { deadlines: penalties })
     }
 
     fn declare_faults(rt: &impl Runtime, params: DeclareFaultsParams) -> Result<(), ActorError> {
@@ -2783,43 +3058,7 @@ impl Actor {
         rt: &impl Runtime,
         params: DeclareFaultsRecoveredParams,
     ) -> Result<(), ActorError> {
-        {
-            let policy = rt.policy();
-            if params.recoveries.len() as u64 > policy.declarations_max {
-                return Err(actor_error!(
-                    illegal_argument,
-                    "too many recovery declarations for a single message: {} > {}",
-                    params.recoveries.len(),
-                    policy.declarations_max
-                ));
-            }
-        }
-
-        let mut to_process = DeadlineSectorMap::new();
-
-        for term in params.recoveries {
-            let deadline = term.deadline;
-            let partition = term.partition;
-
-            to_process.add(rt.policy(), deadline, partition, term.sectors).map_err(|e| {
-                actor_error!(
-                    illegal_argument,
-                    "failed to process deadline {}, partition {}: {}",
-                    deadline,
-                    partition,
-                    e
-                )
-            })?;
-        }
-
-        {
-            let policy = rt.policy();
-            to_process
-                .check(policy.addressed_partitions_max, policy.addressed_sectors_max)
-                .map_err(|e| {
-                    actor_error!(illegal_argument, "cannot process requested parameters: {}", e)
-                })?;
-        }
+        let mut to_process = recovery_declarations_to_map(rt.policy(), &params.recoveries)?;
 
         let fee_to_burn = rt.transaction(|state: &mut State, rt| {
             // Verify unlocked funds cover both InitialPledgeRequirement and FeeDebt
@@ -2832,71 +3071,7 @@ impl Actor {
                 info.control_addresses.iter().chain(&[info.worker, info.owner]),
             )?;
 
-            if consensus_fault_active(&info, rt.curr_epoch()) {
-                return Err(actor_error!(
-                    forbidden,
-                    "recovery not allowed during active consensus fault"
-                ));
-            }
-
-            let store = rt.store();
-
-            let mut deadlines =
-                state.load_deadlines(store).map_err(|e| e.wrap("failed to load deadlines"))?;
-
-            let sectors = Sectors::load(store, &state.sectors).map_err(|e| {
-                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors array")
-            })?;
-            let curr_epoch = rt.curr_epoch();
-            for (deadline_idx, partition_map) in to_process.iter() {
-                let policy = rt.policy();
-                let target_deadline = declaration_deadline_info(
-                    policy,
-                    state.current_proving_period_start(policy, curr_epoch),
-                    deadline_idx,
-                    curr_epoch,
-                )
-                .map_err(|e| {
-                    actor_error!(
-                        illegal_argument,
-                        "invalid recovery declaration deadline {}: {}",
-                        deadline_idx,
-                        e
-                    )
-                })?;
-
-                validate_fr_declaration_deadline(&target_deadline).map_err(|e| {
-                    actor_error!(
-                        illegal_argument,
-                        "failed recovery declaration at deadline {}: {}",
-                        deadline_idx,
-                        e
-                    )
-                })?;
-
-                let mut deadline = deadlines.load_deadline(store, deadline_idx)?;
-
-                deadline
-                    .declare_faults_recovered(store, &sectors, info.sector_size, partition_map)
-                    .map_err(|e| {
-                        e.downcast_default(
-                            ExitCode::USR_ILLEGAL_STATE,
-                            format!("failed to declare recoveries for deadline {}", deadline_idx),
-                        )
-                    })?;
-
-                deadlines.update_deadline(policy, store, deadline_idx, &deadline).map_err(|e| {
-                    e.downcast_default(
-                        ExitCode::USR_ILLEGAL_STATE,
-                        format!("failed to store deadline {}", deadline_idx),
-                    )
-                })?;
-            }
-
-            state.save_deadlines(store, deadlines).map_err(|e| {
-                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines")
-            })?;
-
+            record_declared_recoveries(rt, state, &info, &mut to_process)?;
             Ok(fee_to_burn)
         })?;
 
@@ -3172,201 +3347,76 @@ impl Actor {
         rt.validate_immediate_caller_accept_any()?;
         let reporter = rt.message().caller();
 
-        let fault = rt
-            .verify_consensus_fault(&params.header1, &params.header2, &params.header_extra)
-            .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_ARGUMENT, "fault not verified"))?
-            .ok_or_else(|| actor_error!(illegal_argument, "No consensus fault found"))?;
-        if fault.target != rt.message().receiver() {
-            return Err(actor_error!(
-                illegal_argument,
-                "fault by {} reported to miner {}",
-                fault.target,
-                rt.message().receiver()
-            ));
-        }
+        let fault = verify_consensus_fault_report(rt, &params)?;
+        penalize_consensus_fault(rt, &reporter, fault.epoch)
+    }
 
-        // Elapsed since the fault (i.e. since the higher of the two blocks)
-        let fault_age = rt.curr_epoch() - fault.epoch;
-        if fault_age <= 0 {
+    /// Reports several consensus faults by this miner together.
+    /// Every report must be valid and within the consensus fault reporting window, and a batch
+    /// may have at most `declarations_max` reports.
+    /// Reports describing the same fault are counted once. The faults result in a single
+    /// penalty and reporter reward, as for one report of the most recent fault, so the
+    /// miner is never slashed more than once for a batch.
+    fn report_consensus_fault_batch(
+        rt: &impl Runtime,
+        params: ReportConsensusFaultBatchParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let reporter = rt.message().caller();
+
+        if params.reports.is_empty() {
+            return Err(actor_error!(illegal_argument, "no consensus fault reports"));
+        }
+        if params.reports.len() as u64 > rt.policy().declarations_max {
             return Err(actor_error!(
                 illegal_argument,
-                "invalid fault epoch {} ahead of current {}",
-                fault.epoch,
-                rt.curr_epoch()
+                "too many consensus fault reports for a single message: {} > {}",
+                params.reports.len(),
+                rt.policy().declarations_max
             ));
         }
 
-        // Reward reporter with a share of the miner's current balance.
-        let reward_stats = request_current_epoch_block_reward(rt)?;
-
-        // The policy amounts we should burn and send to reporter
-        // These may differ from actual funds send when miner goes into fee debt
-        let this_epoch_reward =
-            TokenAmount::from_atto(reward_stats.this_epoch_reward_smoothed.estimate());
-        let fault_penalty = consensus_fault_penalty(this_epoch_reward.clone());
-        let slasher_reward = reward_for_consensus_slash_report(&this_epoch_reward);
-
-        let mut pledge_delta = TokenAmount::zero();
-
-        let (burn_amount, reward_amount) = rt.transaction(|st: &mut State, rt| {
-            let mut info = get_miner_info(rt.store(), st)?;
-
-            // Verify miner hasn't already been faulted
-            if fault.epoch < info.consensus_fault_elapsed {
+        let mut faults = BTreeSet::<(ChainEpoch, u8)>::new();
+        for (i, report) in params.reports.iter().enumerate() {
+            let fault = verify_consensus_fault_report(rt, report)
+                .with_context(|| format!("invalid consensus fault report at index {}", i))?;
+            let fault_age = rt.curr_epoch() - fault.epoch;
+            if fault_age > rt.policy().consensus_fault_reporting_window {
                 return Err(actor_error!(
-                    forbidden,
-                    "fault epoch {} is too old, last exclusion period ended at {}",
+                    illegal_argument,
+                    "fault at epoch {} in report {} is outside the reporting window of {} epochs",
                     fault.epoch,
-                    info.consensus_fault_elapsed
+                    i,
+                    rt.policy().consensus_fault_reporting_window
                 ));
             }
-
-            st.apply_penalty(&fault_penalty).map_err(|e| {
-                actor_error!(illegal_state, format!("failed to apply penalty: {}", e))
-            })?;
-
-            // Pay penalty
-            let (penalty_from_vesting, penalty_from_balance) = st
-                .repay_partial_debt_in_priority_order(
-                    rt.store(),
-                    rt.curr_epoch(),
-                    &rt.current_balance(),
-                )
-                .map_err(|e| {
-                    e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to pay fees")
-                })?;
-
-            let mut burn_amount = &penalty_from_vesting + &penalty_from_balance;
-            pledge_delta -= penalty_from_vesting;
-
-            // clamp reward at funds burnt
-            let reward_amount = std::cmp::min(&burn_amount, &slasher_reward).clone();
-            burn_amount -= &reward_amount;
-
-            info.consensus_fault_elapsed =
-                rt.curr_epoch() + rt.policy().consensus_fault_ineligibility_duration;
-
-            st.save_info(rt.store(), &info).map_err(|e| {
-                e.downcast_default(ExitCode::USR_SERIALIZATION, "failed to save miner info")
-            })?;
-
-            Ok((burn_amount, reward_amount))
-        })?;
-
-        if let Err(e) =
-            extract_send_result(rt.send_simple(&reporter, METHOD_SEND, None, reward_amount))
-        {
-            error!("failed to send reward: {}", e);
+            faults.insert((fault.epoch, fault.fault_type as u8));
         }
 
-        burn_funds(rt, burn_amount)?;
-        notify_pledge_changed(rt, &pledge_delta)?;
-
-        let state: State = rt.state()?;
-        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
-        Ok(())
+        // The latest fault is the one most likely to fall after any previous exclusion period.
+        let (latest_epoch, _) = *faults.last().unwrap();
+        penalize_consensus_fault(rt, &reporter, latest_epoch)
     }
 
     fn withdraw_balance(
         rt: &impl Runtime,
         params: WithdrawBalanceParams,
     ) -> Result<WithdrawBalanceReturn, ActorError> {
-        if params.amount_requested.is_negative() {
-            return Err(actor_error!(
-                illegal_argument,
-                "negative fund requested for withdrawal: {}",
-                params.amount_requested
-            ));
-        }
-
-        let (info, amount_withdrawn, newly_vested, fee_to_burn, state) =
-            rt.transaction(|state: &mut State, rt| {
-                let mut info = get_miner_info(rt.store(), state)?;
-
-                // Only the owner or the beneficiary is allowed to withdraw the balance.
-                rt.validate_immediate_caller_is(&[info.owner, info.beneficiary])?;
-
-                // Ensure we don't have any pending terminations.
-                if !state.early_terminations.is_empty() {
-                    return Err(actor_error!(
-                        forbidden,
-                        "cannot withdraw funds while {} deadlines have terminated sectors \
-                        with outstanding fees",
-                        state.early_terminations.len()
-                    ));
-                }
-
-                // Unlock vested funds so we can spend them.
-                let newly_vested =
-                    state.unlock_vested_funds(rt.store(), rt.curr_epoch()).map_err(|e| {
-                        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "Failed to vest fund")
-                    })?;
-
-                // available balance already accounts for fee debt so it is correct to call
-                // this before RepayDebts. We would have to
-                // subtract fee debt explicitly if we called this after.
-                let available_balance =
-                    state.get_available_balance(&rt.current_balance()).map_err(|e| {
-                        actor_error!(
-                            illegal_state,
-                            format!("failed to calculate available balance: {}", e)
-                        )
-                    })?;
-
-                // Verify unlocked funds cover both InitialPledgeRequirement and FeeDebt
-                // and repay fee debt now.
-                let fee_to_burn = repay_debts_or_abort(rt, state)?;
-                let mut amount_withdrawn =
-                    std::cmp::min(&available_balance, &params.amount_requested);
-                if amount_withdrawn.is_negative() {
-                    return Err(actor_error!(
-                        illegal_state,
-                        "negative amount to withdraw: {}",
-                        amount_withdrawn
-                    ));
-                }
-                if info.beneficiary != info.owner {
-                    // remaining_quota always zero and positive
-                    let remaining_quota = info.beneficiary_term.available(rt.curr_epoch());
-                    if remaining_quota.is_zero() {
-                        return Err(actor_error!(
-                            forbidden,
-                            "beneficiary expiration of epoch {} passed or quota of {} depleted with {} used",
-                            info.beneficiary_term.expiration,
-                            info.beneficiary_term.quota,
-                            info.beneficiary_term.used_quota
-                        ));
-                    }
-                    amount_withdrawn = std::cmp::min(amount_withdrawn, &remaining_quota);
-                    if amount_withdrawn.is_positive() {
-                        info.beneficiary_term.used_quota += amount_withdrawn;
-                        state.save_info(rt.store(), &info).map_err(|e| {
-                            e.downcast_default(
-                                ExitCode::USR_ILLEGAL_STATE,
-                                "failed to save miner info",
-                            )
-                        })?;
-                    }
-                    Ok((info, amount_withdrawn.clone(), newly_vested, fee_to_burn, state.clone()))
-                } else {
-                    Ok((info, amount_withdrawn.clone(), newly_vested, fee_to_burn, state.clone()))
-                }
-            })?;
-
-        if amount_withdrawn.is_positive() {
-            extract_send_result(rt.send_simple(
-                &info.beneficiary,
-                METHOD_SEND,
-                None,
-                amount_withdrawn.clone(),
-            ))?;
-        }
-
-        burn_funds(rt, fee_to_burn)?;
-        notify_pledge_changed(rt, &newly_vested.neg())?;
+        withdraw_available_balance(rt, params.amount_requested, None)
+    }
 
-        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
-        Ok(WithdrawBalanceReturn { amount_withdrawn })
+    /// Withdraws available balance like WithdrawBalance, but sends the funds to the given
+    /// address rather than the beneficiary. Only the party entitled to the withdrawn funds
+    /// may redirect them: the owner when there is no separate beneficiary, otherwise the
+    /// beneficiary, within its remaining quota.
+    fn withdraw_balance_to(
+        rt: &impl Runtime,
+        params: WithdrawBalanceToParams,
+    ) -> Result<WithdrawBalanceReturn, ActorError> {
+        let to = rt.resolve_address(&params.to).ok_or_else(|| {
+            actor_error!(illegal_argument, "unable to resolve withdrawal address {}", params.to)
+        })?;
+        withdraw_available_balance(rt, params.amount_requested, Some(Address::new_id(to)))
     }
 
     /// Proposes or confirms a change of beneficiary address.
@@ -3546,6 +3596,128 @@ impl Actor {
         Ok(())
     }
 
+    /// Repays fee debt by burning up to the requested amount of locked funds that have not
+    /// yet vested. Funds that have already vested are not touched.
+    /// Fails if the miner has no fee debt.
+    /// Returns the fee debt remaining after repayment.
+    fn repay_debt_from_vesting(
+        rt: &impl Runtime,
+        params: RepayDebtFromVestingParams,
+    ) -> Result<RepayDebtFromVestingReturn, ActorError> {
+        let (from_vesting, state) = rt.transaction(|state: &mut State, rt| {
+            let info = get_miner_info(rt.store(), state)?;
+            rt.validate_immediate_caller_is(
+                info.control_addresses.iter().chain(&[info.worker, info.owner]),
+            )?;
+
+            if !params.amount.is_positive() {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "amount to repay {} must be positive",
+                    params.amount
+                ));
+            }
+            if !state.fee_debt.is_positive() {
+                return Err(actor_error!(forbidden, "miner has no fee debt to repay"));
+            }
+
+            let from_vesting = state
+                .repay_debt_from_vesting(rt.store(), rt.curr_epoch(), &params.amount)
+                .map_err(|e| {
+                    e.downcast_default(
+                        ExitCode::USR_ILLEGAL_STATE,
+                        "failed to repay fee debt from vesting funds",
+                    )
+                })?;
+
+            Ok((from_vesting, state.clone()))
+        })?;
+
+        notify_pledge_changed(rt, &from_vesting.clone().neg())?;
+        burn_funds(rt, from_vesting)?;
+
+        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+        Ok(RepayDebtFromVestingReturn { remaining_debt: state.fee_debt })
+    }
+
+    /// Deposits the value sent into the fault fee escrow, from which deadline cron pays
+    /// continued fault fees before incurring fee debt.
+    /// Escrowed funds are not available for pledge or withdrawal until withdrawn from the escrow.
+    /// While the escrow covers a deadline's continued fault fee, its faulty sectors are not
+    /// terminated for exceeding the maximum fault age. Their fault expiration is deferred by a
+    /// proving period each time the fee is paid from the escrow.
+    fn prepay_fault_fees(rt: &impl Runtime) -> Result<(), ActorError> {
+        let state = rt.transaction(|state: &mut State, rt| {
+            let info = get_miner_info(rt.store(), state)?;
+            rt.validate_immediate_caller_is(
+                info.control_addresses.iter().chain(&[info.worker, info.owner]),
+            )?;
+
+            let deposit = rt.message().value_received();
+            if !deposit.is_positive() {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "fault fee prepayment {} must be positive",
+                    deposit
+                ));
+            }
+            state.fault_fee_escrow += deposit;
+            Ok(state.clone())
+        })?;
+
+        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+        Ok(())
+    }
+
+    /// Withdraws up to the requested amount from the fault fee escrow to the owner.
+    /// May only be called by the owner, and only while the miner has no faulty sectors.
+    fn withdraw_fault_fee_escrow(
+        rt: &impl Runtime,
+        params: WithdrawBalanceParams,
+    ) -> Result<WithdrawBalanceReturn, ActorError> {
+        if params.amount_requested.is_negative() {
+            return Err(actor_error!(
+                illegal_argument,
+                "negative fund requested for withdrawal: {}",
+                params.amount_requested
+            ));
+        }
+
+        let (info, amount_withdrawn, state) = rt.transaction(|state: &mut State, rt| {
+            let info = get_miner_info(rt.store(), state)?;
+            rt.validate_immediate_caller_is(std::iter::once(&info.owner))?;
+
+            let deadlines = state.load_deadlines(rt.store())?;
+            for dl_idx in 0..rt.policy().wpost_period_deadlines {
+                let deadline = deadlines.load_deadline(rt.store(), dl_idx)?;
+                if !deadline.faulty_power.is_zero() {
+                    return Err(actor_error!(
+                        forbidden,
+                        "cannot withdraw fault fee escrow while deadline {} has faulty sectors",
+                        dl_idx
+                    ));
+                }
+            }
+
+            let amount_withdrawn =
+                std::cmp::min(&params.amount_requested, &state.fault_fee_escrow).clone();
+            state.fault_fee_escrow -= &amount_withdrawn;
+            Ok((info, amount_withdrawn, state.clone()))
+        })?;
+
+        if amount_withdrawn.is_positive() {
+            extract_send_result(rt.send_simple(
+                &info.owner,
+                METHOD_SEND,
+                None,
+                amount_withdrawn.clone(),
+            ))?;
+        }
+
+        state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+        Ok(WithdrawBalanceReturn { amount_withdrawn })
+    }
+
     fn on_deferred_cron_event(
         rt: &impl Runtime,
         params: DeferredCronEventParams,
@@ -4325,6 +4497,143 @@ fn update_existing_sector_info(
     new_sector_info
 }
 
+/// Terminates the sectors in the given deadline sector map, processing as many of the
+/// resulting early terminations as possible. Returns true if all early termination work
+/// has been completed.
+fn terminate_sectors_in_map(
+    rt: &impl Runtime,
+    to_process: DeadlineSectorMap,
+) -> Result<bool, ActorError> {
+    {
+        let policy = rt.policy();
+        to_process.check(policy.addressed_partitions_max, policy.addressed_sectors_max).map_err(
+            |e| actor_error!(illegal_argument, "cannot process requested parameters: {}", e),
+        )?;
+    }
+
+    let (had_early_terminations, power_delta) = rt.transaction(|state: &mut State, rt| {
+        let had_early_terminations = have_pending_early_terminations(state);
+
+        let info = get_miner_info(rt.store(), state)?;
+
+        rt.validate_immediate_caller_is(
+            info.control_addresses.iter().chain(&[info.worker, info.owner]),
+        )?;
+
+        let store = rt.store();
+        let curr_epoch = rt.curr_epoch();
+        let mut power_delta = PowerPair::zero();
+
+        let mut deadlines =
+            state.load_deadlines(store).map_err(|e| e.wrap("failed to load deadlines"))?;
+
+        // We're only reading the sectors, so there's no need to save this back.
+        // However, we still want to avoid re-loading this array per-partition.
+        let sectors = Sectors::load(store, &state.sectors).map_err(|e| {
+            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors")
+        })?;
+
+        for (deadline_idx, partition_sectors) in to_process.iter() {
+            // If the deadline is the current or next deadline to prove, don't allow terminating sectors.
+            // We assume that deadlines are immutable when being proven.
+            if !deadline_is_mutable(
+                rt.policy(),
+                state.current_proving_period_start(rt.policy(), curr_epoch),
+                deadline_idx,
+                curr_epoch,
+            ) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cannot terminate sectors in immutable deadline {}",
+                    deadline_idx
+                ));
+            }
+
+            let quant = state.quant_spec_for_deadline(rt.policy(), deadline_idx);
+            let mut deadline = deadlines.load_deadline(store, deadline_idx)?;
+
+            let removed_power = deadline
+                .terminate_sectors(
+                    rt.policy(),
+                    store,
+                    &sectors,
+                    curr_epoch,
+                    partition_sectors,
+                    info.sector_size,
+                    quant,
+                )
+                .map_err(|e| {
+                    e.downcast_default(
+                        ExitCode::USR_ILLEGAL_STATE,
+                        format!("failed to terminate sectors in deadline {}", deadline_idx),
+                    )
+                })?;
+
+            state.early_terminations.set(deadline_idx);
+            power_delta -= &removed_power;
+
+            deadlines.update_deadline(rt.policy(), store, deadline_idx, &deadline).map_err(
+                |e| {
+                    e.downcast_default(
+                        ExitCode::USR_ILLEGAL_STATE,
+                        format!("failed to update deadline {}", deadline_idx),
+                    )
+                },
+            )?;
+        }
+
+        state.save_deadlines(store, deadlines).map_err(|e| {
+            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines")
+        })?;
+
+        Ok((had_early_terminations, power_delta))
+    })?;
+    let epoch_reward = request_current_epoch_block_reward(rt)?;
+    let pwr_total = request_current_total_power(rt)?;
+
+    // Now, try to process these sectors.
+    let more = process_early_terminations(
+        rt,
+        &epoch_reward.this_epoch_reward_smoothed,
+        &pwr_total.quality_adj_power_smoothed,
+    )?;
+
+    if more && !had_early_terminations {
+        // We have remaining terminations, and we didn't _previously_
+        // have early terminations to process, schedule a cron job.
+        // NOTE: This isn't quite correct. If we repeatedly fill, empty,
+        // fill, and empty, the queue, we'll keep scheduling new cron
+        // jobs. However, in practice, that shouldn't be all that bad.
+        schedule_early_termination_work(rt)?;
+    }
+    let state: State = rt.state()?;
+    state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+
+    request_update_power(rt, power_delta)?;
+    Ok(!more)
+}
+
+/// Computes the penalty for terminating a sector at the given epoch.
+fn termination_penalty_for_sector(
+    sector_size: SectorSize,
+    sector: &SectorOnChainInfo,
+    termination_epoch: ChainEpoch,
+    reward_smoothed: &FilterEstimate,
+    quality_adj_power_smoothed: &FilterEstimate,
+) -> TokenAmount {
+    let sector_power = qa_power_for_sector(sector_size, sector);
+    pledge_penalty_for_termination(
+        &sector.expected_day_reward,
+        termination_epoch - sector.power_base_epoch,
+        &sector.expected_storage_pledge,
+        quality_adj_power_smoothed,
+        &sector_power,
+        reward_smoothed,
+        &sector.replaced_day_reward,
+        sector.power_base_epoch - sector.activation,
+    )
+}
+
 // Note: We're using the current power+epoch reward, rather than at time of termination.
 fn process_early_terminations(
     rt: &impl Runtime,
@@ -4369,17 +4678,13 @@ fn process_early_terminations(
 
             for sector in &sectors {
                 total_initial_pledge += &sector.initial_pledge;
-                let sector_power = qa_power_for_sector(info.sector_size, sector);
                 terminated_sector_nums.push(sector.sector_number);
-                total_penalty += pledge_penalty_for_termination(
-                    &sector.expected_day_reward,
-                    epoch - sector.power_base_epoch,
-                    &sector.expected_storage_pledge,
-                    quality_adj_power_smoothed,
-                    &sector_power,
+                total_penalty += termination_penalty_for_sector(
+                    info.sector_size,
+                    sector,
+                    epoch,
                     reward_smoothed,
-                    &sector.replaced_day_reward,
-                    sector.power_base_epoch - sector.activation,
+                    quality_adj_power_smoothed,
                 );
                 if sector.deal_weight.is_positive() || sector.verified_deal_weight.is_positive() {
                     sectors_with_data.push(sector.sector_number);
@@ -4446,6 +4751,10 @@ fn process_early_terminations(
 }
 
 /// Invoked at the end of the last epoch for each proving deadline.
+/// Deadline-end processing is not split across callbacks: the power actor has already drained
+/// this epoch's cron queue, so an event enrolled for the current epoch would never fire, and
+/// deferring fault detection or expiration to a later epoch would change the fault timeline.
+/// Early terminations are the only deadline work that is batched, via their own cron event.
 fn handle_proving_deadline(
     rt: &impl Runtime,
     reward_smoothed: &FilterEstimate,
@@ -4508,9 +4817,29 @@ fn handle_proving_deadline(
         // That way, don't re-schedule a cron callback if one is already scheduled.
         had_early_terminations = have_pending_early_terminations(state);
 
-        let result = state.advance_deadline(policy, rt.store(), rt.curr_epoch()).map_err(|e| {
-            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to advance deadline")
-        })?;
+        // While the fault fee escrow covers the continued fault fee for this deadline, its faulty
+        // sectors are not terminated for exceeding the maximum fault age.
+        let dl_info = state.deadline_info(policy, curr_epoch);
+        let mut defer_fault_expirations = false;
+        if dl_info.period_started() {
+            let deadline_faulty_power = state
+                .load_deadlines(rt.store())?
+                .load_deadline(rt.store(), dl_info.index)?
+                .faulty_power;
+            defer_fault_expirations = !deadline_faulty_power.is_zero()
+                && state.fault_fee_escrow
+                    >= pledge_penalty_for_continued_fault(
+                        reward_smoothed,
+                        quality_adj_power_smoothed,
+                        &deadline_faulty_power.qa,
+                    );
+        }
+
+        let result = state
+            .advance_deadline(policy, rt.store(), rt.curr_epoch(), defer_fault_expirations)
+            .map_err(|e| {
+                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to advance deadline")
+            })?;
 
         // Faults detected by this missed PoSt pay no penalty, but sectors that were already faulty
         // and remain faulty through this deadline pay the fault fee.
@@ -4523,8 +4852,12 @@ fn handle_proving_deadline(
         power_delta_total += &result.power_delta;
         pledge_delta_total += &result.pledge_delta;
 
+        // Fees are paid from the fault fee escrow first, with any remainder becoming fee debt.
+        let from_escrow = state
+            .draw_fault_fee_escrow(&penalty_target)
+            .map_err(|e| actor_error!(illegal_state, "failed to draw fault fee escrow: {}", e))?;
         state
-            .apply_penalty(&penalty_target)
+            .apply_penalty(&(&penalty_target - &from_escrow))
             .map_err(|e| actor_error!(illegal_state, "failed to apply penalty: {}", e))?;
 
         log::debug!(
@@ -4543,7 +4876,7 @@ fn handle_proving_deadline(
                 e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to unlock penalty")
             })?;
 
-        penalty_total = &penalty_from_vesting + penalty_from_balance;
+        penalty_total = &penalty_from_vesting + penalty_from_balance + from_escrow;
         pledge_delta_total -= penalty_from_vesting;
 
         continue_cron = state.continue_deadline_cron();
@@ -5170,6 +5503,196 @@ fn verify_deals(
     ))?)
 }
 
+/// Disputes a single optimistically accepted Window PoSt, recording faults for the disputed
+/// partitions if the proof is invalid. The state is modified only if the dispute succeeds.
+/// Returns the penalty to charge the miner, the target reward for the disputer,
+/// and the power removed by the new faults.
+fn dispute_post(
+    rt: &impl Runtime,
+    st: &mut State,
+    info: &MinerInfo,
+    params: &DisputeWindowedPoStParams,
+    epoch_reward: &ThisEpochRewardReturn,
+    power_total: &ext::power::CurrentTotalPowerReturn,
+) -> Result<(TokenAmount, TokenAmount, PowerPair), ActorError> {
+    let policy = rt.policy();
+    let current_epoch = rt.curr_epoch();
+    let dl_info = st.deadline_info(policy, current_epoch);
+
+    if !deadline_available_for_optimistic_post_dispute(
+        policy,
+        dl_info.period_start,
+        params.deadline,
+        current_epoch,
+    ) {
+        return Err(actor_error!(
+            forbidden,
+            "can only dispute window posts during the dispute window \
+        ({} epochs after the challenge window closes)",
+            policy.wpost_dispute_window
+        ));
+    }
+
+    // --- check proof ---
+
+    // Find the proving period start for the deadline in question.
+    let mut pp_start = dl_info.period_start;
+    if dl_info.index < params.deadline {
+        pp_start -= policy.wpost_proving_period
+    }
+    let target_deadline = new_deadline_info(policy, pp_start, params.deadline, current_epoch);
+    // Load the target deadline
+    let mut deadlines_current =
+        st.load_deadlines(rt.store()).map_err(|e| e.wrap("failed to load deadlines"))?;
+
+    let mut dl_current = deadlines_current.load_deadline(rt.store(), params.deadline)?;
+
+    // Take the post from the snapshot for dispute.
+    // This operation REMOVES the PoSt from the snapshot so
+    // it can't be disputed again. If this method fails,
+    // this operation must be rolled back.
+    let (partitions, proofs) =
+        dl_current.take_post_proofs(rt.store(), params.post_index).map_err(|e| {
+            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load proof for dispute")
+        })?;
+
+    // Load the partition info we need for the dispute.
+    let mut dispute_info =
+        dl_current.load_partitions_for_dispute(rt.store(), partitions).map_err(|e| {
+            e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load partition for dispute")
+        })?;
+
+    // This includes power that is no longer active (e.g., due to sector terminations).
+    // It must only be used for penalty calculations, not power adjustments.
+    let penalised_power = dispute_info.disputed_power.clone();
+
+    // Load sectors for the dispute.
+    let sectors = Sectors::load(rt.store(), &dl_current.sectors_snapshot).map_err(|e| {
+        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors array")
+    })?;
+    let sector_infos = sectors
+        .load_for_proof(&dispute_info.all_sector_nos, &dispute_info.ignored_sector_nos)
+        .map_err(|e| {
+            e.downcast_default(
+                ExitCode::USR_ILLEGAL_STATE,
+                "failed to load sectors to dispute window post",
+            )
+        })?;
+
+    // Check proof, we fail if validation succeeds.
+    if verify_windowed_post(rt, target_deadline.challenge, &sector_infos, proofs)? {
+        return Err(actor_error!(illegal_argument, "failed to dispute valid post"));
+    } else {
+        info!("Successfully disputed post- window post was invalid");
+    }
+
+    // Ok, now we record faults. This always works because
+    // we don't allow compaction/moving sectors during the
+    // challenge window.
+    //
+    // However, some of these sectors may have been
+    // terminated. That's fine, we'll skip them.
+    let fault_expiration_epoch = target_deadline.last() + policy.fault_max_age;
+    let power_delta = dl_current
+        .record_faults(
+            rt.store(),
+            &sectors,
+            info.sector_size,
+            quant_spec_for_deadline(policy, &target_deadline),
+            fault_expiration_epoch,
+            &mut dispute_info.disputed_sectors,
+        )
+        .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to declare faults"))?;
+
+    deadlines_current.update_deadline(policy, rt.store(), params.deadline, &dl_current).map_err(
+        |e| {
+            e.downcast_default(
+                ExitCode::USR_ILLEGAL_STATE,
+                format!("failed to update deadline {}", params.deadline),
+            )
+        },
+    )?;
+
+    st.save_deadlines(rt.store(), deadlines_current)
+        .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines"))?;
+
+    // --- penalties ---
+
+    // Calculate the base penalty.
+    let penalty_base = pledge_penalty_for_invalid_windowpost(
+        &epoch_reward.this_epoch_reward_smoothed,
+        &power_total.quality_adj_power_smoothed,
+        &penalised_power.qa,
+    );
+
+    // Calculate the target reward.
+    let reward_target =
+        reward_for_disputed_window_post(info.window_post_proof_type, penalised_power);
+
+    // Compute the target penalty by adding the
+    // base penalty to the target reward. We don't
+    // take reward out of the penalty as the miner
+    // could end up receiving a substantial
+    // portion of their fee back as a reward.
+    let penalty_target = &penalty_base + &reward_target;
+    Ok((penalty_target, reward_target, power_delta))
+}
+
+/// Charges the penalty for disputed posts, paying it from vesting funds and balance.
+/// Returns the pledge delta, the amount to burn and the amount to reward the disputer,
+/// which is as much of the target reward as could be paid.
+fn apply_dispute_penalty(
+    rt: &impl Runtime,
+    st: &mut State,
+    current_epoch: ChainEpoch,
+    penalty_target: &TokenAmount,
+    reward_target: &TokenAmount,
+) -> Result<(TokenAmount, TokenAmount, TokenAmount), ActorError> {
+    st.apply_penalty(penalty_target)
+        .map_err(|e| actor_error!(illegal_state, "failed to apply penalty {}", e))?;
+    let (penalty_from_vesting, penalty_from_balance) = st
+        .repay_partial_debt_in_priority_order(rt.store(), current_epoch, &rt.current_balance())
+        .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to pay debt"))?;
+
+    let to_burn = &penalty_from_vesting + &penalty_from_balance;
+
+    // Now, move as much of the target reward as
+    // we can from the burn to the reward.
+    let to_reward = std::cmp::min(&to_burn, reward_target);
+    let to_burn = &to_burn - to_reward;
+    let pledge_delta = penalty_from_vesting.neg();
+
+    Ok((pledge_delta, to_burn, to_reward.clone()))
+}
+
+/// Sends the effects of disputed posts to other actors: updates power, rewards the disputer
+/// and burns the remaining penalty.
+fn settle_dispute(
+    rt: &impl Runtime,
+    reporter: Address,
+    power_delta: PowerPair,
+    pledge_delta: TokenAmount,
+    mut to_burn: TokenAmount,
+    to_reward: TokenAmount,
+) -> Result<(), ActorError> {
+    request_update_power(rt, power_delta)?;
+    if !to_reward.is_zero() {
+        if let Err(e) =
+            extract_send_result(rt.send_simple(&reporter, METHOD_SEND, None, to_reward.clone()))
+        {
+            error!("failed to send reward: {}", e);
+            to_burn += to_reward;
+        }
+    }
+
+    burn_funds(rt, to_burn)?;
+    notify_pledge_changed(rt, &pledge_delta)?;
+
+    let st: State = rt.state()?;
+    st.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+    Ok(())
+}
+
 /// Requests the current epoch target block reward from the reward actor.
 /// return value includes reward, smoothed estimate of reward, and baseline power
 fn request_current_epoch_block_reward(
@@ -5258,6 +5781,111 @@ fn notify_pledge_changed(rt: &impl Runtime, pledge_delta: &TokenAmount) -> Resul
     Ok(())
 }
 
+/// Verifies a consensus fault report against this miner, returning the fault.
+fn verify_consensus_fault_report(
+    rt: &impl Runtime,
+    params: &ReportConsensusFaultParams,
+) -> Result<ConsensusFault, ActorError> {
+    let fault = rt
+        .verify_consensus_fault(&params.header1, &params.header2, &params.header_extra)
+        .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_ARGUMENT, "fault not verified"))?
+        .ok_or_else(|| actor_error!(illegal_argument, "No consensus fault found"))?;
+    if fault.target != rt.message().receiver() {
+        return Err(actor_error!(
+            illegal_argument,
+            "fault by {} reported to miner {}",
+            fault.target,
+            rt.message().receiver()
+        ));
+    }
+
+    // Elapsed since the fault (i.e. since the higher of the two blocks)
+    let fault_age = rt.curr_epoch() - fault.epoch;
+    if fault_age <= 0 {
+        return Err(actor_error!(
+            illegal_argument,
+            "invalid fault epoch {} ahead of current {}",
+            fault.epoch,
+            rt.curr_epoch()
+        ));
+    }
+    Ok(fault)
+}
+
+/// Charges the consensus fault penalty for a fault at the given epoch, rewards the reporter,
+/// and makes the miner ineligible for the consensus fault ineligibility duration.
+fn penalize_consensus_fault(
+    rt: &impl Runtime,
+    reporter: &Address,
+    fault_epoch: ChainEpoch,
+) -> Result<(), ActorError> {
+    // Reward reporter with a share of the miner's current balance.
+    let reward_stats = request_current_epoch_block_reward(rt)?;
+
+    // The policy amounts we should burn and send to reporter
+    // These may differ from actual funds send when miner goes into fee debt
+    let this_epoch_reward =
+        TokenAmount::from_atto(reward_stats.this_epoch_reward_smoothed.estimate());
+    let fault_penalty = consensus_fault_penalty(this_epoch_reward.clone());
+    let slasher_reward = reward_for_consensus_slash_report(&this_epoch_reward);
+
+    let mut pledge_delta = TokenAmount::zero();
+
+    let (burn_amount, reward_amount) = rt.transaction(|st: &mut State, rt| {
+        let mut info = get_miner_info(rt.store(), st)?;
+
+        // Verify miner hasn't already been faulted
+        if fault_epoch < info.consensus_fault_elapsed {
+            return Err(actor_error!(
+                forbidden,
+                "fault epoch {} is too old, last exclusion period ended at {}",
+                fault_epoch,
+                info.consensus_fault_elapsed
+            ));
+        }
+
+        st.apply_penalty(&fault_penalty)
+            .map_err(|e| actor_error!(illegal_state, format!("failed to apply penalty: {}", e)))?;
+
+        // Pay penalty
+        let (penalty_from_vesting, penalty_from_balance) = st
+            .repay_partial_debt_in_priority_order(
+                rt.store(),
+                rt.curr_epoch(),
+                &rt.current_balance(),
+            )
+            .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to pay fees"))?;
+
+        let mut burn_amount = &penalty_from_vesting + &penalty_from_balance;
+        pledge_delta -= penalty_from_vesting;
+
+        // clamp reward at funds burnt
+        let reward_amount = std::cmp::min(&burn_amount, &slasher_reward).clone();
+        burn_amount -= &reward_amount;
+
+        info.consensus_fault_elapsed =
+            rt.curr_epoch() + rt.policy().consensus_fault_ineligibility_duration;
+
+        st.save_info(rt.store(), &info).map_err(|e| {
+            e.downcast_default(ExitCode::USR_SERIALIZATION, "failed to save miner info")
+        })?;
+
+        Ok((burn_amount, reward_amount))
+    })?;
+
+    if let Err(e) = extract_send_result(rt.send_simple(reporter, METHOD_SEND, None, reward_amount))
+    {
+        error!("failed to send reward: {}", e);
+    }
+
+    burn_funds(rt, burn_amount)?;
+    notify_pledge_changed(rt, &pledge_delta)?;
+
+    let state: State = rt.state()?;
+    state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+    Ok(())
+}
+
 fn get_claims(
     rt: &impl Runtime,
     ids: &[ext::verifreg::ClaimID],
@@ -5357,6 +5985,175 @@ fn validate_fr_declaration_deadline(deadline: &DeadlineInfo) -> anyhow::Result<(
     }
 }
 
+/// Collects recovery declarations by deadline and partition, checking them against the
+/// per-message declaration limits.
+fn recovery_declarations_to_map(
+    policy: &Policy,
+    recoveries: &[RecoveryDeclaration],
+) -> Result<DeadlineSectorMap, ActorError> {
+    if recoveries.len() as u64 > policy.declarations_max {
+        return Err(actor_error!(
+            illegal_argument,
+            "too many recovery declarations for a single message: {} > {}",
+            recoveries.len(),
+            policy.declarations_max
+        ));
+    }
+
+    let mut to_process = DeadlineSectorMap::new();
+    for term in recoveries {
+        let deadline = term.deadline;
+        let partition = term.partition;
+
+        to_process.add(policy, deadline, partition, term.sectors.clone()).map_err(|e| {
+            actor_error!(
+                illegal_argument,
+                "failed to process deadline {}, partition {}: {}",
+                deadline,
+                partition,
+                e
+            )
+        })?;
+    }
+
+    to_process.check(policy.addressed_partitions_max, policy.addressed_sectors_max).map_err(
+        |e| actor_error!(illegal_argument, "cannot process requested parameters: {}", e),
+    )?;
+    Ok(to_process)
+}
+
+/// Marks faulty sectors as recovering in their deadlines.
+/// Power is not restored until the recovered sectors are successfully PoSted.
+fn record_declared_recoveries(
+    rt: &impl Runtime,
+    state: &mut State,
+    info: &MinerInfo,
+    to_process: &mut DeadlineSectorMap,
+) -> Result<(), ActorError> {
+    if consensus_fault_active(info, rt.curr_epoch()) {
+        return Err(actor_error!(forbidden, "recovery not allowed during active consensus fault"));
+    }
+
+    let store = rt.store();
+
+    let mut deadlines =
+        state.load_deadlines(store).map_err(|e| e.wrap("failed to load deadlines"))?;
+
+    let sectors = Sectors::load(store, &state.sectors).map_err(|e| {
+        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load sectors array")
+    })?;
+    let curr_epoch = rt.curr_epoch();
+    for (deadline_idx, partition_map) in to_process.iter() {
+        let policy = rt.policy();
+        let target_deadline = declaration_deadline_info(
+            policy,
+            state.current_proving_period_start(policy, curr_epoch),
+            deadline_idx,
+            curr_epoch,
+        )
+        .map_err(|e| {
+            actor_error!(
+                illegal_argument,
+                "invalid recovery declaration deadline {}: {}",
+                deadline_idx,
+                e
+            )
+        })?;
+
+        validate_fr_declaration_deadline(&target_deadline).map_err(|e| {
+            actor_error!(
+                illegal_argument,
+                "failed recovery declaration at deadline {}: {}",
+                deadline_idx,
+                e
+            )
+        })?;
+
+        let mut deadline = deadlines.load_deadline(store, deadline_idx)?;
+
+        deadline
+            .declare_faults_recovered(store, &sectors, info.sector_size, partition_map)
+            .map_err(|e| {
+                e.downcast_default(
+                    ExitCode::USR_ILLEGAL_STATE,
+                    format!("failed to declare recoveries for deadline {}", deadline_idx),
+                )
+            })?;
+
+        deadlines.update_deadline(policy, store, deadline_idx, &deadline).map_err(|e| {
+            e.downcast_default(
+                ExitCode::USR_ILLEGAL_STATE,
+                format!("failed to store deadline {}", deadline_idx),
+            )
+        })?;
+    }
+
+    state
+        .save_deadlines(store, deadlines)
+        .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save deadlines"))
+}
+
+/// Marks faulty sectors as recovering in the deadline being proven by a Window PoSt submission.
+/// The recovered sectors must be faulty in partitions being proven, and must not be skipped.
+fn record_post_recoveries<BS: Blockstore>(
+    policy: &Policy,
+    store: &BS,
+    deadline: &mut Deadline,
+    sectors: &Sectors<'_, BS>,
+    sector_size: SectorSize,
+    params: &SubmitWindowedPoStParams,
+    recoveries: &[RecoveryDeclaration],
+) -> Result<(), ActorError> {
+    let mut to_process = recovery_declarations_to_map(policy, recoveries)?;
+    for (deadline_idx, partition_map) in to_process.iter() {
+        if deadline_idx != params.deadline {
+            return Err(actor_error!(
+                illegal_argument,
+                "recovery deadline {} does not match proven deadline {}",
+                deadline_idx,
+                params.deadline
+            ));
+        }
+
+        for (partition_idx, recovered) in partition_map.iter() {
+            let post_partition =
+                params.partitions.iter().find(|p| p.index == partition_idx).ok_or_else(|| {
+                    actor_error!(
+                        illegal_argument,
+                        "recovered partition {} is not being proven",
+                        partition_idx
+                    )
+                })?;
+            if recovered.contains_any(&post_partition.skipped) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cannot skip recovered sectors in partition {}",
+                    partition_idx
+                ));
+            }
+
+            let partition = deadline.load_partition(store, partition_idx)?;
+            if !partition.faults.contains_all(recovered) {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "recovered sectors in partition {} are not all faulty",
+                    partition_idx
+                ));
+            }
+        }
+
+        deadline.declare_faults_recovered(store, sectors, sector_size, partition_map).map_err(
+            |e| {
+                e.downcast_default(
+                    ExitCode::USR_ILLEGAL_STATE,
+                    format!("failed to declare recoveries for deadline {}", deadline_idx),
+                )
+            },
+        )?;
+    }
+    Ok(())
+}
+
 /// Validates that a partition contains the given sectors.
 fn validate_partition_contains_sectors(
     partition: &Partition,
@@ -5422,6 +6219,129 @@ fn process_pending_worker(
         .map_err(|e| e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save miner info"))
 }
 
+/// Withdraws up to the requested amount of available balance, sending it to the beneficiary,
+/// or to the given address if specified.
+fn withdraw_available_balance(
+    rt: &impl Runtime,
+    amount_requested: TokenAmount,
+    to: Option<Address>,
+) -> Result<WithdrawBalanceReturn, ActorError> {
+    if amount_requested.is_negative() {
+        return Err(actor_error!(
+            illegal_argument,
+            "negative fund requested for withdrawal: {}",
+            amount_requested
+        ));
+    }
+
+    let (info, amount_withdrawn, newly_vested, fee_to_burn, state) =
+        rt.transaction(|state: &mut State, rt| {
+            let mut info = get_miner_info(rt.store(), state)?;
+
+            // Only the owner or the beneficiary is allowed to withdraw the balance.
+            rt.validate_immediate_caller_is(&[info.owner, info.beneficiary])?;
+
+            // Ensure we don't have any pending terminations.
+            if !state.early_terminations.is_empty() {
+                return Err(actor_error!(
+                    forbidden,
+                    "cannot withdraw funds while {} deadlines have terminated sectors \
+                    with outstanding fees",
+                    state.early_terminations.len()
+                ));
+            }
+
+            // Unlock vested funds so we can spend them.
+            let newly_vested =
+                state.unlock_vested_funds(rt.store(), rt.curr_epoch()).map_err(|e| {
+                    e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "Failed to vest fund")
+                })?;
+
+            // available balance already accounts for fee debt so it is correct to call
+            // this before RepayDebts. We would have to
+            // subtract fee debt explicitly if we called this after.
+            let available_balance =
+                state.get_available_balance(&rt.current_balance()).map_err(|e| {
+                    actor_error!(
+                        illegal_state,
+                        format!("failed to calculate available balance: {}", e)
+                    )
+                })?;
+
+            // Verify unlocked funds cover both InitialPledgeRequirement and FeeDebt
+            // and repay fee debt now.
+            let fee_to_burn = repay_debts_or_abort(rt, state)?;
+            let mut amount_withdrawn =
+                std::cmp::min(&available_balance, &amount_requested);
+            if amount_withdrawn.is_negative() {
+                return Err(actor_error!(
+                    illegal_state,
+                    "negative amount to withdraw: {}",
+                    amount_withdrawn
+                ));
+            }
+            // An expired beneficiary term reverts to the owner when the owner withdraws.
+            if info.beneficiary != info.owner
+                && rt.message().caller() == info.owner
+                && info.beneficiary_term.expiration <= rt.curr_epoch()
+            {
+                info.beneficiary = info.owner;
+                info.beneficiary_term = BeneficiaryTerm::default();
+                state.save_info(rt.store(), &info).map_err(|e| {
+                    e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save miner info")
+                })?;
+            }
+            if to.is_some() && rt.message().caller() != info.beneficiary {
+                return Err(actor_error!(
+                    forbidden,
+                    "only the beneficiary {} may choose the withdrawal destination",
+                    info.beneficiary
+                ));
+            }
+            if info.beneficiary != info.owner {
+                // remaining_quota always zero and positive
+                let remaining_quota = info.beneficiary_term.available(rt.curr_epoch());
+                if remaining_quota.is_zero() {
+                    return Err(actor_error!(
+                        forbidden,
+                        "beneficiary expiration of epoch {} passed or quota of {} depleted with {} used",
+                        info.beneficiary_term.expiration,
+                        info.beneficiary_term.quota,
+                        info.beneficiary_term.used_quota
+                    ));
+                }
+                amount_withdrawn = std::cmp::min(amount_withdrawn, &remaining_quota);
+                if amount_withdrawn.is_positive() {
+                    info.beneficiary_term.used_quota += amount_withdrawn;
+                    state.save_info(rt.store(), &info).map_err(|e| {
+                        e.downcast_default(
+                            ExitCode::USR_ILLEGAL_STATE,
+                            "failed to save miner info",
+                        )
+                    })?;
+                }
+                Ok((info, amount_withdrawn.clone(), newly_vested, fee_to_burn, state.clone()))
+            } else {
+                Ok((info, amount_withdrawn.clone(), newly_vested, fee_to_burn, state.clone()))
+            }
+        })?;
+
+    if amount_withdrawn.is_positive() {
+        extract_send_result(rt.send_simple(
+            &to.unwrap_or(info.beneficiary),
+            METHOD_SEND,
+            None,
+            amount_withdrawn.clone(),
+        ))?;
+    }
+
+    burn_funds(rt, fee_to_burn)?;
+    notify_pledge_changed(rt, &newly_vested.neg())?;
+
+    state.check_balance_invariants(&rt.current_balance()).map_err(balance_invariants_broken)?;
+    Ok(WithdrawBalanceReturn { amount_withdrawn })
+}
+
 /// Repays all fee debt and then verifies that the miner has amount needed to cover
 /// the pledge requirement after burning all fee debt.  If not aborts.
 /// Returns an amount that must be burnt by the actor.
@@ -6008,6 +6928,13 @@ impl ActorCode for Actor {
         ApplyRewards => apply_rewards,
         ReportConsensusFault => report_consensus_fault,
         WithdrawBalance|WithdrawBalanceExported => withdraw_balance,
+        WithdrawBalanceTo|WithdrawBalanceToExported => withdraw_balance_to,
+        PrepayFaultFees|PrepayFaultFeesExported => prepay_fault_fees,
+        WithdrawFaultFeeEscrow|WithdrawFaultFeeEscrowExported => withdraw_fault_fee_escrow,
+        ExtendSectorExpiration2Batched => extend_sector_expiration2_batched,
+        ReportConsensusFaultBatch => report_consensus_fault_batch,
+        SubmitWindowedPoStWithRecoveries => submit_windowed_post_with_recoveries,
+        SectorExpirationsExported => sector_expirations,
         InternalSectorSetupForPreseal => internal_sector_setup_preseal,
         ChangeMultiaddrs|ChangeMultiaddrsExported => change_multiaddresses,
         CompactPartitions => compact_partitions,
@@ -6016,6 +6943,7 @@ impl ActorCode for Actor {
         RepayDebt|RepayDebtExported => repay_debt,
         ChangeOwnerAddress|ChangeOwnerAddressExported => change_owner_address,
         DisputeWindowedPoSt => dispute_windowed_post,
+        DisputeWindowedPoStBatch => dispute_windowed_post_batch,
         ProveCommitAggregate => prove_commit_aggregate,
         ProveReplicaUpdates => prove_replica_updates,
         PreCommitSectorBatch2 => pre_commit_sector_batch2,
@@ -6029,9 +6957,15 @@ impl ActorCode for Actor {
         GetVestingFundsExported => get_vesting_funds,
         GetPeerIDExported => get_peer_id,
         GetMultiaddrsExported => get_multiaddresses,
+        ProvingDeadlineInfoExported => proving_deadline_info,
+        AvailableSectorNumbersExported => available_sector_numbers,
+        TerminateSectorsDryRunExported => terminate_sectors_dry_run,
         ProveCommitSectors3 => prove_commit_sectors3,
         ProveReplicaUpdates3 => prove_replica_updates3,
+        ProveReplicaUpdatesWithRecoveries => prove_replica_updates_with_recoveries,
         ProveCommitSectorsNI => prove_commit_sectors_ni,
+        BatchTerminateSectors => batch_terminate_sectors,
+        RepayDebtFromVesting|RepayDebtFromVestingExported => repay_debt_from_vesting,
     }
 }
 
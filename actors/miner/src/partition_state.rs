@@ -592,6 +592,36 @@ impl Partition {
         Ok(popped)
     }
 
+    /// Defers the early expiration of faulty sectors due to expire at or before an epoch
+    /// to a new epoch, unless they expire on-time before then.
+    /// Returns whether any sector's expiration was deferred.
+    pub fn defer_fault_expirations<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        sectors: &Sectors<'_, BS>,
+        until: ChainEpoch,
+        new_expiration: ChainEpoch,
+        sector_size: SectorSize,
+        quant: QuantSpec,
+    ) -> anyhow::Result<bool> {
+        if self.faults.is_empty() {
+            return Ok(false);
+        }
+
+        let sector_infos = sectors.load_sector(&self.faults)?;
+        let mut expirations = ExpirationQueue::new(store, &self.expirations_epochs, quant)
+            .map_err(|e| e.downcast_wrap("failed to load sector expirations"))?;
+        let deferred = expirations
+            .reschedule_early_expirations(until, new_expiration, &sector_infos, sector_size)
+            .map_err(|e| e.downcast_wrap("failed to defer fault expirations"))?;
+        self.expirations_epochs = expirations.amt.flush()?;
+
+        // check invariants
+        self.validate_state()?;
+
+        Ok(!deferred.is_empty())
+    }
+
     /// Marks all non-faulty sectors in the partition as faulty and clears recoveries, updating power memos appropriately.
     /// All sectors' expirations are rescheduled to the fault expiration, as "early" (if not expiring earlier)
     /// Returns the power of the newly faulty and failed recovery sectors.
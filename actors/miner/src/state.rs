@@ -9,6 +9,7 @@ use anyhow::{anyhow, Error};
 use cid::multihash::Code;
 use cid::Cid;
 use fvm_ipld_amt::Error as AmtError;
+use fvm_ipld_bitfield::iter::Ranges;
 use fvm_ipld_bitfield::BitField;
 use fvm_ipld_blockstore::Blockstore;
 use fvm_ipld_encoding::tuple::*;
@@ -112,6 +113,10 @@ pub struct State {
 
     // True when miner cron is active, false otherwise
     pub deadline_cron_active: bool,
+
+    /// Funds deposited in advance to pay continued fault fees.
+    /// Drawn down by deadline cron before any new fee debt is incurred.
+    pub fault_fee_escrow: TokenAmount,
 }
 
 #[derive(PartialEq, Eq)]
@@ -189,6 +194,7 @@ impl State {
             early_terminations: BitField::new(),
             deadline_cron_active: false,
             pre_committed_sectors_cleanup: empty_precommits_cleanup_array,
+            fault_fee_escrow: TokenAmount::default(),
         })
     }
 
@@ -283,6 +289,45 @@ impl State {
         Ok(())
     }
 
+    /// Returns the lowest `count` sector numbers that are neither allocated nor masked.
+    /// Never includes numbers above MAX_SECTOR_NUMBER, so fewer than `count` numbers are returned
+    /// if the sector number space is exhausted.
+    pub fn available_sector_numbers<BS: Blockstore>(
+        &self,
+        store: &BS,
+        count: u64,
+    ) -> Result<BitField, ActorError> {
+        let allocated: BitField = store
+            .get_cbor(&self.allocated_sectors)
+            .map_err(|e| {
+                e.downcast_default(
+                    ExitCode::USR_ILLEGAL_STATE,
+                    "failed to load allocated sectors bitfield",
+                )
+            })?
+            .ok_or_else(|| actor_error!(illegal_state, "allocated sectors bitfield not found"))?;
+
+        // The gaps between allocated ranges are available, as is everything after the last
+        // allocated range up to the maximum sector number.
+        let limit = MAX_SECTOR_NUMBER + 1;
+        let mut available = Vec::new();
+        let mut remaining = count;
+        let mut next = 0;
+        for allocated_range in allocated.ranges().chain(std::iter::once(limit..limit)) {
+            if remaining == 0 || next >= limit {
+                break;
+            }
+            let gap_end = cmp::min(allocated_range.start, limit);
+            if gap_end > next {
+                let end = cmp::min(gap_end, next.saturating_add(remaining));
+                available.push(next..end);
+                remaining -= end - next;
+            }
+            next = cmp::max(next, allocated_range.end);
+        }
+        Ok(BitField::from_ranges(Ranges::new(available)))
+    }
+
     /// Stores a pre-committed sector info, failing if the sector number is already present.
     pub fn put_precommitted_sectors<BS: Blockstore>(
         &mut self,
@@ -805,6 +850,28 @@ impl State {
         Ok(())
     }
 
+    /// Returns true when the miner has no live sectors in any deadline and no pre-committed
+    /// sectors awaiting proof.
+    pub fn has_no_sectors<BS: Blockstore>(&self, store: &BS) -> Result<bool, ActorError> {
+        let precommitted =
+            PreCommitMap::load(store, &self.pre_committed_sectors, PRECOMMIT_CONFIG, "precommits")?;
+        if !precommitted.is_empty() {
+            return Ok(false);
+        }
+
+        let deadlines = self.load_deadlines(store)?;
+        let mut live_sectors = 0;
+        deadlines
+            .for_each(store, |_, deadline| {
+                live_sectors += deadline.live_sectors;
+                Ok(())
+            })
+            .map_err(|e| {
+                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load deadlines")
+            })?;
+        Ok(live_sectors == 0)
+    }
+
     // Return true when the miner actor needs to continue scheduling deadline crons
     pub fn continue_deadline_cron(&self) -> bool {
         !self.pre_commit_deposits.is_zero()
@@ -853,6 +920,17 @@ impl State {
         }
     }
 
+    /// Draws up to the given fault fee from the fault fee escrow.
+    /// Returns the amount drawn, which must be burnt.
+    pub fn draw_fault_fee_escrow(&mut self, fee: &TokenAmount) -> anyhow::Result<TokenAmount> {
+        if fee.is_negative() {
+            return Err(anyhow!("drawing negative fault fee {} not allowed", fee));
+        }
+        let drawn = cmp::min(fee, &self.fault_fee_escrow).clone();
+        self.fault_fee_escrow -= &drawn;
+        Ok(drawn)
+    }
+
     /// First vests and unlocks the vested funds AND then locks the given funds in the vesting table.
     pub fn add_locked_funds<BS: Blockstore>(
         &mut self,
@@ -919,6 +997,24 @@ impl State {
         Ok((from_vesting, from_balance))
     }
 
+    /// Repays up to `max_amount` of fee debt by unlocking funds that have not yet vested.
+    /// Already-vested funds and the unlocked balance are not used.
+    /// Returns the amount unlocked from vesting funds, which must be burnt.
+    pub fn repay_debt_from_vesting<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        current_epoch: ChainEpoch,
+        max_amount: &TokenAmount,
+    ) -> anyhow::Result<TokenAmount> {
+        let target = cmp::min(max_amount, &self.fee_debt).clone();
+        let from_vesting = self.unlock_unvested_funds(store, current_epoch, &target)?;
+        if from_vesting > self.fee_debt {
+            return Err(anyhow!("should never unlock more than the debt we need to repay"));
+        }
+        self.fee_debt -= &from_vesting;
+        Ok(from_vesting)
+    }
+
     /// Repays the full miner actor fee debt.  Returns the amount that must be
     /// burnt and an error if there are not sufficient funds to cover repayment.
     /// Miner state repays from unlocked funds and fails if unlocked funds are insufficient to cover fee debt.
@@ -1004,10 +1100,14 @@ impl State {
             .fold(TokenAmount::zero(), |acc, fund| acc + &fund.amount))
     }
 
-    /// Unclaimed funds that are not locked -- includes funds used to cover initial pledge requirement.
+    /// Unclaimed funds that are not locked or held in the fault fee escrow -- includes funds used to
+    /// cover initial pledge requirement.
     pub fn get_unlocked_balance(&self, actor_balance: &TokenAmount) -> anyhow::Result<TokenAmount> {
-        let unlocked_balance =
-            actor_balance - &self.locked_funds - &self.pre_commit_deposits - &self.initial_pledge;
+        let unlocked_balance = actor_balance
+            - &self.locked_funds
+            - &self.pre_commit_deposits
+            - &self.initial_pledge
+            - &self.fault_fee_escrow;
         if unlocked_balance.is_negative() {
             return Err(anyhow!("negative unlocked balance {}", unlocked_balance));
         }
@@ -1037,8 +1137,14 @@ impl State {
         if self.fee_debt.is_negative() {
             return Err(anyhow!("fee debt is negative: {}", self.fee_debt));
         }
+        if self.fault_fee_escrow.is_negative() {
+            return Err(anyhow!("fault fee escrow is negative: {}", self.fault_fee_escrow));
+        }
 
-        let min_balance = &self.pre_commit_deposits + &self.locked_funds + &self.initial_pledge;
+        let min_balance = &self.pre_commit_deposits
+            + &self.locked_funds
+            + &self.initial_pledge
+            + &self.fault_fee_escrow;
         if balance < &min_balance {
             return Err(anyhow!("fee debt is negative: {}", self.fee_debt));
         }
@@ -1130,6 +1236,7 @@ impl State {
         policy: &Policy,
         store: &BS,
         current_epoch: ChainEpoch,
+        defer_fault_expirations: bool,
     ) -> anyhow::Result<AdvanceDeadlineResult> {
         let mut pledge_delta = TokenAmount::zero();
 
@@ -1178,6 +1285,21 @@ impl State {
         // dropped along with faulty sectors expiring this round.
         let total_faulty_power = deadline.faulty_power.clone();
 
+        // While faults are paid for in advance, faulty sectors are not terminated for being
+        // faulty for too long. Their fault expiration is deferred by a proving period instead.
+        if defer_fault_expirations {
+            let sectors = Sectors::load(store, &self.sectors)?;
+            let sector_size = self.get_info(store)?.sector_size;
+            deadline.defer_fault_expirations(
+                store,
+                &sectors,
+                dl_info.last(),
+                dl_info.last() + policy.wpost_proving_period,
+                sector_size,
+                quant,
+            )?;
+        }
+
         // Expire sectors that are due, either for on-time expiration or "early" faulty-for-too-long.
         let expired = deadline.pop_expired_sectors(store, dl_info.last(), quant)?;
 
@@ -25,6 +25,7 @@ use fil_actors_runtime::{BatchReturn, DealWeight};
 use crate::commd::CompactCommD;
 use crate::ext::verifreg::AllocationID;
 use crate::ext::verifreg::ClaimID;
+use crate::PowerPair;
 
 use super::beneficiary::*;
 
@@ -129,6 +130,15 @@ pub struct SubmitWindowedPoStParams {
     pub chain_commit_rand: Randomness,
 }
 
+/// Information submitted by a miner to provide a Window PoSt together with recoveries.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct SubmitWindowedPoStWithRecoveriesParams {
+    /// The Window PoSt, which must cover the recovered sectors.
+    pub post: SubmitWindowedPoStParams,
+    /// Faulty sectors to recover, in partitions of the deadline being proven.
+    pub recoveries: Vec<RecoveryDeclaration>,
+}
+
 // Deprecated as of FIP 0084 -- kept for legacy testing
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct ProveCommitSectorParams {
@@ -251,6 +261,23 @@ pub struct ExtendSectorExpiration2Params {
     pub extensions: Vec<ExpirationExtension2>,
 }
 
+#[derive(Clone, Debug, Serialize_tuple, Deserialize_tuple)]
+pub struct ExtendSectorExpiration2BatchedParams {
+    pub extensions: Vec<ExpirationExtension2>,
+    // Index of the first declaration in extensions to process.
+    pub cursor: u64,
+    // Maximum cost of declarations processed, counting one per partition and
+    // one per claim-bearing sector.
+    pub max_partitions: u64,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ExtendSectorExpiration2BatchedReturn {
+    // Set if declarations remain to be processed from next_cursor.
+    pub partial: bool,
+    pub next_cursor: u64,
+}
+
 #[derive(Clone, Debug, Serialize_tuple, Deserialize_tuple)]
 pub struct SectorClaim {
     pub sector_number: SectorNumber,
@@ -303,6 +330,45 @@ pub struct TerminateSectorsReturn {
     pub done: bool,
 }
 
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct DeadlineTerminationPenalty {
+    pub deadline: u64,
+    // Funds that would be burnt to pay the fees, from vesting funds and then unlocked balance.
+    // Any fees not covered become fee debt.
+    pub pledge_to_burn: TokenAmount,
+    // Outstanding fee debt, such as unpaid fault fees, that would be repaid along with the
+    // termination fee.
+    pub fault_fee: TokenAmount,
+    // Early termination fee for the terminated sectors.
+    pub termination_fee: TokenAmount,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct TerminateSectorsDryRunReturn {
+    // Penalties for each deadline with declared terminations, in deadline order.
+    pub deadlines: Vec<DeadlineTerminationPenalty>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct BatchTerminateSectorsParams {
+    pub terminations: Vec<TerminationDeclaration>,
+    // The maximum number of sectors to terminate in this invocation.
+    // Defaults to the policy's addressed sectors max when not specified.
+    pub max_sectors: Option<u64>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct BatchTerminateSectorsReturn {
+    // Set to true if all early termination work has been completed,
+    // with the same meaning as in TerminateSectorsReturn.
+    pub done: bool,
+    // Set to true if the max sectors limit was reached before all declared
+    // sectors could be terminated. The miner may re-submit the declarations
+    // for the remaining sectors in a subsequent invocation.
+    pub partial: bool,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct DeclareFaultsParams {
     pub faults: Vec<FaultDeclaration>,
@@ -323,7 +389,7 @@ pub struct DeclareFaultsRecoveredParams {
     pub recoveries: Vec<RecoveryDeclaration>,
 }
 
-#[derive(Serialize_tuple, Deserialize_tuple)]
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct RecoveryDeclaration {
     /// The deadline to which the recovered sectors are assigned, in range [0..WPoStPeriodDeadlines)
     pub deadline: u64,
@@ -354,17 +420,44 @@ pub struct ReportConsensusFaultParams {
     pub header_extra: Vec<u8>,
 }
 
+#[derive(Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ReportConsensusFaultBatchParams {
+    pub reports: Vec<ReportConsensusFaultParams>,
+}
+
 #[derive(Clone, Serialize_tuple, Deserialize_tuple)]
 pub struct WithdrawBalanceParams {
     pub amount_requested: TokenAmount,
 }
 
+#[derive(Clone, Serialize_tuple, Deserialize_tuple)]
+pub struct WithdrawBalanceToParams {
+    /// Address to receive the withdrawn funds. Must resolve to an existing actor.
+    pub to: Address,
+    pub amount_requested: TokenAmount,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple)]
 #[serde(transparent)]
 pub struct WithdrawBalanceReturn {
     pub amount_withdrawn: TokenAmount,
 }
 
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct RepayDebtFromVestingParams {
+    /// The maximum amount of unvested funds to burn against fee debt.
+    pub amount: TokenAmount,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct RepayDebtFromVestingReturn {
+    /// The fee debt remaining after repayment.
+    pub remaining_debt: TokenAmount,
+}
+
 #[derive(Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct WorkerKeyChange {
     /// Must be an ID address
@@ -485,6 +578,19 @@ pub struct DisputeWindowedPoStParams {
     pub post_index: u64, // only one is allowed at a time to avoid loading too many sector infos.
 }
 
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct DisputeWindowedPoStBatchParams {
+    pub disputes: Vec<DisputeWindowedPoStParams>,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct DisputeWindowedPoStBatchReturn {
+    /// Result of each dispute, in the order given.
+    pub results: BatchReturn,
+}
+
 #[derive(Debug, Clone, Serialize_tuple, Deserialize_tuple)]
 pub struct ProveCommitAggregateParams {
     pub sector_numbers: BitField,
@@ -526,6 +632,14 @@ pub struct ProveReplicaUpdates3Params {
     pub require_notification_success: bool,
 }
 
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ProveReplicaUpdatesWithRecoveriesParams {
+    pub updates: ProveReplicaUpdates3Params,
+    // Faulty sectors to declare recovered, in partitions also addressed by a proven update.
+    // May not include any sector being updated.
+    pub recoveries: Vec<RecoveryDeclaration>,
+}
+
 #[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct SectorUpdateManifest {
     pub sector: SectorNumber,
@@ -620,6 +734,65 @@ pub struct GetMultiaddrsReturn {
     pub multi_addrs: Vec<BytesDe>,
 }
 
+/// Deadline calculations for the miner's current proving deadline.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ProvingDeadlineInfoReturn {
+    /// Epoch at which this info was calculated.
+    pub current_epoch: ChainEpoch,
+    /// First epoch of the proving period (<= current_epoch).
+    pub period_start: ChainEpoch,
+    /// Current deadline index, in [0..WPoStPeriodDeadlines).
+    pub index: u64,
+    /// First epoch from which a proof may be submitted (>= current_epoch).
+    pub open: ChainEpoch,
+    /// First epoch from which a proof may no longer be submitted (>= open).
+    pub close: ChainEpoch,
+    /// Epoch at which to sample the chain for challenge (< open).
+    pub challenge: ChainEpoch,
+    /// First epoch at which a fault declaration is rejected (< open).
+    pub fault_cutoff: ChainEpoch,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct AvailableSectorNumbersParams {
+    /// Maximum number of available sector numbers to return.
+    pub count: u64,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct AvailableSectorNumbersReturn {
+    pub sector_numbers: BitField,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct SectorExpirationsParams {
+    /// Numbers of the sectors to query.
+    pub sectors: BitField,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct SectorExpirationInfo {
+    /// Epoch at which the sector is scheduled to expire.
+    pub expiration: ChainEpoch,
+    #[serde(with = "bigint_ser")]
+    pub deal_weight: DealWeight,
+    #[serde(with = "bigint_ser")]
+    pub verified_deal_weight: DealWeight,
+    /// Raw byte and quality-adjusted power of the sector.
+    pub power: PowerPair,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct SectorExpirationsReturn {
+    /// Result for each queried sector, in ascending sector number order.
+    pub results: BatchReturn,
+    /// Info for each sector that was found, in ascending sector number order.
+    pub sectors: Vec<SectorExpirationInfo>,
+}
+
 // Notification of change committed to one or more sectors.
 // The relevant state must be already committed so the receiver can observe any impacts
 // at the sending miner actor.
@@ -29,9 +29,18 @@ fn setup() -> (ActorHarness, MockRuntime) {
     (h, rt)
 }
 
+// Sets up a miner with a pre-committed sector, which is subject to the worker key change delay.
+fn setup_with_sector() -> (ActorHarness, MockRuntime) {
+    let (h, rt) = setup();
+    rt.set_epoch(1);
+    h.pre_commit_single_sector(&rt, 100, true);
+
+    (h, rt)
+}
+
 #[test]
 fn successfully_change_only_the_worker_address() {
-    let (h, rt) = setup();
+    let (h, rt) = setup_with_sector();
 
     let original_control_addresses = &h.control_addrs;
     let new_worker = Address::new_id(999);
@@ -78,7 +87,7 @@ fn successfully_change_only_the_worker_address() {
 
 #[test]
 fn change_and_confirm_worker_address_restricted_correctly() {
-    let (h, rt) = setup();
+    let (h, rt) = setup_with_sector();
 
     let original_control_addresses = h.control_addrs.clone();
     let new_worker = Address::new_id(999);
@@ -150,7 +159,7 @@ fn change_and_confirm_worker_address_restricted_correctly() {
 
 #[test]
 fn change_cannot_be_overridden() {
-    let (h, rt) = setup();
+    let (h, rt) = setup_with_sector();
 
     let original_control_addresses = h.control_addrs.clone();
     let (new_worker_1, new_worker_2) = (Address::new_id(999), Address::new_id(1023));
@@ -182,6 +191,72 @@ fn change_cannot_be_overridden() {
     h.check_state(&rt);
 }
 
+#[test]
+fn worker_change_is_immediate_without_sectors() {
+    let (h, rt) = setup();
+
+    let new_worker = Address::new_id(999);
+    h.change_worker_address(&rt, new_worker, h.control_addrs.clone()).unwrap();
+
+    // the change is effective without confirmation
+    let info = h.get_info(&rt);
+    assert_eq!(new_worker, info.worker);
+    assert!(info.pending_worker_key.is_none());
+    assert_eq!(h.control_addrs, info.control_addresses);
+
+    h.check_state(&rt);
+}
+
+#[test]
+fn precommit_after_immediate_worker_change() {
+    let (mut h, rt) = setup();
+    rt.set_epoch(1);
+
+    let new_worker = Address::new_id(999);
+    h.change_worker_address(&rt, new_worker, h.control_addrs.clone()).unwrap();
+    assert_eq!(new_worker, h.get_info(&rt).worker);
+    h.worker = new_worker;
+
+    // the first pre-commit lands before the delay would have elapsed, from the new worker
+    h.pre_commit_single_sector(&rt, 100, true);
+
+    // confirmation has nothing left to do
+    rt.set_epoch(1 + rt.policy().worker_key_change_delay);
+    h.confirm_change_worker_address(&rt).unwrap();
+    let info = h.get_info(&rt);
+    assert_eq!(new_worker, info.worker);
+    assert!(info.pending_worker_key.is_none());
+
+    // now the miner has a sector, further changes are delayed
+    let next_worker = Address::new_id(1023);
+    let current_epoch = *rt.epoch.borrow();
+    h.change_worker_address(&rt, next_worker, h.control_addrs.clone()).unwrap();
+    let info = h.get_info(&rt);
+    assert_eq!(new_worker, info.worker);
+    let pending_worker_key = info.pending_worker_key.unwrap();
+    assert_eq!(next_worker, pending_worker_key.new_worker);
+    assert_eq!(
+        current_epoch + rt.policy().worker_key_change_delay,
+        pending_worker_key.effective_at
+    );
+
+    h.check_state(&rt);
+}
+
+#[test]
+fn precommit_before_worker_change_keeps_delay() {
+    let (h, rt) = setup_with_sector();
+
+    let new_worker = Address::new_id(999);
+    h.change_worker_address(&rt, new_worker, h.control_addrs.clone()).unwrap();
+
+    let info = h.get_info(&rt);
+    assert_eq!(h.worker, info.worker);
+    assert_eq!(new_worker, info.pending_worker_key.unwrap().new_worker);
+
+    h.check_state(&rt);
+}
+
 #[test]
 fn successfully_resolve_and_change_only_control_addresses() {
     let (h, rt) = setup();
@@ -206,7 +281,7 @@ fn successfully_resolve_and_change_only_control_addresses() {
 
 #[test]
 fn successfully_change_both_worker_and_control_addresses() {
-    let (h, rt) = setup();
+    let (h, rt) = setup_with_sector();
 
     let new_worker = Address::new_id(999);
     let (control_address_1, control_address_2) = (Address::new_id(5001), Address::new_id(5002));
@@ -1,4 +1,6 @@
 use fil_actors_runtime::test_utils::{expect_abort, MockRuntime};
+use fvm_ipld_bitfield::iter::Ranges;
+use fvm_ipld_bitfield::BitField;
 use fvm_shared::address::Address;
 use fvm_shared::{clock::ChainEpoch, error::ExitCode};
 
@@ -151,4 +153,33 @@ mod compact_sector_numbers_test {
         rt.reset();
         check_state_invariants_from_mock_runtime(&rt);
     }
+
+    #[test]
+    fn available_sector_numbers_skip_allocated_and_masked() {
+        let (h, rt) = setup();
+        assert_eq!(bitfield_from_slice(&[0, 1, 2]), h.available_sector_numbers(&rt, 3).unwrap());
+        assert_eq!(bitfield_from_slice(&[]), h.available_sector_numbers(&rt, 0).unwrap());
+
+        h.compact_sector_numbers(&rt, h.worker, bitfield_from_slice(&[1, 3, 4]));
+        assert_eq!(bitfield_from_slice(&[0, 2, 5, 6]), h.available_sector_numbers(&rt, 4).unwrap());
+        check_state_invariants_from_mock_runtime(&rt);
+
+        // A count larger than the sector number space returns every available number.
+        assert_eq!(
+            BitField::from_ranges(Ranges::new([0..1, 2..3, 5..MAX_SECTOR_NUMBER + 1])),
+            h.available_sector_numbers(&rt, u64::MAX).unwrap()
+        );
+
+        // Numbers above the maximum sector number are never returned.
+        // (State invariants aren't checked after this, as they expand the allocated sectors.)
+        h.compact_sector_numbers(
+            &rt,
+            h.worker,
+            BitField::from_ranges(Ranges::new([7..MAX_SECTOR_NUMBER])),
+        );
+        assert_eq!(
+            bitfield_from_slice(&[0, 2, 5, 6, MAX_SECTOR_NUMBER]),
+            h.available_sector_numbers(&rt, 10).unwrap()
+        );
+    }
 }
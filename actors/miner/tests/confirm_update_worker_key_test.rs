@@ -19,6 +19,8 @@ fn setup() -> (ActorHarness, MockRuntime) {
     h.construct_and_verify(&rt);
     rt.balance.replace(BIG_BALANCE.clone());
     rt.set_epoch(current_epoch);
+    // A miner with sectors is subject to the worker key change delay.
+    h.pre_commit_single_sector(&rt, 100, true);
 
     (h, rt)
 }
@@ -405,6 +405,46 @@ fn reschedules_sectors_as_faults() {
     assert_eq!(set.faulty_power, PowerPair::zero());
 }
 
+#[test]
+fn reschedules_early_expirations() {
+    let h = ActorHarness::new(0);
+    let rt = h.new_runtime();
+
+    // Fault all sectors to expire at epoch 5.
+    // The first set expires on time, the rest become early expirations at epoch 5.
+    let mut queue = empty_expiration_queue_with_quantizing(&rt, QuantSpec { unit: 4, offset: 1 });
+    let (_sec_nums, _power, _pledge) = queue.add_active_sectors(&sectors(), SECTOR_SIZE).unwrap();
+    queue.reschedule_all_as_faults(2).unwrap();
+
+    let _ = queue.amt.flush().unwrap();
+
+    // Defer the early expirations to epoch 9.
+    // Sectors 3 and 4 expire on time at epoch 9 anyway, so are left to expire early at epoch 5.
+    let rescheduled = queue.reschedule_early_expirations(5, 6, &sectors(), SECTOR_SIZE).unwrap();
+    assert_eq!(rescheduled, mk_bitfield([5, 6]));
+
+    let _ = queue.amt.flush().unwrap();
+
+    require_no_expiration_groups_before(5, &mut queue);
+    let set = queue.pop_until(5).unwrap();
+    assert_eq!(set.on_time_sectors, mk_bitfield([1, 2]));
+    assert_eq!(set.early_sectors, mk_bitfield([3, 4]));
+    assert_eq!(set.active_power, PowerPair::zero());
+    assert_eq!(set.faulty_power, power_for_sectors(SECTOR_SIZE, &sectors()[0..4]));
+
+    // The deferred sectors remain faulty.
+    require_no_expiration_groups_before(9, &mut queue);
+    let set = queue.pop_until(9).unwrap();
+    assert!(set.on_time_sectors.is_empty());
+    assert_eq!(set.early_sectors, mk_bitfield([5, 6]));
+    assert_eq!(set.active_power, PowerPair::zero());
+    assert_eq!(set.faulty_power, power_for_sectors(SECTOR_SIZE, &sectors()[4..]));
+
+    // Nothing is left in the queue.
+    let set = queue.pop_until(20).unwrap();
+    assert!(set.is_empty());
+}
+
 #[test]
 fn reschedules_all_sectors_as_faults() {
     let h = ActorHarness::new(0);
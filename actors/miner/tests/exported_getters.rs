@@ -1,6 +1,6 @@
 use fil_actor_miner::{
     Actor, GetAvailableBalanceReturn, GetOwnerReturn, GetSectorSizeReturn,
-    IsControllingAddressParam, IsControllingAddressReturn, Method,
+    IsControllingAddressParam, IsControllingAddressReturn, Method, ProvingDeadlineInfoReturn,
 };
 use fil_actors_runtime::runtime::policy_constants::MAX_SECTOR_NUMBER;
 use fil_actors_runtime::test_utils::EVM_ACTOR_CODE_ID;
@@ -144,3 +144,41 @@ fn collateral_getters() {
 
     h.check_state(&rt);
 }
+
+#[test]
+fn proving_deadline_info_getter() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    // move several deadlines ahead without running deadline cron,
+    // so the deadline recorded in state is stale
+    let recorded = h.deadline(&rt);
+    let epoch = recorded.close + 2 * rt.policy.wpost_challenge_window + 1;
+    rt.set_epoch(epoch);
+    assert_eq!(recorded.index, h.deadline(&rt).index);
+
+    rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+    rt.expect_validate_caller_any();
+    let ret: ProvingDeadlineInfoReturn = rt
+        .call::<Actor>(Method::ProvingDeadlineInfoExported as u64, None)
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .unwrap();
+    rt.verify();
+
+    let expected = h.current_deadline(&rt);
+    assert_eq!(epoch, ret.current_epoch);
+    assert_ne!(recorded.index, ret.index);
+    assert_eq!(expected.period_start, ret.period_start);
+    assert_eq!(expected.index, ret.index);
+    assert_eq!(expected.open, ret.open);
+    assert_eq!(expected.close, ret.close);
+    assert_eq!(expected.challenge, ret.challenge);
+    assert_eq!(expected.fault_cutoff, ret.fault_cutoff);
+    assert!(ret.open <= epoch && epoch < ret.close);
+
+    h.check_state(&rt);
+}
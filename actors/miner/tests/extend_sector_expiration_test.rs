@@ -1,18 +1,21 @@
 use fil_actor_market::ActivatedDeal;
 use fil_actor_miner::ext::verifreg::Claim as FILPlusClaim;
 use fil_actor_miner::{
-    power_for_sector, seal_proof_sector_maximum_lifetime, ExpirationExtension,
-    ExpirationExtension2, ExtendSectorExpiration2Params, ExtendSectorExpirationParams,
-    PoStPartition, SectorClaim, SectorOnChainInfo, State,
+    power_for_sector, seal_proof_sector_maximum_lifetime, Actor, ExpirationExtension,
+    ExpirationExtension2, ExtendSectorExpiration2BatchedParams, ExtendSectorExpiration2Params,
+    ExtendSectorExpirationParams, Method, PoStPartition, SectorClaim, SectorOnChainInfo, State,
 };
 use fil_actors_runtime::DealWeight;
 use fil_actors_runtime::{
     actor_error,
     runtime::{Runtime, RuntimePolicy},
-    test_utils::{expect_abort_contains_message, make_piece_cid, MockRuntime},
+    test_utils::{
+        expect_abort_contains_message, make_piece_cid, MockRuntime, ACCOUNT_ACTOR_CODE_ID,
+    },
     EPOCHS_IN_DAY,
 };
 use fvm_ipld_bitfield::BitField;
+use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_shared::deal::DealID;
 use fvm_shared::{
     address::Address,
@@ -728,6 +731,191 @@ fn extend_expiration2_drop_claims() {
     assert_sector_verified_space(&mut h, &rt, old_sector.sector_number, verified_deals[0].size.0);
 }
 
+#[test]
+fn extend_expiration2_batched_resumes_from_cursor() {
+    let (mut h, rt) = setup();
+    h.construct_and_verify(&rt);
+
+    let sector_infos =
+        h.commit_and_prove_sectors(&rt, 4, DEFAULT_SECTOR_EXPIRATION as u64, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sector_infos);
+
+    let new_expiration = sector_infos[0].expiration + 42 * rt.policy().wpost_proving_period;
+    let mut extensions: Vec<ExpirationExtension2> = Vec::new();
+    let state: State = rt.get_state();
+    let deadlines = state.load_deadlines(rt.store()).unwrap();
+    deadlines
+        .for_each(rt.store(), |deadline_index, deadline| {
+            let partitions = deadline.partitions_amt(rt.store()).unwrap();
+            partitions
+                .for_each(|partition_index, partition| {
+                    extensions.push(ExpirationExtension2 {
+                        deadline: deadline_index,
+                        partition: partition_index,
+                        sectors: partition.sectors.clone(),
+                        sectors_with_claims: vec![],
+                        new_expiration,
+                    });
+                    Ok(())
+                })
+                .unwrap();
+            Ok(())
+        })
+        .unwrap();
+    assert!(extensions.len() >= 2, "test error: this test should touch more than one partition");
+
+    // One partition per call.
+    let mut cursor = 0;
+    while cursor < extensions.len() as u64 {
+        let params = ExtendSectorExpiration2BatchedParams {
+            extensions: extensions.clone(),
+            cursor,
+            max_partitions: 1,
+        };
+        let ret = h.extend_sectors2_batched(&rt, params, HashMap::new(), cursor + 1).unwrap();
+
+        // Declarations up to the cursor are extended in full, later ones are untouched.
+        for (i, decl) in extensions.iter().enumerate() {
+            let expected = if (i as u64) < ret.next_cursor {
+                new_expiration
+            } else {
+                sector_infos[0].expiration
+            };
+            for sector_number in decl.sectors.iter() {
+                assert_eq!(expected, h.get_sector(&rt, sector_number).expiration);
+            }
+        }
+        h.check_state(&rt);
+        cursor = ret.next_cursor;
+    }
+
+    // Resuming past the last declaration does nothing.
+    let params = ExtendSectorExpiration2BatchedParams { extensions, cursor, max_partitions: 1 };
+    h.extend_sectors2_batched(&rt, params, HashMap::new(), cursor).unwrap();
+    h.check_state(&rt);
+}
+
+#[test]
+fn extend_expiration2_batched_counts_claims_against_budget() {
+    let (mut h, rt) = setup();
+    let verified_deals = vec![test_activated_deal(h.sector_size as u64, 1)];
+    let old_sector = commit_sector_verified_deals(&verified_deals, &mut h, &rt);
+    h.advance_and_submit_posts(&rt, &vec![old_sector.clone()]);
+
+    let state: State = rt.get_state();
+    let (deadline_index, partition_index) =
+        state.find_sector(rt.store(), old_sector.sector_number).unwrap();
+
+    let new_expiration = old_sector.expiration + 42 * rt.policy().wpost_proving_period;
+    let claim_id = 400;
+    let claim = make_claim(
+        claim_id,
+        &old_sector,
+        Address::new_id(3000).id().unwrap(),
+        h.receiver.id().unwrap(),
+        new_expiration,
+        &verified_deals[0],
+        rt.policy.minimum_verified_allocation_term,
+    );
+    let mut claims = HashMap::new();
+    claims.insert(claim_id, Ok(claim));
+
+    let extensions = vec![
+        ExpirationExtension2 {
+            deadline: deadline_index,
+            partition: partition_index,
+            sectors: BitField::new(),
+            new_expiration,
+            sectors_with_claims: vec![SectorClaim {
+                sector_number: old_sector.sector_number,
+                maintain_claims: vec![claim_id],
+                drop_claims: vec![],
+            }],
+        },
+        ExpirationExtension2 {
+            deadline: deadline_index,
+            partition: partition_index,
+            sectors: BitField::new(),
+            new_expiration,
+            sectors_with_claims: vec![],
+        },
+    ];
+
+    // The claim-bearing declaration costs two, so it exhausts the budget alone.
+    let params = ExtendSectorExpiration2BatchedParams {
+        extensions: extensions.clone(),
+        cursor: 0,
+        max_partitions: 2,
+    };
+    let ret = h.extend_sectors2_batched(&rt, params, claims.clone(), 1).unwrap();
+    assert!(ret.partial);
+    check_for_expiration(
+        &mut h,
+        &rt,
+        new_expiration,
+        old_sector.sector_number,
+        deadline_index,
+        partition_index,
+    );
+
+    // A declaration exceeding the budget is still processed if it is the first one.
+    let params = ExtendSectorExpiration2BatchedParams {
+        extensions: extensions.clone(),
+        cursor: 0,
+        max_partitions: 1,
+    };
+    let ret = h.extend_sectors2_batched(&rt, params, claims.clone(), 1).unwrap();
+    assert!(ret.partial);
+
+    let params = ExtendSectorExpiration2BatchedParams { extensions, cursor: 0, max_partitions: 3 };
+    let ret = h.extend_sectors2_batched(&rt, params, claims, 2).unwrap();
+    assert!(!ret.partial);
+    h.check_state(&rt);
+}
+
+#[test]
+fn extend_expiration2_batched_rejects_invalid_params() {
+    let (mut h, rt) = setup();
+    let old_sector = commit_sector(&mut h, &rt);
+    h.advance_and_submit_posts(&rt, &vec![old_sector.clone()]);
+
+    let state: State = rt.get_state();
+    let (deadline_index, partition_index) =
+        state.find_sector(rt.store(), old_sector.sector_number).unwrap();
+    let extensions = vec![ExpirationExtension2 {
+        deadline: deadline_index,
+        partition: partition_index,
+        sectors: make_bitfield(&[old_sector.sector_number]),
+        sectors_with_claims: vec![],
+        new_expiration: old_sector.expiration + 42 * rt.policy().wpost_proving_period,
+    }];
+
+    let call = |params: ExtendSectorExpiration2BatchedParams| {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.worker);
+        rt.call::<Actor>(
+            Method::ExtendSectorExpiration2Batched as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )
+    };
+
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "max partitions must be positive",
+        call(ExtendSectorExpiration2BatchedParams {
+            extensions: extensions.clone(),
+            cursor: 0,
+            max_partitions: 0,
+        }),
+    );
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "cursor 2 exceeds extension count 1",
+        call(ExtendSectorExpiration2BatchedParams { extensions, cursor: 2, max_partitions: 1 }),
+    );
+    rt.verify();
+    h.check_state(&rt);
+}
+
 #[test]
 fn update_expiration_legacy_fails_on_new_sector_with_deals() {
     let (mut h, rt) = setup();
@@ -0,0 +1,162 @@
+use fil_actor_miner::{pledge_penalty_for_continued_fault, power_for_sectors};
+use fil_actors_runtime::test_utils::{expect_abort, expect_abort_contains_message};
+use fvm_shared::clock::ChainEpoch;
+use fvm_shared::econ::TokenAmount;
+use fvm_shared::error::ExitCode;
+use num_traits::Zero;
+
+mod util;
+use util::*;
+
+const PERIOD_OFFSET: ChainEpoch = 100;
+
+#[test]
+fn escrow_pays_continued_fault_fee_before_fee_debt() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+    rt.set_balance(BIG_BALANCE.clone());
+    let one_sector = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    h.advance_and_submit_posts(&rt, &one_sector);
+
+    // Leave no unlocked funds, so any fee not covered by the escrow becomes fee debt.
+    let st = h.get_state(&rt);
+    rt.set_balance(&st.pre_commit_deposits + &st.initial_pledge + &st.locked_funds);
+
+    let ongoing_pwr = power_for_sectors(h.sector_size, &one_sector);
+    let ff = pledge_penalty_for_continued_fault(
+        &h.epoch_reward_smooth,
+        &h.epoch_qa_power_smooth,
+        &ongoing_pwr.qa,
+    );
+    let escrow = &ff + TokenAmount::from_atto(1);
+    h.prepay_fault_fees(&rt, &escrow).unwrap();
+    assert_eq!(escrow, h.get_state(&rt).fault_fee_escrow);
+
+    h.declare_faults(&rt, &one_sector);
+    let (dl_idx, _) = st.find_sector(&rt.store, one_sector[0].sector_number).unwrap();
+    h.advance_to_deadline(&rt, dl_idx);
+
+    // The fee is burnt from the escrow rather than added to fee debt.
+    h.advance_deadline(
+        &rt,
+        CronConfig {
+            continued_faults_penalty: ff.clone(),
+            penalty_from_unlocked: ff.clone(),
+            ..Default::default()
+        },
+    );
+    let st = h.get_state(&rt);
+    assert_eq!(TokenAmount::from_atto(1), st.fault_fee_escrow);
+    assert!(st.fee_debt.is_zero());
+
+    // Once the escrow is exhausted, the remainder becomes fee debt.
+    h.advance_to_deadline(&rt, dl_idx);
+    h.advance_deadline(
+        &rt,
+        CronConfig {
+            continued_faults_penalty: TokenAmount::from_atto(1),
+            penalty_from_unlocked: TokenAmount::from_atto(1),
+            ..Default::default()
+        },
+    );
+    let st = h.get_state(&rt);
+    assert!(st.fault_fee_escrow.is_zero());
+    assert_eq!(&ff - TokenAmount::from_atto(1), st.fee_debt);
+    h.check_state(&rt);
+}
+
+#[test]
+fn escrow_defers_termination_of_old_faults() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let mut rt = h.new_runtime();
+    rt.policy.fault_max_age = 2 * rt.policy.wpost_proving_period;
+    h.construct_and_verify(&rt);
+    rt.set_balance(BIG_BALANCE.clone());
+    let one_sector = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    h.advance_and_submit_posts(&rt, &one_sector);
+
+    let ongoing_pwr = power_for_sectors(h.sector_size, &one_sector);
+    let ff = pledge_penalty_for_continued_fault(
+        &h.epoch_reward_smooth,
+        &h.epoch_qa_power_smooth,
+        &ongoing_pwr.qa,
+    );
+    let periods = 4;
+    h.prepay_fault_fees(&rt, &(&ff * (periods + 1))).unwrap();
+
+    h.declare_faults(&rt, &one_sector);
+    let st = h.get_state(&rt);
+    let (dl_idx, p_idx) = st.find_sector(&rt.store, one_sector[0].sector_number).unwrap();
+
+    // The sector stays faulty well past the maximum fault age while the escrow pays its fees.
+    for _ in 0..periods {
+        h.advance_to_deadline(&rt, dl_idx);
+        h.advance_deadline(
+            &rt,
+            CronConfig {
+                continued_faults_penalty: ff.clone(),
+                penalty_from_unlocked: ff.clone(),
+                ..Default::default()
+            },
+        );
+    }
+
+    let st = h.get_state(&rt);
+    assert_eq!(ff, st.fault_fee_escrow);
+    assert!(st.early_terminations.is_empty());
+    let partition = h.get_deadline(&rt, dl_idx).load_partition(&rt.store, p_idx).unwrap();
+    assert!(partition.faults.get(one_sector[0].sector_number));
+    assert!(partition.terminated.is_empty());
+    h.check_state(&rt);
+}
+
+#[test]
+fn escrow_is_not_available_balance() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+
+    let escrow = TokenAmount::from_whole(10);
+    h.prepay_fault_fees(&rt, &escrow).unwrap();
+    h.withdraw_funds(&rt, h.owner, &escrow, &TokenAmount::zero(), &TokenAmount::zero()).unwrap();
+
+    h.withdraw_fault_fee_escrow(&rt, &TokenAmount::from_whole(4), &TokenAmount::from_whole(4))
+        .unwrap();
+    h.withdraw_fault_fee_escrow(&rt, &escrow, &TokenAmount::from_whole(6)).unwrap();
+    assert!(h.get_state(&rt).fault_fee_escrow.is_zero());
+    h.check_state(&rt);
+}
+
+#[test]
+fn escrow_not_withdrawable_while_faulty() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+    rt.set_balance(BIG_BALANCE.clone());
+    let one_sector = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    h.advance_and_submit_posts(&rt, &one_sector);
+
+    let escrow = TokenAmount::from_whole(10);
+    h.prepay_fault_fees(&rt, &escrow).unwrap();
+    h.declare_faults(&rt, &one_sector);
+
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "has faulty sectors",
+        h.withdraw_fault_fee_escrow(&rt, &escrow, &TokenAmount::zero()),
+    );
+    rt.reset();
+    h.check_state(&rt);
+}
+
+#[test]
+fn prepay_requires_positive_value() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+
+    expect_abort(ExitCode::USR_ILLEGAL_ARGUMENT, h.prepay_fault_fees(&rt, &TokenAmount::zero()));
+    rt.reset();
+    h.check_state(&rt);
+}
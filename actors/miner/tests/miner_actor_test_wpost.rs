@@ -96,6 +96,115 @@ fn basic_post_and_dispute() {
     h.dispute_window_post(&rt, &dlinfo, 0, &dispute_sectors, Some(expected_result));
 }
 
+#[test]
+fn batch_dispute_continues_past_failures() {
+    let period_offset = ChainEpoch::from(100);
+    let precommit_epoch = ChainEpoch::from(1);
+
+    let mut h = ActorHarness::new(period_offset);
+    h.set_proof_type(RegisteredSealProof::StackedDRG2KiBV1P1);
+
+    let rt = h.new_runtime();
+    rt.epoch.replace(precommit_epoch);
+    rt.balance.replace(BIG_BALANCE.clone());
+
+    h.construct_and_verify(&rt);
+
+    let sectors = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    let sector = sectors[0].clone();
+    let pwr = miner::power_for_sector(h.sector_size, &sector);
+
+    let state = h.get_state(&rt);
+    let (dlidx, pidx) = state.find_sector(&rt.store, sector.sector_number).unwrap();
+    let dlinfo = h.advance_to_deadline(&rt, dlidx);
+
+    h.submit_window_post(
+        &rt,
+        &dlinfo,
+        vec![miner::PoStPartition { index: pidx, skipped: make_empty_bitfield() }],
+        sectors.clone(),
+        PoStConfig::with_expected_power_delta(&pwr),
+    );
+    h.advance_deadline(&rt, CronConfig::empty());
+
+    // The first dispute succeeds, the second names a missing proof and the third
+    // repeats the first, whose proof has already been taken.
+    let disputes = vec![
+        miner::DisputeWindowedPoStParams { deadline: dlidx, post_index: 0 },
+        miner::DisputeWindowedPoStParams { deadline: dlidx, post_index: 1 },
+        miner::DisputeWindowedPoStParams { deadline: dlidx, post_index: 0 },
+    ];
+    h.expect_dispute_verification(&rt, &dlinfo, 0, &sectors, true);
+    let expected_fee = miner::pledge_penalty_for_invalid_windowpost(
+        &h.epoch_reward_smooth,
+        &h.epoch_qa_power_smooth,
+        &pwr.qa,
+    );
+    let expected_result = PoStDisputeResult {
+        expected_power_delta: Some(-pwr),
+        expected_penalty: Some(expected_fee),
+        expected_reward: Some(miner::BASE_REWARD_FOR_DISPUTED_WINDOW_POST.clone()),
+        expected_pledge_delta: None,
+    };
+    let results = h.dispute_window_post_batch(&rt, disputes, &expected_result);
+    assert_eq!(1, results.success_count);
+    assert_eq!(
+        vec![ExitCode::OK, ExitCode::USR_ILLEGAL_ARGUMENT, ExitCode::USR_ILLEGAL_ARGUMENT],
+        results.codes()
+    );
+
+    let deadline = h.get_deadline(&rt, dlidx);
+    let partition = deadline.load_partition(&rt.store, pidx).unwrap();
+    assert_bitfield_equals(&partition.faults, &[sector.sector_number]);
+    h.check_state(&rt);
+}
+
+#[test]
+fn batch_dispute_fails_if_no_dispute_succeeds() {
+    let period_offset = ChainEpoch::from(100);
+    let precommit_epoch = ChainEpoch::from(1);
+
+    let mut h = ActorHarness::new(period_offset);
+    h.set_proof_type(RegisteredSealProof::StackedDRG2KiBV1P1);
+
+    let rt = h.new_runtime();
+    rt.epoch.replace(precommit_epoch);
+    rt.balance.replace(BIG_BALANCE.clone());
+
+    h.construct_and_verify(&rt);
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.worker);
+    rt.expect_validate_caller_any();
+    let params = miner::DisputeWindowedPoStBatchParams { disputes: vec![] };
+    let result = rt.call::<miner::Actor>(
+        miner::Method::DisputeWindowedPoStBatch as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    );
+    expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "no disputes", result);
+    rt.verify();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.worker);
+    rt.expect_validate_caller_any();
+    h.expect_query_network_info(&rt);
+    let params = miner::DisputeWindowedPoStBatchParams {
+        disputes: vec![
+            miner::DisputeWindowedPoStParams { deadline: 50, post_index: 0 },
+            miner::DisputeWindowedPoStParams { deadline: 0, post_index: 0 },
+        ],
+    };
+    let result = rt.call::<miner::Actor>(
+        miner::Method::DisputeWindowedPoStBatch as u64,
+        IpldBlock::serialize_cbor(&params).unwrap(),
+    );
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "failed to dispute any post",
+        result,
+    );
+    rt.verify();
+    h.check_state(&rt);
+}
+
 #[test]
 fn invalid_submissions() {
     let period_offset = ChainEpoch::from(100);
@@ -755,6 +864,181 @@ fn successful_recoveries_recover_power() {
     h.check_state(&rt);
 }
 
+#[test]
+fn inline_recoveries_recover_power() {
+    let period_offset = ChainEpoch::from(100);
+    let precommit_epoch = ChainEpoch::from(1);
+
+    let mut h = ActorHarness::new(period_offset);
+    h.set_proof_type(RegisteredSealProof::StackedDRG2KiBV1P1);
+
+    let rt = h.new_runtime();
+    rt.epoch.replace(precommit_epoch);
+    rt.balance.replace(BIG_BALANCE.clone());
+
+    h.construct_and_verify(&rt);
+
+    let infos = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    let pwr = miner::power_for_sectors(h.sector_size, &infos);
+
+    h.apply_rewards(&rt, BIG_REWARDS.clone(), TokenAmount::zero());
+    let initial_locked = h.get_locked_funds(&rt);
+
+    // Submit first PoSt to ensure we are sufficiently early to add a fault
+    h.advance_and_submit_posts(&rt, &infos);
+
+    // advance deadline and declare fault
+    h.advance_deadline(&rt, CronConfig::empty());
+    h.declare_faults(&rt, &infos);
+
+    // advance to the faulty sector's deadline without declaring recovery
+    let state = h.get_state(&rt);
+    let (dlidx, pidx) = state.find_sector(&rt.store, infos[0].sector_number).unwrap();
+    let dlinfo = h.advance_to_deadline(&rt, dlidx);
+
+    // Submit PoSt with the recovery inline. Power should return for the recovered sector.
+    let params = miner::SubmitWindowedPoStWithRecoveriesParams {
+        post: miner::SubmitWindowedPoStParams {
+            deadline: dlidx,
+            partitions: vec![miner::PoStPartition { index: pidx, skipped: make_empty_bitfield() }],
+            proofs: make_post_proofs(h.window_post_proof_type),
+            chain_commit_epoch: dlinfo.challenge,
+            chain_commit_rand: Randomness(TEST_RANDOMNESS_ARRAY_FROM_ONE.into()),
+        },
+        recoveries: vec![miner::RecoveryDeclaration {
+            deadline: dlidx,
+            partition: pidx,
+            sectors: make_bitfield(&[infos[0].sector_number]),
+        }],
+    };
+    let cfg = PoStConfig::with_expected_power_delta(&pwr);
+    h.submit_window_post_with_recoveries_raw(&rt, &dlinfo, infos.clone(), params, cfg).unwrap();
+    rt.verify();
+
+    // faulty power has been removed, partition no longer has faults or recoveries
+    let (deadline, partition) = h.find_sector(&rt, infos[0].sector_number);
+    assert_eq!(miner::PowerPair::zero(), deadline.faulty_power);
+    assert_eq!(miner::PowerPair::zero(), partition.faulty_power);
+    assert!(partition.faults.is_empty());
+    assert!(partition.recoveries.is_empty());
+
+    // We restored power, so the proof was verified and not recorded for dispute.
+    let deadline = h.get_deadline(&rt, dlidx);
+    assert_bitfield_equals(&deadline.partitions_posted, &[pidx]);
+    let posts = amt_to_vec::<miner::WindowedPoSt>(&rt, &deadline.optimistic_post_submissions);
+    assert!(posts.is_empty());
+
+    // Next deadline cron does not charge for the fault
+    h.advance_deadline(&rt, CronConfig::empty());
+    assert_eq!(initial_locked, h.get_locked_funds(&rt));
+
+    h.check_state(&rt);
+}
+
+#[test]
+fn invalid_inline_recoveries_rejected() {
+    let period_offset = ChainEpoch::from(100);
+    let precommit_epoch = ChainEpoch::from(1);
+
+    let mut h = ActorHarness::new(period_offset);
+    h.set_proof_type(RegisteredSealProof::StackedDRG2KiBV1P1);
+
+    let rt = h.new_runtime();
+    rt.epoch.replace(precommit_epoch);
+    rt.balance.replace(BIG_BALANCE.clone());
+
+    h.construct_and_verify(&rt);
+
+    let infos = h.commit_and_prove_sectors(&rt, 2, DEFAULT_SECTOR_EXPIRATION, vec![], true);
+    h.apply_rewards(&rt, BIG_REWARDS.clone(), TokenAmount::zero());
+    h.advance_and_submit_posts(&rt, &infos);
+
+    // fault only the first sector
+    h.advance_deadline(&rt, CronConfig::empty());
+    h.declare_faults(&rt, &infos[0..1]);
+
+    let state = h.get_state(&rt);
+    let (dlidx, pidx) = state.find_sector(&rt.store, infos[0].sector_number).unwrap();
+    let dlinfo = h.advance_to_deadline(&rt, dlidx);
+
+    let make_params = |skipped: &[u64], recovery: miner::RecoveryDeclaration| {
+        miner::SubmitWindowedPoStWithRecoveriesParams {
+            post: miner::SubmitWindowedPoStParams {
+                deadline: dlidx,
+                partitions: vec![miner::PoStPartition {
+                    index: pidx,
+                    skipped: make_bitfield(skipped),
+                }],
+                proofs: make_post_proofs(h.window_post_proof_type),
+                chain_commit_epoch: dlinfo.challenge,
+                chain_commit_rand: Randomness(TEST_RANDOMNESS_ARRAY_FROM_ONE.into()),
+            },
+            recoveries: vec![recovery],
+        }
+    };
+    let recovery = |deadline: u64, partition: u64, sectors: &[u64]| miner::RecoveryDeclaration {
+        deadline,
+        partition,
+        sectors: make_bitfield(sectors),
+    };
+
+    // Recovering a sector that is not faulty is rejected.
+    let params = make_params(&[], recovery(dlidx, pidx, &[infos[1].sector_number]));
+    let result = h.submit_window_post_with_recoveries_raw(
+        &rt,
+        &dlinfo,
+        infos.clone(),
+        params,
+        PoStConfig::empty(),
+    );
+    expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "not all faulty", result);
+    rt.reset();
+
+    // Recovering a sector in a partition that is not being proven is rejected.
+    let params = make_params(&[], recovery(dlidx, pidx + 1, &[infos[0].sector_number]));
+    let result = h.submit_window_post_with_recoveries_raw(
+        &rt,
+        &dlinfo,
+        infos.clone(),
+        params,
+        PoStConfig::empty(),
+    );
+    expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "is not being proven", result);
+    rt.reset();
+
+    // Recovering a sector in another deadline is rejected.
+    let other_dlidx = (dlidx + 1) % rt.policy.wpost_period_deadlines;
+    let params = make_params(&[], recovery(other_dlidx, pidx, &[infos[0].sector_number]));
+    let result = h.submit_window_post_with_recoveries_raw(
+        &rt,
+        &dlinfo,
+        infos.clone(),
+        params,
+        PoStConfig::empty(),
+    );
+    expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "does not match", result);
+    rt.reset();
+
+    // Skipping a recovered sector is rejected.
+    let params =
+        make_params(&[infos[0].sector_number], recovery(dlidx, pidx, &[infos[0].sector_number]));
+    let result = h.submit_window_post_with_recoveries_raw(
+        &rt,
+        &dlinfo,
+        infos.clone(),
+        params,
+        PoStConfig::empty(),
+    );
+    expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "cannot skip", result);
+    rt.reset();
+
+    // The sector remains faulty.
+    let (_, partition) = h.find_sector(&rt, infos[0].sector_number);
+    assert!(partition.faults.get(infos[0].sector_number));
+    assert!(partition.recoveries.is_empty());
+    h.check_state(&rt);
+}
+
 #[test]
 fn skipped_faults_adjust_power() {
     let period_offset = ChainEpoch::from(100);
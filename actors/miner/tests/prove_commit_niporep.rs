@@ -522,9 +522,12 @@ fn ni_prove_fail_duplicated_sector_numbers() {
         proving_deadline,
     );
 
+    let state_before = *rt.state.borrow();
     let res = h.prove_commit_sectors_ni(&rt, params, true, noop());
     assert!(res.is_err());
     assert_eq!(res.unwrap_err().exit_code(), ExitCode::USR_ILLEGAL_ARGUMENT);
+    // No state was changed
+    assert_eq!(state_before, *rt.state.borrow());
 }
 
 fn fail_for_seal_rand_epoch(
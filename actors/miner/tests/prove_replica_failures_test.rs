@@ -9,8 +9,8 @@ use fvm_shared::ActorID;
 
 use fil_actor_miner::ext::verifreg::AllocationID;
 use fil_actor_miner::{
-    ProveReplicaUpdates3Params, SectorUpdateManifest, State, ERR_NOTIFICATION_RECEIVER_ABORTED,
-    ERR_NOTIFICATION_REJECTED,
+    ProveReplicaUpdates3Params, RecoveryDeclaration, SectorUpdateManifest, State,
+    ERR_NOTIFICATION_RECEIVER_ABORTED, ERR_NOTIFICATION_REJECTED,
 };
 use fil_actors_runtime::runtime::Runtime;
 use fil_actors_runtime::test_utils::{expect_abort_contains_message, MockRuntime};
@@ -112,6 +112,46 @@ fn reject_aggregate_proof() {
     h.check_state(&rt);
 }
 
+#[test]
+fn reject_sector_both_updated_and_recovered() {
+    let (h, rt, sector_updates) = setup(1, 0, 0, 0);
+    let update = sector_updates[0].clone();
+    let cfg = ProveReplicaUpdatesConfig {
+        recoveries: vec![RecoveryDeclaration {
+            deadline: update.deadline,
+            partition: update.partition,
+            sectors: make_bitfield(&[update.sector]),
+        }],
+        ..Default::default()
+    };
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "cannot be both updated and declared recovered",
+        h.prove_replica_updates2_batch(&rt, &sector_updates, false, false, cfg),
+    );
+    h.check_state(&rt);
+}
+
+#[test]
+fn reject_recovery_not_covered_by_update() {
+    let (h, rt, sector_updates) = setup(1, 0, 0, 0);
+    let update = sector_updates[0].clone();
+    let cfg = ProveReplicaUpdatesConfig {
+        recoveries: vec![RecoveryDeclaration {
+            deadline: update.deadline,
+            partition: update.partition + 1,
+            sectors: make_bitfield(&[update.sector + 1]),
+        }],
+        ..Default::default()
+    };
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "not covered by an update",
+        h.prove_replica_updates2_batch(&rt, &sector_updates, false, false, cfg),
+    );
+    h.check_state(&rt);
+}
+
 #[test]
 fn reject_all_proofs_fail() {
     let (h, rt, sector_updates) = setup(2, 0, 0, 0);
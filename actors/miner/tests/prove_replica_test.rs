@@ -5,7 +5,7 @@ use fvm_shared::{clock::ChainEpoch, ActorID};
 
 use fil_actor_miner::ext::verifreg::{AllocationClaim, SectorAllocationClaims};
 use fil_actor_miner::{DataActivationNotification, PieceChange, SectorChanges, State};
-use fil_actor_miner::{ProveReplicaUpdates3Return, SectorOnChainInfo};
+use fil_actor_miner::{ProveReplicaUpdates3Return, RecoveryDeclaration, SectorOnChainInfo};
 use fil_actors_runtime::cbor::serialize;
 use fil_actors_runtime::test_utils::{expect_abort_contains_message, MockRuntime};
 use fil_actors_runtime::{runtime::Runtime, BatchReturn, EPOCHS_IN_DAY, STORAGE_MARKET_ACTOR_ADDR};
@@ -491,6 +491,39 @@ fn update_to_empty() {
     h.check_state(&rt);
 }
 
+#[test]
+fn update_with_recoveries() {
+    let (h, rt, sectors) = setup_empty_sectors(2);
+    let snos = sectors.iter().map(|s| s.sector_number).collect::<Vec<_>>();
+    h.declare_faults(&rt, &sectors[1..]);
+
+    let st: State = h.get_state(&rt);
+    let store = rt.store();
+    let piece_size = h.sector_size as u64;
+    let sector_updates = vec![make_update_manifest(&st, store, snos[0], &[(piece_size, 0, 0, 0)])];
+    let (dlidx, pidx) = st.find_sector(store, snos[1]).unwrap();
+    assert_eq!((sector_updates[0].deadline, sector_updates[0].partition), (dlidx, pidx));
+
+    let cfg = ProveReplicaUpdatesConfig {
+        recoveries: vec![RecoveryDeclaration {
+            deadline: dlidx,
+            partition: pidx,
+            sectors: make_bitfield(&[snos[1]]),
+        }],
+        ..Default::default()
+    };
+    let (result, _, _) =
+        h.prove_replica_updates2_batch(&rt, &sector_updates, true, true, cfg).unwrap();
+    assert_update_result(&[ExitCode::OK], &result);
+
+    // The faulty sector is recovering, but regains no power until proven.
+    let (_, partition) = h.get_deadline_and_partition(&rt, dlidx, pidx);
+    assert_eq!(make_bitfield(&[snos[1]]), partition.faults);
+    assert_eq!(make_bitfield(&[snos[1]]), partition.recoveries);
+    verify_weights(&rt, &h, snos[0], piece_size, 0);
+    h.check_state(&rt);
+}
+
 fn setup_basic() -> (ActorHarness, MockRuntime) {
     let h = ActorHarness::new_with_options(HarnessOptions::default());
     let rt = h.new_runtime();
@@ -1,4 +1,5 @@
 mod state_harness;
+use fil_actor_miner::VestSpec;
 use fvm_shared::econ::TokenAmount;
 use state_harness::*;
 
@@ -30,3 +31,26 @@ fn repay_debt_in_priority_order() {
     let expected_debt = expected_debt + fee;
     assert_eq!(expected_debt, h.st.fee_debt);
 }
+
+#[test]
+fn repay_debt_from_vesting_leaves_vested_funds() {
+    let mut h = StateHarness::new(0);
+    let vspec = VestSpec { initial_delay: 0, vest_period: 5, step_duration: 1, quantization: 1 };
+
+    // 20 atto vests at each of epochs 101..=105.
+    let vest_start = 100;
+    h.add_locked_funds(vest_start, &TokenAmount::from_atto(100), &vspec).unwrap();
+    h.st.apply_penalty(&TokenAmount::from_atto(100)).unwrap();
+
+    // At epoch 103 only the entries for 103, 104 and 105 are unvested.
+    let from_vesting =
+        h.st.repay_debt_from_vesting(&h.store, vest_start + 3, &TokenAmount::from_atto(1000))
+            .unwrap();
+    assert_eq!(TokenAmount::from_atto(60), from_vesting);
+    assert_eq!(TokenAmount::from_atto(40), h.st.fee_debt);
+    assert_eq!(TokenAmount::from_atto(40), h.st.locked_funds);
+
+    // The already-vested entries remain available to be unlocked.
+    assert_eq!(TokenAmount::from_atto(40), h.unlock_vested_funds(vest_start + 3).unwrap());
+    assert!(h.st.locked_funds.is_zero());
+}
@@ -1,6 +1,9 @@
-use fil_actor_miner::{locked_reward_from_reward, Actor, Method};
-use fil_actors_runtime::test_utils::{expect_abort_contains_message, EVM_ACTOR_CODE_ID};
+use fil_actor_miner::{locked_reward_from_reward, Actor, Method, RepayDebtFromVestingParams};
+use fil_actors_runtime::test_utils::{
+    expect_abort, expect_abort_contains_message, ACCOUNT_ACTOR_CODE_ID, EVM_ACTOR_CODE_ID,
+};
 use fil_actors_runtime::BURNT_FUNDS_ACTOR_ADDR;
+use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_shared::bigint::Zero;
 use fvm_shared::clock::ChainEpoch;
 use fvm_shared::econ::TokenAmount;
@@ -141,3 +144,100 @@ fn pay_debt_partially_from_vested_funds() {
     assert!(st.fee_debt.is_zero());
     h.check_state(&rt);
 }
+
+#[test]
+fn repay_debt_from_vesting_burns_unvested_funds() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+
+    let reward_amount: TokenAmount = 4 * &*BIG_BALANCE;
+    let (amount_locked, _) = locked_reward_from_reward(reward_amount.clone());
+    rt.set_balance(amount_locked.clone());
+    h.apply_rewards(&rt, reward_amount, TokenAmount::zero());
+
+    // introduce fee debt
+    let mut st = h.get_state(&rt);
+    let fee_debt: TokenAmount = 4 * &*BIG_BALANCE;
+    st.fee_debt = fee_debt.clone();
+    rt.replace_state(&st);
+
+    // repay part of the debt from vesting funds
+    let amount = BIG_BALANCE.clone();
+    let ret = h.repay_debt_from_vesting(&rt, &amount, &amount).unwrap();
+    assert_eq!(&fee_debt - &amount, ret.remaining_debt);
+
+    let st = h.get_state(&rt);
+    assert_eq!(&fee_debt - &amount, st.fee_debt);
+    assert_eq!(&amount_locked - &amount, st.locked_funds);
+
+    // a larger request is limited by the remaining unvested funds
+    let remaining_locked = st.locked_funds.clone();
+    let ret = h.repay_debt_from_vesting(&rt, &fee_debt, &remaining_locked).unwrap();
+    assert_eq!(&fee_debt - &amount - &remaining_locked, ret.remaining_debt);
+    assert!(h.get_locked_funds(&rt).is_zero());
+    h.check_state(&rt);
+}
+
+#[test]
+fn repay_debt_from_vesting_is_capped_at_debt() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+
+    let reward_amount: TokenAmount = 4 * &*BIG_BALANCE;
+    let (amount_locked, _) = locked_reward_from_reward(reward_amount.clone());
+    rt.set_balance(amount_locked.clone());
+    h.apply_rewards(&rt, reward_amount, TokenAmount::zero());
+
+    let mut st = h.get_state(&rt);
+    st.fee_debt = BIG_BALANCE.clone();
+    rt.replace_state(&st);
+
+    let ret = h.repay_debt_from_vesting(&rt, &amount_locked, &BIG_BALANCE).unwrap();
+    assert!(ret.remaining_debt.is_zero());
+    assert_eq!(&amount_locked - &*BIG_BALANCE, h.get_locked_funds(&rt));
+    h.check_state(&rt);
+}
+
+#[test]
+fn repay_debt_from_vesting_fails_without_debt() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    h.construct_and_verify(&rt);
+
+    let reward_amount: TokenAmount = 4 * &*BIG_BALANCE;
+    let (amount_locked, _) = locked_reward_from_reward(reward_amount.clone());
+    rt.set_balance(amount_locked.clone());
+    h.apply_rewards(&rt, reward_amount, TokenAmount::zero());
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.worker);
+    rt.expect_validate_caller_addr(h.caller_addrs());
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "no fee debt",
+        rt.call::<Actor>(
+            Method::RepayDebtFromVesting as u64,
+            IpldBlock::serialize_cbor(&RepayDebtFromVestingParams { amount: BIG_BALANCE.clone() })
+                .unwrap(),
+        ),
+    );
+    rt.verify();
+
+    // a non-positive amount is rejected
+    let mut st = h.get_state(&rt);
+    st.fee_debt = BIG_BALANCE.clone();
+    rt.replace_state(&st);
+    rt.expect_validate_caller_addr(h.caller_addrs());
+    expect_abort(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        rt.call::<Actor>(
+            Method::RepayDebtFromVesting as u64,
+            IpldBlock::serialize_cbor(&RepayDebtFromVestingParams { amount: TokenAmount::zero() })
+                .unwrap(),
+        ),
+    );
+    rt.verify();
+    assert_eq!(amount_locked, h.get_locked_funds(&rt));
+    h.check_state(&rt);
+}
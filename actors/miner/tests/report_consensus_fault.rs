@@ -1,4 +1,5 @@
 use fil_actor_miner::testing::check_state_invariants;
+use fil_actor_miner::ReportConsensusFaultParams;
 use fil_actors_runtime::runtime::{Runtime, RuntimePolicy};
 use fil_actors_runtime::test_utils::{expect_abort, expect_abort_contains_message, MockRuntime};
 use fvm_shared::address::Address;
@@ -246,3 +247,131 @@ fn double_report_of_consensus_fault_fails() {
     rt.reset();
     check_state_invariants(rt.policy(), &h.get_state(&rt), rt.store(), &rt.get_balance());
 }
+
+fn fault_report(id: u8) -> ReportConsensusFaultParams {
+    ReportConsensusFaultParams { header1: vec![id], header2: vec![id, 1], header_extra: vec![] }
+}
+
+#[test]
+fn batch_report_applies_single_penalty() {
+    let (h, rt) = setup();
+    let report_epoch = 333;
+    rt.set_epoch(report_epoch);
+
+    let test_addr = Address::new_id(1234);
+    let fault = |epoch| ConsensusFault {
+        target: rt.receiver,
+        epoch,
+        fault_type: ConsensusFaultType::DoubleForkMining,
+    };
+
+    // Two distinct faults, one of which is reported twice, result in one penalty.
+    h.report_consensus_fault_batch(
+        &rt,
+        test_addr,
+        vec![
+            (fault_report(1), Some(fault(report_epoch - 3))),
+            (fault_report(2), Some(fault(report_epoch - 1))),
+            (fault_report(3), Some(fault(report_epoch - 3))),
+        ],
+        true,
+    )
+    .unwrap();
+    let info = h.get_info(&rt);
+    assert_eq!(
+        report_epoch + rt.policy.consensus_fault_ineligibility_duration,
+        info.consensus_fault_elapsed
+    );
+
+    // The same faults can't be reported again.
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "too old",
+        h.report_consensus_fault_batch(
+            &rt,
+            test_addr,
+            vec![(fault_report(2), Some(fault(report_epoch - 1)))],
+            false,
+        ),
+    );
+    rt.reset();
+    check_state_invariants(rt.policy(), &h.get_state(&rt), rt.store(), &rt.get_balance());
+}
+
+#[test]
+fn batch_report_rejects_fault_outside_reporting_window() {
+    let (h, rt) = setup();
+    let report_epoch = rt.policy.consensus_fault_reporting_window + 10;
+    rt.set_epoch(report_epoch);
+
+    let test_addr = Address::new_id(1234);
+    let fault = |epoch| ConsensusFault {
+        target: rt.receiver,
+        epoch,
+        fault_type: ConsensusFaultType::ParentGrinding,
+    };
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "outside the reporting window",
+        h.report_consensus_fault_batch(
+            &rt,
+            test_addr,
+            vec![
+                (fault_report(1), Some(fault(report_epoch - 1))),
+                (fault_report(2), Some(fault(5))),
+            ],
+            false,
+        ),
+    );
+    rt.reset();
+    assert_eq!(-1, h.get_info(&rt).consensus_fault_elapsed);
+    check_state_invariants(rt.policy(), &h.get_state(&rt), rt.store(), &rt.get_balance());
+}
+
+#[test]
+fn batch_report_rejects_invalid_reports() {
+    let (h, rt) = setup();
+    rt.set_epoch(10);
+    let test_addr = Address::new_id(1234);
+
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "no consensus fault reports",
+        h.report_consensus_fault_batch(&rt, test_addr, vec![], false),
+    );
+    rt.reset();
+
+    // Batches are limited in size, and rejected before any report is verified.
+    let too_many =
+        (0..=rt.policy.declarations_max).map(|i| (fault_report(i as u8), None)).collect();
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "too many consensus fault reports",
+        h.report_consensus_fault_batch(&rt, test_addr, too_many, false),
+    );
+    rt.reset();
+
+    // A single unverifiable report fails the batch.
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "invalid consensus fault report at index 1",
+        h.report_consensus_fault_batch(
+            &rt,
+            test_addr,
+            vec![
+                (
+                    fault_report(1),
+                    Some(ConsensusFault {
+                        target: rt.receiver,
+                        epoch: 9,
+                        fault_type: ConsensusFaultType::DoubleForkMining,
+                    }),
+                ),
+                (fault_report(2), None),
+            ],
+            false,
+        ),
+    );
+    rt.reset();
+    check_state_invariants(rt.policy(), &h.get_state(&rt), rt.store(), &rt.get_balance());
+}
@@ -1,8 +1,9 @@
 use fil_actor_miner::{
-    expected_reward_for_power, pledge_penalty_for_termination, qa_power_for_sector, Actor,
-    CronEventPayload, DeferredCronEventParams, Method, SectorOnChainInfo, State,
-    TerminateSectorsParams, TerminationDeclaration, CRON_EVENT_PROCESS_EARLY_TERMINATIONS,
-    INITIAL_PLEDGE_PROJECTION_PERIOD,
+    expected_reward_for_power, pledge_penalty_for_termination, power_for_sector,
+    qa_power_for_sector, Actor, BatchTerminateSectorsParams, CronEventPayload,
+    DeadlineTerminationPenalty, DeferredCronEventParams, Method, SectorExpirationInfo,
+    SectorOnChainInfo, State, TerminateSectorsParams, TerminationDeclaration,
+    CRON_EVENT_PROCESS_EARLY_TERMINATIONS, INITIAL_PLEDGE_PROJECTION_PERIOD,
 };
 use fil_actors_runtime::{
     runtime::Runtime,
@@ -309,6 +310,189 @@ fn system_can_terminate_if_market_cron_fails() {
     h.check_state(&rt);
 }
 
+#[test]
+fn batch_terminate_stops_at_max_sectors() {
+    let (mut h, rt) = setup();
+
+    let sectors = h.commit_and_prove_sectors(&rt, 3, DEFAULT_SECTOR_EXPIRATION, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sectors);
+    // Add locked funds to ensure correct fee calculation is used.
+    h.apply_rewards(&rt, BIG_REWARDS.clone(), TokenAmount::zero());
+
+    let snos: Vec<SectorNumber> = sectors.iter().map(|s| s.sector_number).collect();
+
+    // Only the first two declared sectors are terminated.
+    let expected_fee: TokenAmount = sectors[..2]
+        .iter()
+        .fold(TokenAmount::zero(), |acc, s| acc + calc_expected_fee_for_termination(&h, &rt, s));
+    let ret = h.batch_terminate_sectors(&rt, &bitfield_from_slice(&snos), Some(2), expected_fee);
+    assert!(ret.done);
+    assert!(ret.partial);
+
+    let (_, partition) = h.find_sector(&rt, snos[0]);
+    assert!(partition.terminated.get(snos[0]));
+    assert!(partition.terminated.get(snos[1]));
+    assert!(!partition.terminated.get(snos[2]));
+
+    // Resubmitting the remainder completes the termination.
+    let expected_fee = calc_expected_fee_for_termination(&h, &rt, &sectors[2]);
+    let ret = h.batch_terminate_sectors(&rt, &bitfield_from_slice(&snos[2..]), None, expected_fee);
+    assert!(ret.done);
+    assert!(!ret.partial);
+
+    let state: State = rt.get_state();
+    assert!(state.initial_pledge.is_zero());
+    h.check_state(&rt);
+}
+
+#[test]
+fn batch_terminate_rejects_invalid_max_sectors() {
+    let (mut h, rt) = setup();
+
+    let sectors = h.commit_and_prove_sectors(&rt, 1, DEFAULT_SECTOR_EXPIRATION, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sectors);
+    let sector = &sectors[0];
+
+    let state: State = rt.get_state();
+    let (deadline, partition) = state.find_sector(rt.store(), sector.sector_number).unwrap();
+
+    for max_sectors in [0, rt.policy.addressed_sectors_max + 1] {
+        let params = BatchTerminateSectorsParams {
+            terminations: vec![TerminationDeclaration {
+                deadline,
+                partition,
+                sectors: make_bitfield(&[sector.sector_number]),
+            }],
+            max_sectors: Some(max_sectors),
+        };
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.worker);
+        let res = rt.call::<Actor>(
+            Method::BatchTerminateSectors as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        );
+        expect_abort_contains_message(ExitCode::USR_ILLEGAL_ARGUMENT, "max sectors", res);
+        rt.reset();
+    }
+
+    h.check_state(&rt);
+}
+
+#[test]
+fn dry_run_matches_termination() {
+    let (mut h, rt) = setup();
+
+    let sectors = h.commit_and_prove_sectors(&rt, 2, DEFAULT_SECTOR_EXPIRATION, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sectors);
+    h.apply_rewards(&rt, BIG_REWARDS.clone(), TokenAmount::zero());
+
+    let snos = bitfield_from_slice(&[sectors[0].sector_number, sectors[1].sector_number]);
+    let state_before = *rt.state.borrow();
+    let ret = h.terminate_sectors_dry_run(&rt, &snos).unwrap();
+    assert_eq!(state_before, *rt.state.borrow());
+
+    let expected_fee = calc_expected_fee_for_termination(&h, &rt, &sectors[0])
+        + calc_expected_fee_for_termination(&h, &rt, &sectors[1]);
+    let state: State = rt.get_state();
+    let (dlidx, _) = state.find_sector(rt.store(), sectors[0].sector_number).unwrap();
+    // Locked rewards cover the whole fee.
+    assert_eq!(
+        vec![DeadlineTerminationPenalty {
+            deadline: dlidx,
+            pledge_to_burn: expected_fee.clone(),
+            fault_fee: TokenAmount::zero(),
+            termination_fee: expected_fee.clone(),
+        }],
+        ret.deadlines
+    );
+
+    // The real termination charges the same fee.
+    h.terminate_sectors(&rt, &snos, expected_fee);
+
+    // Sectors that are already terminated are rejected.
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "can only terminate live sectors",
+        h.terminate_sectors_dry_run(&rt, &snos),
+    );
+    rt.reset();
+    h.check_state(&rt);
+}
+
+#[test]
+fn dry_run_caps_burn_by_available_funds() {
+    let (h, rt) = setup();
+
+    let sectors = h.commit_and_prove_sectors(&rt, 2, DEFAULT_SECTOR_EXPIRATION, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sectors);
+
+    // With outstanding fee debt and no locked rewards or spare balance, only the released
+    // pledge is available to pay fees. The rest would remain as fee debt.
+    let mut state: State = rt.get_state();
+    let fee_debt = &state.initial_pledge * 2;
+    state.fee_debt = fee_debt.clone();
+    rt.replace_state(&state);
+    rt.set_balance(&state.pre_commit_deposits + &state.initial_pledge + &state.locked_funds);
+
+    let snos = bitfield_from_slice(&[sectors[0].sector_number, sectors[1].sector_number]);
+    let ret = h.terminate_sectors_dry_run(&rt, &snos).unwrap();
+
+    let expected_fee = calc_expected_fee_for_termination(&h, &rt, &sectors[0])
+        + calc_expected_fee_for_termination(&h, &rt, &sectors[1]);
+    let (dlidx, _) = state.find_sector(rt.store(), sectors[0].sector_number).unwrap();
+    assert_eq!(
+        vec![DeadlineTerminationPenalty {
+            deadline: dlidx,
+            pledge_to_burn: &sectors[0].initial_pledge + &sectors[1].initial_pledge,
+            fault_fee: fee_debt.clone(),
+            termination_fee: expected_fee,
+        }],
+        ret.deadlines
+    );
+    assert_eq!(fee_debt, h.get_state(&rt).fee_debt);
+}
+
+#[test]
+fn sector_expirations_omits_terminated_sectors() {
+    let (mut h, rt) = setup();
+
+    let sector_infos =
+        h.commit_and_prove_sectors(&rt, 2, DEFAULT_SECTOR_EXPIRATION, Vec::new(), true);
+    h.advance_and_submit_posts(&rt, &sector_infos);
+    let (live, terminated) = (&sector_infos[0], &sector_infos[1]);
+
+    let expected_fee = calc_expected_fee_for_termination(&h, &rt, terminated);
+    h.terminate_sectors(&rt, &bitfield_from_slice(&[terminated.sector_number]), expected_fee);
+
+    let unknown = 1000;
+    let ret = h
+        .sector_expirations(&rt, &[live.sector_number, terminated.sector_number, unknown])
+        .unwrap();
+    assert_eq!(
+        vec![ExitCode::OK, ExitCode::USR_NOT_FOUND, ExitCode::USR_NOT_FOUND],
+        ret.results.codes()
+    );
+    assert_eq!(
+        vec![SectorExpirationInfo {
+            expiration: live.expiration,
+            deal_weight: live.deal_weight.clone(),
+            verified_deal_weight: live.verified_deal_weight.clone(),
+            power: power_for_sector(h.sector_size, live),
+        }],
+        ret.sectors
+    );
+
+    // Too many sectors are rejected.
+    let too_many = (0..=rt.policy.addressed_sectors_max).collect::<Vec<_>>();
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "too many sectors",
+        h.sector_expirations(&rt, &too_many),
+    );
+    rt.reset();
+    h.check_state(&rt);
+}
+
 fn calc_expected_fee_for_termination(
     h: &ActorHarness,
     rt: &MockRuntime,
@@ -61,30 +61,38 @@ use fil_actor_miner::{
     new_deadline_info_from_offset_and_epoch, pledge_penalty_for_continued_fault, power_for_sectors,
     qa_power_for_sector, qa_power_for_weight, reward_for_consensus_slash_report,
     testing::{check_deadline_state_invariants, check_state_invariants, DeadlineStateSummary},
-    ActiveBeneficiary, Actor, ApplyRewardParams, BeneficiaryTerm, BitFieldQueue,
-    ChangeBeneficiaryParams, ChangeMultiaddrsParams, ChangePeerIDParams, ChangeWorkerAddressParams,
-    CheckSectorProvenParams, CompactCommD, CompactPartitionsParams, CompactSectorNumbersParams,
-    CronEventPayload, DataActivationNotification, Deadline, DeadlineInfo, Deadlines,
-    DeclareFaultsParams, DeclareFaultsRecoveredParams, DeferredCronEventParams,
-    DisputeWindowedPoStParams, ExpirationQueue, ExpirationSet, ExtendSectorExpiration2Params,
+    ActiveBeneficiary, Actor, ApplyRewardParams, AvailableSectorNumbersParams,
+    AvailableSectorNumbersReturn, BatchTerminateSectorsParams, BatchTerminateSectorsReturn,
+    BeneficiaryTerm, BitFieldQueue, ChangeBeneficiaryParams, ChangeMultiaddrsParams,
+    ChangePeerIDParams, ChangeWorkerAddressParams, CheckSectorProvenParams, CompactCommD,
+    CompactPartitionsParams, CompactSectorNumbersParams, CronEventPayload,
+    DataActivationNotification, Deadline, DeadlineInfo, Deadlines, DeclareFaultsParams,
+    DeclareFaultsRecoveredParams, DeferredCronEventParams, DisputeWindowedPoStBatchParams,
+    DisputeWindowedPoStBatchReturn, DisputeWindowedPoStParams, ExpirationExtension2,
+    ExpirationQueue, ExpirationSet, ExtendSectorExpiration2BatchedParams,
+    ExtendSectorExpiration2BatchedReturn, ExtendSectorExpiration2Params,
     ExtendSectorExpirationParams, FaultDeclaration, GetAvailableBalanceReturn,
     GetBeneficiaryReturn, GetControlAddressesReturn, GetMultiaddrsReturn, GetPeerIDReturn, Method,
     Method as MinerMethod, MinerConstructorParams as ConstructorParams, MinerInfo, Partition,
     PendingBeneficiaryChange, PieceActivationManifest, PieceChange, PieceReturn, PoStPartition,
     PowerPair, PreCommitSectorBatchParams, PreCommitSectorBatchParams2, PreCommitSectorParams,
     ProveCommitAggregateParams, ProveCommitSectorParams, ProveCommitSectors3Params,
-    ProveCommitSectors3Return, QuantSpec, RecoveryDeclaration, ReportConsensusFaultParams,
+    ProveCommitSectors3Return, QuantSpec, RecoveryDeclaration, RepayDebtFromVestingParams,
+    RepayDebtFromVestingReturn, ReportConsensusFaultBatchParams, ReportConsensusFaultParams,
     SectorActivationManifest, SectorChanges, SectorContentChangedParams,
-    SectorContentChangedReturn, SectorOnChainInfo, SectorPreCommitInfo, SectorPreCommitOnChainInfo,
-    SectorReturn, SectorUpdateManifest, Sectors, State, SubmitWindowedPoStParams,
-    TerminateSectorsParams, TerminationDeclaration, VerifiedAllocationKey, VestingFunds,
-    WindowedPoSt, WithdrawBalanceParams, WithdrawBalanceReturn, CRON_EVENT_PROVING_DEADLINE,
-    NI_AGGREGATE_FEE_BASE_SECTOR_COUNT, NO_QUANTIZATION, REWARD_VESTING_SPEC, SECTORS_AMT_BITWIDTH,
-    SECTOR_CONTENT_CHANGED,
+    SectorContentChangedReturn, SectorExpirationsParams, SectorExpirationsReturn,
+    SectorOnChainInfo, SectorPreCommitInfo, SectorPreCommitOnChainInfo, SectorReturn,
+    SectorUpdateManifest, Sectors, State, SubmitWindowedPoStParams,
+    SubmitWindowedPoStWithRecoveriesParams, TerminateSectorsDryRunReturn, TerminateSectorsParams,
+    TerminationDeclaration, VerifiedAllocationKey, VestingFunds, WindowedPoSt,
+    WithdrawBalanceParams, WithdrawBalanceReturn, WithdrawBalanceToParams,
+    CRON_EVENT_PROVING_DEADLINE, NI_AGGREGATE_FEE_BASE_SECTOR_COUNT, NO_QUANTIZATION,
+    REWARD_VESTING_SPEC, SECTORS_AMT_BITWIDTH, SECTOR_CONTENT_CHANGED,
 };
 use fil_actor_miner::{
     raw_power_for_sector, ProveCommitSectorsNIParams, ProveCommitSectorsNIReturn,
-    ProveReplicaUpdates3Params, ProveReplicaUpdates3Return, SectorNIActivationInfo,
+    ProveReplicaUpdates3Params, ProveReplicaUpdates3Return,
+    ProveReplicaUpdatesWithRecoveriesParams, SectorNIActivationInfo,
 };
 use fil_actor_power::{
     CurrentTotalPowerReturn, EnrollCronEventParams, Method as PowerMethod, UpdateClaimedPowerParams,
@@ -749,6 +757,20 @@ impl ActorHarness {
         result[0].clone()
     }
 
+    // Pre-commits a single sector with no deals at the current epoch.
+    pub fn pre_commit_single_sector(
+        &self,
+        rt: &MockRuntime,
+        sector_no: SectorNumber,
+        first: bool,
+    ) -> SectorPreCommitOnChainInfo {
+        let epoch = *rt.epoch.borrow();
+        let expiration = self.deadline(rt).period_end()
+            + rt.policy.wpost_proving_period * DEFAULT_SECTOR_EXPIRATION as i64;
+        let params = self.make_pre_commit_params(sector_no, epoch - 1, expiration, vec![]);
+        self.pre_commit_sector_and_get(rt, params, PreCommitConfig::empty(), first)
+    }
+
     pub fn has_precommit(&self, rt: &MockRuntime, sector_number: SectorNumber) -> bool {
         let state = self.get_state(rt);
         state.get_precommitted_sector(&rt.store, sector_number).unwrap().is_some()
@@ -1687,10 +1709,21 @@ impl ActorHarness {
             )
         }
 
-        let result = rt.call::<Actor>(
-            MinerMethod::ProveReplicaUpdates3 as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        );
+        let result = if cfg.recoveries.is_empty() {
+            rt.call::<Actor>(
+                MinerMethod::ProveReplicaUpdates3 as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )
+        } else {
+            let params = ProveReplicaUpdatesWithRecoveriesParams {
+                updates: params,
+                recoveries: cfg.recoveries,
+            };
+            rt.call::<Actor>(
+                MinerMethod::ProveReplicaUpdatesWithRecoveries as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )
+        };
         let result = result
             .map(|r| {
                 let ret: ProveReplicaUpdates3Return = r.unwrap().deserialize().unwrap();
@@ -1847,6 +1880,41 @@ impl ActorHarness {
         params: SubmitWindowedPoStParams,
         cfg: PoStConfig,
     ) -> Result<Option<IpldBlock>, ActorError> {
+        self.expect_submit_window_post(rt, deadline, infos, &params, &BitField::new(), cfg);
+        rt.call::<Actor>(
+            Method::SubmitWindowedPoSt as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )
+    }
+
+    pub fn submit_window_post_with_recoveries_raw(
+        &self,
+        rt: &MockRuntime,
+        deadline: &DeadlineInfo,
+        infos: Vec<SectorOnChainInfo>,
+        params: SubmitWindowedPoStWithRecoveriesParams,
+        cfg: PoStConfig,
+    ) -> Result<Option<IpldBlock>, ActorError> {
+        let mut inline_recoveries = BitField::new();
+        for r in &params.recoveries {
+            inline_recoveries |= &r.sectors;
+        }
+        self.expect_submit_window_post(rt, deadline, infos, &params.post, &inline_recoveries, cfg);
+        rt.call::<Actor>(
+            Method::SubmitWindowedPoStWithRecoveries as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )
+    }
+
+    fn expect_submit_window_post(
+        &self,
+        rt: &MockRuntime,
+        deadline: &DeadlineInfo,
+        infos: Vec<SectorOnChainInfo>,
+        params: &SubmitWindowedPoStParams,
+        inline_recoveries: &BitField,
+        cfg: PoStConfig,
+    ) {
         rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
         let chain_commit_rand = match cfg.chain_randomness {
             Some(r) => r,
@@ -1869,9 +1937,10 @@ impl ActorHarness {
         for p in &params.partitions {
             let maybe_partition = dln.load_partition(&rt.store, p.index);
             if let Ok(partition) = maybe_partition {
-                let expected_faults = &partition.faults - &partition.recoveries;
+                let recoveries = &partition.recoveries | &(&partition.faults & inline_recoveries);
+                let expected_faults = &partition.faults - &recoveries;
                 all_ignored |= &(&expected_faults | &p.skipped);
-                all_recovered |= &(&partition.recoveries - &p.skipped);
+                all_recovered |= &(&recoveries - &p.skipped);
             }
         }
         let optimistic = all_recovered.is_empty();
@@ -1914,11 +1983,6 @@ impl ActorHarness {
         if let Some(power_delta) = cfg.expected_power_delta {
             expect_update_power(rt, power_delta);
         }
-
-        rt.call::<Actor>(
-            Method::SubmitWindowedPoSt as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        )
     }
 
     fn make_window_post_verify_info(
@@ -1963,7 +2027,71 @@ impl ActorHarness {
         rt.expect_validate_caller_any();
 
         self.expect_query_network_info(rt);
+        self.expect_dispute_verification(
+            rt,
+            deadline,
+            proof_index,
+            infos,
+            expect_success.is_some(),
+        );
+        if let Some(dispute_result) = &expect_success {
+            self.expect_dispute_result(rt, dispute_result);
+        }
 
+        let params =
+            DisputeWindowedPoStParams { deadline: deadline.index, post_index: proof_index };
+        let result = rt.call::<Actor>(
+            Method::DisputeWindowedPoSt as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        );
+
+        if expect_success.is_some() {
+            result.unwrap();
+        } else {
+            expect_abort_contains_message(
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+                "failed to dispute valid post",
+                result,
+            );
+        }
+        rt.verify();
+    }
+
+    // Disputes a batch of posts. Verification of any proofs reached by the batch must
+    // be set up by the caller with expect_dispute_verification.
+    pub fn dispute_window_post_batch(
+        &self,
+        rt: &MockRuntime,
+        disputes: Vec<DisputeWindowedPoStParams>,
+        expected_result: &PoStDisputeResult,
+    ) -> BatchReturn {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
+        rt.expect_validate_caller_any();
+        self.expect_query_network_info(rt);
+        self.expect_dispute_result(rt, expected_result);
+
+        let params = DisputeWindowedPoStBatchParams { disputes };
+        let ret: DisputeWindowedPoStBatchReturn = rt
+            .call::<Actor>(
+                Method::DisputeWindowedPoStBatch as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )
+            .unwrap()
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        ret.results
+    }
+
+    pub fn expect_dispute_verification(
+        &self,
+        rt: &MockRuntime,
+        deadline: &DeadlineInfo,
+        proof_index: u64,
+        infos: &[SectorOnChainInfo],
+        proof_invalid: bool,
+    ) {
         let challenge_rand = TEST_RANDOMNESS_ARRAY_FROM_ONE;
         let mut all_ignored = BitField::new();
         let dln = self.get_deadline(rt, deadline.index);
@@ -2000,67 +2128,41 @@ impl ActorHarness {
             Randomness(challenge_rand.into()),
             post.proofs,
         );
-        let verify_result = match expect_success {
-            Some(_) => ExitCode::USR_ILLEGAL_ARGUMENT,
-            None => ExitCode::OK,
-        };
+        let verify_result =
+            if proof_invalid { ExitCode::USR_ILLEGAL_ARGUMENT } else { ExitCode::OK };
         rt.expect_verify_post(vi, verify_result);
+    }
 
-        if expect_success.is_some() {
-            let dispute_result = expect_success.clone().unwrap();
-
-            if dispute_result.expected_power_delta.is_some() {
-                let expected_power_delta = dispute_result.expected_power_delta.unwrap();
-                expect_update_power(rt, expected_power_delta);
-            }
-
-            if dispute_result.expected_reward.is_some() {
-                let expected_reward = dispute_result.expected_reward.unwrap();
-                rt.expect_send_simple(
-                    self.worker,
-                    METHOD_SEND,
-                    None,
-                    expected_reward,
-                    None,
-                    ExitCode::OK,
-                );
-            }
-
-            if dispute_result.expected_penalty.is_some() {
-                let expected_penalty = dispute_result.expected_penalty.unwrap();
-                rt.expect_send_simple(
-                    BURNT_FUNDS_ACTOR_ADDR,
-                    METHOD_SEND,
-                    None,
-                    expected_penalty,
-                    None,
-                    ExitCode::OK,
-                );
-            }
-
-            if dispute_result.expected_pledge_delta.is_some() {
-                let expected_pledge_delta = dispute_result.expected_pledge_delta.unwrap();
-                expect_update_pledge(rt, &expected_pledge_delta);
-            }
+    fn expect_dispute_result(&self, rt: &MockRuntime, dispute_result: &PoStDisputeResult) {
+        if let Some(expected_power_delta) = &dispute_result.expected_power_delta {
+            expect_update_power(rt, expected_power_delta.clone());
         }
 
-        let params =
-            DisputeWindowedPoStParams { deadline: deadline.index, post_index: proof_index };
-        let result = rt.call::<Actor>(
-            Method::DisputeWindowedPoSt as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        );
+        if let Some(expected_reward) = &dispute_result.expected_reward {
+            rt.expect_send_simple(
+                self.worker,
+                METHOD_SEND,
+                None,
+                expected_reward.clone(),
+                None,
+                ExitCode::OK,
+            );
+        }
 
-        if expect_success.is_some() {
-            result.unwrap();
-        } else {
-            expect_abort_contains_message(
-                ExitCode::USR_ILLEGAL_ARGUMENT,
-                "failed to dispute valid post",
-                result,
+        if let Some(expected_penalty) = &dispute_result.expected_penalty {
+            rt.expect_send_simple(
+                BURNT_FUNDS_ACTOR_ADDR,
+                METHOD_SEND,
+                None,
+                expected_penalty.clone(),
+                None,
+                ExitCode::OK,
             );
         }
-        rt.verify();
+
+        if let Some(expected_pledge_delta) = &dispute_result.expected_pledge_delta {
+            expect_update_pledge(rt, expected_pledge_delta);
+        }
     }
 
     fn get_submitted_proof(&self, rt: &MockRuntime, deadline: &Deadline, idx: u64) -> WindowedPoSt {
@@ -2381,7 +2483,53 @@ impl ActorHarness {
             fault,
             verify_exit_code,
         );
+        self.expect_consensus_fault_penalty(rt, from);
+
+        let result = rt.call::<Actor>(
+            Method::ReportConsensusFault as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+        expect_empty(result);
+        rt.verify();
+        Ok(())
+    }
+
+    // Reports the given header triples with their verified faults in a single batch.
+    // When penalized is false, only the verification of the reports is expected.
+    pub fn report_consensus_fault_batch(
+        &self,
+        rt: &MockRuntime,
+        from: Address,
+        reports: Vec<(ReportConsensusFaultParams, Option<ConsensusFault>)>,
+        penalized: bool,
+    ) -> Result<(), ActorError> {
+        rt.expect_validate_caller_any();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, from);
+        let mut params = ReportConsensusFaultBatchParams { reports: vec![] };
+        for (report, fault) in reports {
+            rt.expect_verify_consensus_fault(
+                report.header1.clone(),
+                report.header2.clone(),
+                report.header_extra.clone(),
+                fault,
+                ExitCode::OK,
+            );
+            params.reports.push(report);
+        }
+        if penalized {
+            self.expect_consensus_fault_penalty(rt, from);
+        }
+
+        let result = rt.call::<Actor>(
+            Method::ReportConsensusFaultBatch as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+        expect_empty(result);
+        rt.verify();
+        Ok(())
+    }
 
+    fn expect_consensus_fault_penalty(&self, rt: &MockRuntime, from: Address) {
         let current_reward = ThisEpochRewardReturn {
             this_epoch_baseline_power: self.baseline_power.clone(),
             this_epoch_reward_smoothed: self.epoch_reward_smooth.clone(),
@@ -2409,14 +2557,6 @@ impl ActorHarness {
             None,
             ExitCode::OK,
         );
-
-        let result = rt.call::<Actor>(
-            Method::ReportConsensusFault as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        )?;
-        expect_empty(result);
-        rt.verify();
-        Ok(())
     }
 
     pub fn collect_deadline_expirations(
@@ -2465,6 +2605,103 @@ impl ActorHarness {
         rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
         rt.expect_validate_caller_addr(self.caller_addrs());
 
+        let (power_delta, pledge_delta) = self.expect_terminate_sectors(rt, sectors, expected_fee);
+        let params = TerminateSectorsParams {
+            terminations: self.make_termination_declarations(rt, sectors),
+        };
+
+        rt.call::<Actor>(
+            Method::TerminateSectors as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )
+        .unwrap();
+        rt.verify();
+
+        (power_delta, pledge_delta)
+    }
+
+    pub fn sector_expirations(
+        &self,
+        rt: &MockRuntime,
+        sectors: &[SectorNumber],
+    ) -> Result<SectorExpirationsReturn, ActorError> {
+        rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+        rt.expect_validate_caller_any();
+        let params = SectorExpirationsParams { sectors: bitfield_from_slice(sectors) };
+        let ret = rt
+            .call::<Actor>(
+                Method::SectorExpirationsExported as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        Ok(ret)
+    }
+
+    pub fn terminate_sectors_dry_run(
+        &self,
+        rt: &MockRuntime,
+        sectors: &BitField,
+    ) -> Result<TerminateSectorsDryRunReturn, ActorError> {
+        rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+        rt.expect_validate_caller_any();
+        self.expect_query_network_info(rt);
+        let params = TerminateSectorsParams {
+            terminations: self.make_termination_declarations(rt, sectors),
+        };
+        let ret = rt
+            .call::<Actor>(
+                Method::TerminateSectorsDryRunExported as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        Ok(ret)
+    }
+
+    // Declares all of the provided sectors for termination, expecting only the first
+    // max_sectors of them to be terminated.
+    pub fn batch_terminate_sectors(
+        &self,
+        rt: &MockRuntime,
+        sectors: &BitField,
+        max_sectors: Option<u64>,
+        expected_fee: TokenAmount,
+    ) -> BatchTerminateSectorsReturn {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
+        rt.expect_validate_caller_addr(self.caller_addrs());
+
+        let limit = max_sectors.unwrap_or(rt.policy.addressed_sectors_max);
+        let terminated = sectors.slice(0, std::cmp::min(limit, sectors.len())).unwrap();
+        self.expect_terminate_sectors(rt, &terminated, expected_fee);
+        let params = BatchTerminateSectorsParams {
+            terminations: self.make_termination_declarations(rt, sectors),
+            max_sectors,
+        };
+
+        let ret: BatchTerminateSectorsReturn = rt
+            .call::<Actor>(
+                Method::BatchTerminateSectors as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )
+            .unwrap()
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        ret
+    }
+
+    fn expect_terminate_sectors(
+        &self,
+        rt: &MockRuntime,
+        sectors: &BitField,
+        expected_fee: TokenAmount,
+    ) -> (PowerPair, TokenAmount) {
         let mut sector_infos: Vec<SectorOnChainInfo> = Vec::with_capacity(sectors.len() as usize);
         let mut has_active_sector = false;
         for sector in sectors.iter() {
@@ -2525,37 +2762,29 @@ impl ActorHarness {
             expect_update_power(rt, sector_power.clone().neg());
         }
 
-        // create declarations
-        let state: State = rt.get_state();
-        let deadlines = state.load_deadlines(rt.store()).unwrap();
-
-        let mut terminations: Vec<TerminationDeclaration> = Vec::new();
-
         for sector in sectors.iter() {
-            let (deadline, partition) = deadlines.find_sector(rt.store(), sector).unwrap();
-            terminations.push(TerminationDeclaration {
-                sectors: make_bitfield(&[sector]),
-                deadline,
-                partition,
-            });
+            expect_event(rt, "sector-terminated", &sector);
         }
 
-        for termination in terminations.iter() {
-            for sector in termination.sectors.iter() {
-                expect_event(rt, "sector-terminated", &sector);
-            }
-        }
-
-        let params = TerminateSectorsParams { terminations };
+        (sector_power.neg(), pledge_delta)
+    }
 
-        rt.call::<Actor>(
-            Method::TerminateSectors as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        )
-        .unwrap();
-        rt.verify();
+    // Creates one termination declaration per sector.
+    pub fn make_termination_declarations(
+        &self,
+        rt: &MockRuntime,
+        sectors: &BitField,
+    ) -> Vec<TerminationDeclaration> {
+        let state: State = rt.get_state();
+        let deadlines = state.load_deadlines(rt.store()).unwrap();
 
-        (sector_power.neg(), pledge_delta)
+        sectors
+            .iter()
+            .map(|sector| {
+                let (deadline, partition) = deadlines.find_sector(rt.store(), sector).unwrap();
+                TerminationDeclaration { sectors: make_bitfield(&[sector]), deadline, partition }
+            })
+            .collect()
     }
 
     pub fn change_peer_id(&self, rt: &MockRuntime, new_id: Vec<u8>) {
@@ -2613,6 +2842,86 @@ impl ActorHarness {
         Ok(())
     }
 
+    pub fn prepay_fault_fees(
+        &self,
+        rt: &MockRuntime,
+        value: &TokenAmount,
+    ) -> Result<(), ActorError> {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
+        rt.expect_validate_caller_addr(self.caller_addrs());
+
+        rt.add_balance(value.clone());
+        rt.set_received(value.clone());
+        let result = rt.call::<Actor>(Method::PrepayFaultFees as u64, None)?;
+        expect_empty(result);
+        rt.verify();
+        Ok(())
+    }
+
+    pub fn withdraw_fault_fee_escrow(
+        &self,
+        rt: &MockRuntime,
+        amount_requested: &TokenAmount,
+        expected_withdrawn: &TokenAmount,
+    ) -> Result<(), ActorError> {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.owner);
+        rt.expect_validate_caller_addr(vec![self.owner]);
+        if expected_withdrawn.is_positive() {
+            rt.expect_send_simple(
+                self.owner,
+                METHOD_SEND,
+                None,
+                expected_withdrawn.clone(),
+                None,
+                ExitCode::OK,
+            );
+        }
+        let ret: WithdrawBalanceReturn = rt
+            .call::<Actor>(
+                Method::WithdrawFaultFeeEscrow as u64,
+                IpldBlock::serialize_cbor(&WithdrawBalanceParams {
+                    amount_requested: amount_requested.clone(),
+                })
+                .unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        assert_eq!(expected_withdrawn, &ret.amount_withdrawn);
+        Ok(())
+    }
+
+    pub fn repay_debt_from_vesting(
+        &self,
+        rt: &MockRuntime,
+        amount: &TokenAmount,
+        expected_burnt: &TokenAmount,
+    ) -> Result<RepayDebtFromVestingReturn, ActorError> {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
+        rt.expect_validate_caller_addr(self.caller_addrs());
+        expect_update_pledge(rt, &expected_burnt.neg());
+        rt.expect_send_simple(
+            BURNT_FUNDS_ACTOR_ADDR,
+            METHOD_SEND,
+            None,
+            expected_burnt.clone(),
+            None,
+            ExitCode::OK,
+        );
+        let params = RepayDebtFromVestingParams { amount: amount.clone() };
+        let ret = rt
+            .call::<Actor>(
+                Method::RepayDebtFromVesting as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        Ok(ret)
+    }
+
     pub fn withdraw_funds(
         &self,
         rt: &MockRuntime,
@@ -2669,6 +2978,57 @@ impl ActorHarness {
         Ok(())
     }
 
+    pub fn withdraw_funds_to(
+        &self,
+        rt: &MockRuntime,
+        from_address: Address,
+        to: Address,
+        amount_requested: &TokenAmount,
+        expected_withdrawn: &TokenAmount,
+        expected_debt_repaid: &TokenAmount,
+    ) -> Result<(), ActorError> {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, from_address);
+        rt.expect_validate_caller_addr(vec![self.owner, self.beneficiary]);
+
+        if expected_withdrawn.is_positive() {
+            rt.expect_send_simple(
+                to,
+                METHOD_SEND,
+                None,
+                expected_withdrawn.clone(),
+                None,
+                ExitCode::OK,
+            );
+        }
+
+        if expected_debt_repaid.is_positive() {
+            rt.expect_send_simple(
+                BURNT_FUNDS_ACTOR_ADDR,
+                METHOD_SEND,
+                None,
+                expected_debt_repaid.clone(),
+                None,
+                ExitCode::OK,
+            );
+        }
+        let ret = rt
+            .call::<Actor>(
+                Method::WithdrawBalanceTo as u64,
+                IpldBlock::serialize_cbor(&WithdrawBalanceToParams {
+                    to,
+                    amount_requested: amount_requested.clone(),
+                })
+                .unwrap(),
+            )?
+            .unwrap()
+            .deserialize::<WithdrawBalanceReturn>()
+            .unwrap();
+        rt.verify();
+
+        assert_eq!(expected_withdrawn, &ret.amount_withdrawn);
+        Ok(())
+    }
+
     pub fn check_sector_proven(
         &self,
         rt: &MockRuntime,
@@ -2863,14 +3223,57 @@ impl ActorHarness {
     pub fn extend_sectors2(
         &self,
         rt: &MockRuntime,
-        mut params: ExtendSectorExpiration2Params,
+        params: ExtendSectorExpiration2Params,
         expected_claims: HashMap<ClaimID, Result<FILPlusClaim, ActorError>>,
     ) -> Result<Option<IpldBlock>, ActorError> {
+        self.expect_extend_sectors2(rt, &params.extensions, &expected_claims);
+
+        let ret = rt.call::<Actor>(
+            Method::ExtendSectorExpiration2 as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+
+        rt.verify();
+        Ok(ret)
+    }
+
+    // Extends the declarations from params.cursor up to (but excluding) expected_next_cursor.
+    pub fn extend_sectors2_batched(
+        &self,
+        rt: &MockRuntime,
+        params: ExtendSectorExpiration2BatchedParams,
+        expected_claims: HashMap<ClaimID, Result<FILPlusClaim, ActorError>>,
+        expected_next_cursor: u64,
+    ) -> Result<ExtendSectorExpiration2BatchedReturn, ActorError> {
+        let batch = &params.extensions[params.cursor as usize..expected_next_cursor as usize];
+        self.expect_extend_sectors2(rt, batch, &expected_claims);
+
+        let ret = rt
+            .call::<Actor>(
+                Method::ExtendSectorExpiration2Batched as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize::<ExtendSectorExpiration2BatchedReturn>()
+            .unwrap();
+
+        rt.verify();
+        assert_eq!(expected_next_cursor, ret.next_cursor);
+        assert_eq!(expected_next_cursor < params.extensions.len() as u64, ret.partial);
+        Ok(ret)
+    }
+
+    fn expect_extend_sectors2(
+        &self,
+        rt: &MockRuntime,
+        extensions: &[ExpirationExtension2],
+        expected_claims: &HashMap<ClaimID, Result<FILPlusClaim, ActorError>>,
+    ) {
         rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, self.worker);
         rt.expect_validate_caller_addr(self.caller_addrs());
 
         let mut qa_delta = BigInt::zero();
-        for extension in params.extensions.iter_mut() {
+        for extension in extensions {
             for sc in &extension.sectors_with_claims {
                 // construct expected return value
                 let mut claims = Vec::new();
@@ -2910,7 +3313,7 @@ impl ActorHarness {
 
         self.expect_query_network_info(rt);
         // Handle QA power updates
-        for extension in params.extensions.iter_mut() {
+        for extension in extensions {
             for sector_nr in extension.sectors.validate().unwrap().iter() {
                 let sector = self.get_sector(&rt, sector_nr);
                 let mut new_sector = sector.clone();
@@ -2942,14 +3345,6 @@ impl ActorHarness {
         }
 
         expect_update_power(rt, PowerPair::new(BigInt::zero(), qa_delta));
-
-        let ret = rt.call::<Actor>(
-            Method::ExtendSectorExpiration2 as u64,
-            IpldBlock::serialize_cbor(&params).unwrap(),
-        )?;
-
-        rt.verify();
-        Ok(ret)
     }
 
     pub fn compact_partitions(
@@ -3015,6 +3410,25 @@ impl ActorHarness {
         rt.verify();
         Ok(available_balance_ret.available_balance)
     }
+
+    pub fn available_sector_numbers(
+        &self,
+        rt: &MockRuntime,
+        count: u64,
+    ) -> Result<BitField, ActorError> {
+        // set caller to non-builtin
+        rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1234));
+        rt.expect_validate_caller_any();
+        let ret: AvailableSectorNumbersReturn = rt
+            .call::<Actor>(
+                Method::AvailableSectorNumbersExported as u64,
+                IpldBlock::serialize_cbor(&AvailableSectorNumbersParams { count }).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()?;
+        rt.verify();
+        Ok(ret.sector_numbers)
+    }
 }
 
 pub fn expect_sector_event(
@@ -3162,6 +3576,7 @@ pub struct ProveReplicaUpdatesConfig {
     pub claim_failure: Vec<usize>,      // Simulate verified claim failure for these sector indices.
     pub notification_result: Option<ExitCode>, // Result of notification send (default OK).
     pub notification_rejected: bool,    // Whether to reject the notification
+    pub recoveries: Vec<RecoveryDeclaration>, // Recoveries to declare with the updates.
 }
 
 #[derive(Default)]
@@ -1,8 +1,9 @@
 use fil_actor_miner::{
     Actor, BeneficiaryTerm, Method, WithdrawBalanceParams, WithdrawBalanceReturn,
+    WithdrawBalanceToParams,
 };
 use fil_actors_runtime::test_utils::{
-    expect_abort, expect_abort_contains_message, EVM_ACTOR_CODE_ID,
+    expect_abort, expect_abort_contains_message, ACCOUNT_ACTOR_CODE_ID, EVM_ACTOR_CODE_ID,
 };
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_shared::address::Address;
@@ -220,6 +221,52 @@ fn withdraw_fail_when_beneficiary_expired() {
     h.check_state(&rt);
 }
 
+#[test]
+fn owner_withdraw_reverts_expired_beneficiary() {
+    let mut h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    let first_beneficiary_id = Address::new_id(999);
+    let quota = &*ONE_PERCENT_BALANCE;
+    h.propose_approve_initial_beneficiary(
+        &rt,
+        first_beneficiary_id,
+        BeneficiaryTerm::new(quota.clone(), TokenAmount::zero(), PERIOD_OFFSET - 10),
+    )
+    .unwrap();
+
+    // Before expiration, the owner's withdrawal is paid to the beneficiary.
+    let withdraw_amount = TokenAmount::from_atto(10);
+    h.withdraw_funds(&rt, h.owner, &withdraw_amount, &withdraw_amount, &TokenAmount::zero())
+        .unwrap();
+
+    // After expiration, it reverts the beneficiary to the owner and pays the owner.
+    rt.set_epoch(PERIOD_OFFSET - 10);
+    h.beneficiary = h.owner;
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.owner);
+    rt.expect_validate_caller_addr(vec![h.owner, first_beneficiary_id]);
+    rt.expect_send_simple(h.owner, METHOD_SEND, None, quota.clone(), None, ExitCode::OK);
+    let ret: WithdrawBalanceReturn = rt
+        .call::<Actor>(
+            Method::WithdrawBalance as u64,
+            IpldBlock::serialize_cbor(&WithdrawBalanceParams { amount_requested: quota.clone() })
+                .unwrap(),
+        )
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .unwrap();
+    rt.verify();
+    assert_eq!(quota, &ret.amount_withdrawn);
+
+    let info = h.get_info(&rt);
+    assert_eq!(h.owner, info.beneficiary);
+    assert_eq!(BeneficiaryTerm::default(), info.beneficiary_term);
+    h.check_state(&rt);
+}
+
 #[test]
 fn fail_withdraw_from_non_beneficiary() {
     let mut h = ActorHarness::new(PERIOD_OFFSET);
@@ -261,3 +308,109 @@ fn fail_withdraw_from_non_beneficiary() {
     h.withdraw_funds(&rt, first_beneficiary_id, &one, &one, &TokenAmount::zero()).unwrap();
     h.check_state(&rt);
 }
+
+#[test]
+fn owner_withdraws_to_another_address() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    let custody = Address::new_id(5000);
+    h.withdraw_funds_to(
+        &rt,
+        h.owner,
+        custody,
+        &ONE_PERCENT_BALANCE,
+        &ONE_PERCENT_BALANCE,
+        &TokenAmount::zero(),
+    )
+    .unwrap();
+    h.check_state(&rt);
+}
+
+#[test]
+fn withdraw_to_repays_fee_debt_first() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    let mut st = h.get_state(&rt);
+    let fee_debt = &*BIG_BALANCE - &*ONE_PERCENT_BALANCE;
+    st.fee_debt = fee_debt.clone();
+    rt.replace_state(&st);
+
+    let requested = rt.balance.borrow().to_owned();
+    let expected_withdraw = &requested - &fee_debt;
+    h.withdraw_funds_to(
+        &rt,
+        h.owner,
+        Address::new_id(5000),
+        &requested,
+        &expected_withdraw,
+        &fee_debt,
+    )
+    .unwrap();
+    h.check_state(&rt);
+}
+
+#[test]
+fn beneficiary_withdraws_to_another_address_within_quota() {
+    let mut h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    let first_beneficiary_id = Address::new_id(999);
+    let quota = &*ONE_PERCENT_BALANCE;
+    h.propose_approve_initial_beneficiary(
+        &rt,
+        first_beneficiary_id,
+        BeneficiaryTerm::new(quota.clone(), TokenAmount::zero(), PERIOD_OFFSET + 100),
+    )
+    .unwrap();
+
+    let custody = Address::new_id(5000);
+    let withdraw_amount = &*ONE_PERCENT_BALANCE * 2;
+    h.withdraw_funds_to(&rt, h.beneficiary, custody, &withdraw_amount, quota, &TokenAmount::zero())
+        .unwrap();
+
+    // The owner may not redirect funds owed to the beneficiary.
+    expect_abort_contains_message(
+        ExitCode::USR_FORBIDDEN,
+        "may choose the withdrawal destination",
+        h.withdraw_funds_to(
+            &rt,
+            h.owner,
+            custody,
+            &TokenAmount::from_atto(1),
+            &TokenAmount::zero(),
+            &TokenAmount::zero(),
+        ),
+    );
+    rt.reset();
+    h.check_state(&rt);
+}
+
+#[test]
+fn withdraw_to_fails_for_unresolvable_address() {
+    let h = ActorHarness::new(PERIOD_OFFSET);
+    let rt = h.new_runtime();
+    rt.set_balance(BIG_BALANCE.clone());
+    h.construct_and_verify(&rt);
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, h.owner);
+    let params = IpldBlock::serialize_cbor(&WithdrawBalanceToParams {
+        to: Address::new_actor(b"nobody"),
+        amount_requested: ONE_PERCENT_BALANCE.clone(),
+    })
+    .unwrap();
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "unable to resolve withdrawal address",
+        rt.call::<Actor>(Method::WithdrawBalanceTo as u64, params),
+    );
+    rt.verify();
+    h.check_state(&rt);
+}
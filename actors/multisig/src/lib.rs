@@ -8,14 +8,15 @@ use fvm_ipld_blockstore::Blockstore;
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_ipld_encoding::RawBytes;
 use fvm_shared::address::Address;
+use fvm_shared::clock::ChainEpoch;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
 use fvm_shared::MethodNum;
-use fvm_shared::METHOD_CONSTRUCTOR;
+use fvm_shared::{METHOD_CONSTRUCTOR, METHOD_SEND};
 use num_derive::FromPrimitive;
 use num_traits::Zero;
 
-use fil_actors_runtime::cbor::serialize_vec;
+use fil_actors_runtime::cbor::{serialize, serialize_vec};
 use fil_actors_runtime::runtime::{ActorCode, Primitives, Runtime};
 use fil_actors_runtime::FIRST_EXPORTED_METHOD_NUMBER;
 use fil_actors_runtime::{
@@ -46,6 +47,11 @@ pub enum Method {
     SwapSigner = 7,
     ChangeNumApprovalsThreshold = 8,
     LockBalance = 9,
+    SetSignerLimit = 10,
+    ProposeWithExpiration = 11,
+    PurgeExpiredTransactions = 12,
+    ProposeBatch = 13,
+    ExecuteBatch = 14,
     // Method numbers derived from FRC-0042 standards
     UniversalReceiverHook = frc42_dispatch::method_hash!("Receive"),
 }
@@ -98,6 +104,10 @@ impl Actor {
         }
 
         let empty_root = PendingTxnMap::empty(rt.store(), PENDING_TXN_CONFIG, "empty").flush()?;
+        let empty_limits_root =
+            SignerLimitMap::empty(rt.store(), SIGNER_LIMITS_CONFIG, "empty").flush()?;
+        let empty_expirations_root =
+            TxnExpirationMap::empty(rt.store(), TXN_EXPIRATIONS_CONFIG, "empty").flush()?;
 
         let mut st: State = State {
             signers: resolved_signers,
@@ -107,6 +117,8 @@ impl Actor {
             next_tx_id: Default::default(),
             start_epoch: Default::default(),
             unlock_duration: Default::default(),
+            signer_limits: empty_limits_root,
+            txn_expirations: empty_expirations_root,
         };
 
         if params.unlock_duration != 0 {
@@ -124,45 +136,90 @@ impl Actor {
     /// Multisig actor propose function
     pub fn propose(rt: &impl Runtime, params: ProposeParams) -> Result<ProposeReturn, ActorError> {
         rt.validate_immediate_caller_accept_any()?;
-        let proposer: Address = rt.message().caller();
+        let txn = Transaction {
+            to: params.to,
+            value: params.value,
+            method: params.method,
+            params: params.params,
+            approved: Vec::new(),
+        };
+        Self::propose_transaction(rt, txn, None)
+    }
 
-        if params.value.is_negative() {
+    /// Multisig actor propose function for a transaction that can only be approved
+    /// up to and including the expiration epoch.
+    pub fn propose_with_expiration(
+        rt: &impl Runtime,
+        params: ProposeWithExpirationParams,
+    ) -> Result<ProposeReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        if params.expiration_epoch < rt.curr_epoch() {
             return Err(actor_error!(
                 illegal_argument,
-                "proposed value must be non-negative, was {}",
-                params.value
+                "expiration epoch {} must not be before current epoch {}",
+                params.expiration_epoch,
+                rt.curr_epoch()
             ));
         }
+        let txn = Transaction {
+            to: params.to,
+            value: params.value,
+            method: params.method,
+            params: params.params,
+            approved: Vec::new(),
+        };
+        Self::propose_transaction(rt, txn, Some(params.expiration_epoch))
+    }
 
-        let (txn_id, txn) = rt.transaction(|st: &mut State, rt| {
-            if !st.is_signer(&proposer) {
-                return Err(actor_error!(forbidden, "{} is not a signer", proposer));
-            }
-
-            let mut ptx = PendingTxnMap::load(
-                rt.store(),
-                &st.pending_txs,
-                PENDING_TXN_CONFIG,
-                "pending txns",
-            )?;
-            let t_id = st.next_tx_id;
-            st.next_tx_id.0 += 1;
-
-            let txn = Transaction {
-                to: params.to,
-                value: params.value,
-                method: params.method,
-                params: params.params,
-                approved: Vec::new(),
-            };
-
-            ptx.set(&t_id, txn.clone())?;
-            st.pending_txs = ptx.flush()?;
-            Ok((t_id, txn))
-        })?;
+    /// Multisig actor propose function for a batch of sends that is approved and executed
+    /// as a single transaction. The batch is recorded as one pending transaction that invokes
+    /// ExecuteBatch on this actor, so it requires the normal approvals threshold.
+    pub fn propose_batch(
+        rt: &impl Runtime,
+        params: ProposeBatchParams,
+    ) -> Result<ProposeReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let total = validate_batch(&params)?;
+
+        let st: State = rt.state()?;
+        st.check_available(rt.current_balance(), &total, rt.curr_epoch())
+            .context("insufficient balance for batch")?;
+
+        let txn = Transaction {
+            to: rt.message().receiver(),
+            value: TokenAmount::zero(),
+            method: Method::ExecuteBatch as MethodNum,
+            params: serialize(&params, "batch params")?,
+            approved: Vec::new(),
+        };
+        Self::propose_transaction(rt, txn, None)
+    }
 
-        let (applied, ret, code) = Self::approve_transaction(rt, txn_id, txn)?;
-        Ok(ProposeReturn { txn_id, applied, code, ret })
+    /// Multisig actor function to execute an approved batch of sends.
+    /// Any failed send aborts the call, reverting all sends in the batch.
+    pub fn execute_batch(
+        rt: &impl Runtime,
+        params: ProposeBatchParams,
+    ) -> Result<ExecuteBatchReturn, ActorError> {
+        let receiver = rt.message().receiver();
+        rt.validate_immediate_caller_is(std::iter::once(&receiver))?;
+        let total = validate_batch(&params)?;
+
+        let st: State = rt.state()?;
+        st.check_available(rt.current_balance(), &total, rt.curr_epoch())?;
+
+        let mut rets = Vec::with_capacity(params.sends.len());
+        for (i, send) in params.sends.into_iter().enumerate() {
+            let ret = extract_send_result(rt.send_simple(
+                &send.to,
+                send.method,
+                send.params.into(),
+                send.value,
+            ))
+            .with_context(|| format!("batch send {} to {} failed", i, send.to))?;
+            rets.push(ret.map(|r| RawBytes::new(r.data)).unwrap_or_default());
+        }
+        Ok(ExecuteBatchReturn { rets })
     }
 
     /// Multisig actor approve function
@@ -183,13 +240,23 @@ impl Actor {
             )?;
 
             let txn = get_transaction(rt, &ptx, params.id, params.proposal_hash)?;
+            if let Some(expiration) = st.get_txn_expiration(rt.store(), params.id)? {
+                if rt.curr_epoch() > expiration {
+                    return Err(actor_error!(
+                        forbidden,
+                        "transaction {} expired at epoch {}",
+                        params.id,
+                        expiration
+                    ));
+                }
+            }
 
             // Go implementation holds reference to state after transaction so state must be cloned
             // to match to handle possible exit code inconsistency
             Ok((st.clone(), txn.clone()))
         })?;
 
-        let (applied, ret, code) = execute_transaction_if_approved(rt, &st, id, &txn)?;
+        let (applied, ret, code) = execute_transaction_if_approved(rt, &st, id, &txn, false)?;
         if !applied {
             // if the transaction hasn't already been approved, "process" the approval
             // and see if the transaction can be executed
@@ -236,10 +303,28 @@ impl Actor {
             }
 
             st.pending_txs = ptx.flush()?;
-            Ok(())
+            st.remove_txn_expiration(rt.store(), params.id)
         })
     }
 
+    /// Removes all pending transactions that have passed their expiration epoch.
+    /// May be called by any signer.
+    pub fn purge_expired_transactions(
+        rt: &impl Runtime,
+    ) -> Result<PurgeExpiredTransactionsReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let caller_addr: Address = rt.message().caller();
+
+        let purged = rt.transaction(|st: &mut State, rt| {
+            if !st.is_signer(&caller_addr) {
+                return Err(actor_error!(forbidden; "{} is not a signer", caller_addr));
+            }
+            st.purge_expired_txns(rt.store(), rt.curr_epoch())
+        })?;
+
+        Ok(PurgeExpiredTransactionsReturn { purged })
+    }
+
     /// Multisig actor function to add signers to multisig
     pub fn add_signer(rt: &impl Runtime, params: AddSignerParams) -> Result<(), ActorError> {
         let receiver = rt.message().receiver();
@@ -307,6 +392,8 @@ impl Actor {
             // Remove approvals from removed signer
             st.purge_approvals(rt.store(), &Address::new_id(resolved_old_signer))
                 .context("failed to purge approvals of removed signer")?;
+            st.remove_signer_limit(rt.store(), &Address::new_id(resolved_old_signer))
+                .context("failed to remove limit of removed signer")?;
             st.signers.retain(|s| s != &Address::new_id(resolved_old_signer));
 
             Ok(())
@@ -338,6 +425,7 @@ impl Actor {
             st.signers.push(Address::new_id(to_resolved));
 
             st.purge_approvals(rt.store(), &Address::new_id(from_resolved))?;
+            st.remove_signer_limit(rt.store(), &Address::new_id(from_resolved))?;
             Ok(())
         })?;
 
@@ -390,6 +478,81 @@ impl Actor {
         Ok(())
     }
 
+    /// Multisig actor function to set or remove a signer's spending limit
+    pub fn set_signer_limit(
+        rt: &impl Runtime,
+        params: SetSignerLimitParams,
+    ) -> Result<(), ActorError> {
+        let receiver = rt.message().receiver();
+        rt.validate_immediate_caller_is(std::iter::once(&receiver))?;
+
+        if params.limit.is_negative() {
+            return Err(actor_error!(illegal_argument, "signer limit must be non-negative"));
+        }
+
+        if !params.limit.is_zero() && params.period <= 0 {
+            return Err(actor_error!(illegal_argument, "signer limit period must be positive"));
+        }
+
+        let resolved_signer = resolve_to_actor_id(rt, &params.signer, false)?;
+
+        rt.transaction(|st: &mut State, rt| {
+            if !st.is_signer(&Address::new_id(resolved_signer)) {
+                return Err(actor_error!(forbidden, "{} is not a signer", resolved_signer));
+            }
+            st.set_signer_limit(
+                rt.store(),
+                &Address::new_id(resolved_signer),
+                params.limit,
+                params.period,
+                rt.curr_epoch(),
+            )
+        })?;
+
+        Ok(())
+    }
+
+    fn propose_transaction(
+        rt: &impl Runtime,
+        txn: Transaction,
+        expiration: Option<ChainEpoch>,
+    ) -> Result<ProposeReturn, ActorError> {
+        let proposer: Address = rt.message().caller();
+
+        if txn.value.is_negative() {
+            return Err(actor_error!(
+                illegal_argument,
+                "proposed value must be non-negative, was {}",
+                txn.value
+            ));
+        }
+
+        let txn_id = rt.transaction(|st: &mut State, rt| {
+            if !st.is_signer(&proposer) {
+                return Err(actor_error!(forbidden, "{} is not a signer", proposer));
+            }
+
+            let mut ptx = PendingTxnMap::load(
+                rt.store(),
+                &st.pending_txs,
+                PENDING_TXN_CONFIG,
+                "pending txns",
+            )?;
+            let t_id = st.next_tx_id;
+            st.next_tx_id.0 += 1;
+
+            ptx.set(&t_id, txn.clone())?;
+            st.pending_txs = ptx.flush()?;
+            if let Some(expiration) = expiration {
+                st.set_txn_expiration(rt.store(), t_id, expiration)?;
+            }
+            Ok(t_id)
+        })?;
+
+        let (applied, ret, code) = Self::approve_transaction(rt, txn_id, txn)?;
+        Ok(ProposeReturn { txn_id, applied, code, ret })
+    }
+
     fn approve_transaction(
         rt: &impl Runtime,
         tx_id: TxnID,
@@ -405,7 +568,7 @@ impl Actor {
             }
         }
 
-        let st = rt.transaction(|st: &mut State, rt| {
+        let (st, within_limit) = rt.transaction(|st: &mut State, rt| {
             let mut ptx = PendingTxnMap::load(
                 rt.store(),
                 &st.pending_txs,
@@ -419,12 +582,25 @@ impl Actor {
             ptx.set(&tx_id, txn.clone())?;
             st.pending_txs = ptx.flush()?;
 
+            // A plain value transfer short of the threshold is approved if it is within
+            // the approver's remaining spending limit. The spend is recorded only if the
+            // transfer succeeds.
+            let within_limit = (txn.approved.len() as u64) < st.num_approvals_threshold
+                && txn.method == METHOD_SEND
+                && txn.value.is_positive()
+                && st.signer_limit_allows(
+                    rt.store(),
+                    &rt.message().caller(),
+                    &txn.value,
+                    rt.curr_epoch(),
+                )?;
+
             // Go implementation holds reference to state after transaction so this must be cloned
             // to match to handle possible exit code inconsistency
-            Ok(st.clone())
+            Ok((st.clone(), within_limit))
         })?;
 
-        execute_transaction_if_approved(rt, &st, tx_id, &txn)
+        execute_transaction_if_approved(rt, &st, tx_id, &txn, within_limit)
     }
 
     // Always succeeds, accepting any transfers, so long as the params are valid `UniversalReceiverParams`.
@@ -450,17 +626,21 @@ impl Actor {
     }
 }
 
+// Executes the transaction if it has met the approvals threshold, or if
+// it has been approved within a signer's spending limit.
+// A successful transfer approved within a limit is recorded against the last approver's limit.
 fn execute_transaction_if_approved(
     rt: &impl Runtime,
     st: &State,
     txn_id: TxnID,
     txn: &Transaction,
+    within_limit: bool,
 ) -> Result<(bool, RawBytes, ExitCode), ActorError> {
     let mut out = RawBytes::default();
     let mut code = ExitCode::OK;
     let mut applied = false;
     let threshold_met = txn.approved.len() as u64 >= st.num_approvals_threshold;
-    if threshold_met {
+    if threshold_met || within_limit {
         st.check_available(rt.current_balance(), &txn.value, rt.curr_epoch())?;
 
         match extract_send_result(rt.send_simple(
@@ -492,13 +672,38 @@ fn execute_transaction_if_approved(
             )?;
             ptx.delete(&txn_id)?;
             st.pending_txs = ptx.flush()?;
-            Ok(())
+            if within_limit && code.is_success() {
+                if let Some(approver) = txn.approved.last() {
+                    st.record_signer_spend(rt.store(), approver, &txn.value, rt.curr_epoch())?;
+                }
+            }
+            st.remove_txn_expiration(rt.store(), txn_id)
         })?;
     }
 
     Ok((applied, out, code))
 }
 
+// Checks a batch of sends is well formed, returning the total value to be sent.
+fn validate_batch(params: &ProposeBatchParams) -> Result<TokenAmount, ActorError> {
+    if params.sends.is_empty() {
+        return Err(actor_error!(illegal_argument, "batch must contain at least one send"));
+    }
+    let mut total = TokenAmount::zero();
+    for (i, send) in params.sends.iter().enumerate() {
+        if send.value.is_negative() {
+            return Err(actor_error!(
+                illegal_argument,
+                "batch send {} value must be non-negative, was {}",
+                i,
+                send.value
+            ));
+        }
+        total += &send.value;
+    }
+    Ok(total)
+}
+
 fn get_transaction<'m, BS, RT>(
     rt: &RT,
     ptx: &'m PendingTxnMap<BS>,
@@ -561,6 +766,11 @@ impl ActorCode for Actor {
       SwapSigner => swap_signer,
       ChangeNumApprovalsThreshold => change_num_approvals_threshold,
       LockBalance => lock_balance,
+      SetSignerLimit => set_signer_limit,
+      ProposeWithExpiration => propose_with_expiration,
+      PurgeExpiredTransactions => purge_expired_transactions,
+      ProposeBatch => propose_batch,
+      ExecuteBatch => execute_batch,
       UniversalReceiverHook => universal_receiver_hook,
       _ => fallback,
     }
@@ -14,12 +14,18 @@ use num_traits::Zero;
 
 use fil_actors_runtime::{actor_error, ActorError, Config, Map2, DEFAULT_HAMT_CONFIG};
 
-use super::types::Transaction;
+use super::types::{SignerLimit, Transaction};
 use super::TxnID;
 
 pub type PendingTxnMap<BS> = Map2<BS, TxnID, Transaction>;
 pub const PENDING_TXN_CONFIG: Config = DEFAULT_HAMT_CONFIG;
 
+pub type SignerLimitMap<BS> = Map2<BS, Address, SignerLimit>;
+pub const SIGNER_LIMITS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
+
+pub type TxnExpirationMap<BS> = Map2<BS, TxnID, ChainEpoch>;
+pub const TXN_EXPIRATIONS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
+
 /// Multisig actor state
 #[derive(Serialize_tuple, Deserialize_tuple, Clone, Debug)]
 pub struct State {
@@ -33,6 +39,11 @@ pub struct State {
     pub unlock_duration: ChainEpoch,
 
     pub pending_txs: Cid,
+    // Spending limits of signers, keyed by signer address.
+    pub signer_limits: Cid,
+    // Last epoch at which each expiring pending transaction may be approved, keyed by
+    // transaction ID. Transactions without an entry never expire.
+    pub txn_expirations: Cid,
 }
 
 impl State {
@@ -71,6 +82,203 @@ impl State {
         TokenAmount::from_atto(numerator.atto().div_ceil(&denominator))
     }
 
+    /// Loads the signer limits.
+    pub fn load_signer_limits<BS: Blockstore>(
+        &self,
+        store: BS,
+    ) -> Result<SignerLimitMap<BS>, ActorError> {
+        SignerLimitMap::load(store, &self.signer_limits, SIGNER_LIMITS_CONFIG, "signer limits")
+    }
+
+    /// Loads the transaction expirations.
+    pub fn load_txn_expirations<BS: Blockstore>(
+        &self,
+        store: BS,
+    ) -> Result<TxnExpirationMap<BS>, ActorError> {
+        TxnExpirationMap::load(
+            store,
+            &self.txn_expirations,
+            TXN_EXPIRATIONS_CONFIG,
+            "txn expirations",
+        )
+    }
+
+    /// Returns the start of the signer limit period containing `curr_epoch`.
+    /// Limit periods are aligned to the start epoch of the linear unlock.
+    pub fn limit_period_start(&self, period: ChainEpoch, curr_epoch: ChainEpoch) -> ChainEpoch {
+        self.start_epoch + (curr_epoch - self.start_epoch).div_floor(&period) * period
+    }
+
+    /// Sets the spending limit of a signer, allowing it to spend up to `limit` within each
+    /// period of `period` epochs. A zero limit removes any limit for the signer.
+    pub fn set_signer_limit<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        signer: &Address,
+        limit: TokenAmount,
+        period: ChainEpoch,
+        curr_epoch: ChainEpoch,
+    ) -> Result<(), ActorError> {
+        let mut limits = self.load_signer_limits(store)?;
+        if limit.is_zero() {
+            limits.delete(signer)?;
+        } else {
+            let period_start = self.limit_period_start(period, curr_epoch);
+            limits.set(
+                signer,
+                SignerLimit { limit, period, period_start, spent: TokenAmount::zero() },
+            )?;
+        }
+        self.signer_limits = limits.flush()?;
+        Ok(())
+    }
+
+    /// Removes any spending limit of a signer.
+    pub fn remove_signer_limit<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        signer: &Address,
+    ) -> Result<(), ActorError> {
+        let mut limits = self.load_signer_limits(store)?;
+        if limits.delete(signer)?.is_some() {
+            self.signer_limits = limits.flush()?;
+        }
+        Ok(())
+    }
+
+    /// Returns whether a signer has a limit with sufficient remaining allowance in the current
+    /// period to spend `amount`.
+    pub fn signer_limit_allows<BS: Blockstore>(
+        &self,
+        store: &BS,
+        signer: &Address,
+        amount: &TokenAmount,
+        curr_epoch: ChainEpoch,
+    ) -> Result<bool, ActorError> {
+        let limits = self.load_signer_limits(store)?;
+        Ok(match self.current_signer_limit(&limits, signer, curr_epoch)? {
+            Some(limit) => &limit.spent + amount <= limit.limit,
+            None => false,
+        })
+    }
+
+    /// Records a spend of `amount` against a signer's limit in the current period.
+    /// The spent amount is reset at the start of each limit period.
+    /// Does nothing if the signer has no limit.
+    pub fn record_signer_spend<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        signer: &Address,
+        amount: &TokenAmount,
+        curr_epoch: ChainEpoch,
+    ) -> Result<(), ActorError> {
+        let mut limits = self.load_signer_limits(store)?;
+        let mut limit = match self.current_signer_limit(&limits, signer, curr_epoch)? {
+            Some(limit) => limit,
+            None => return Ok(()),
+        };
+
+        limit.spent = &limit.spent + amount;
+        if limit.spent > limit.limit {
+            return Err(actor_error!(
+                forbidden,
+                "spend of {} by {} exceeds its remaining limit",
+                amount,
+                signer
+            ));
+        }
+
+        limits.set(signer, limit)?;
+        self.signer_limits = limits.flush()?;
+        Ok(())
+    }
+
+    // Returns a signer's limit, with the spent amount reset if a new period has started.
+    fn current_signer_limit<BS: Blockstore>(
+        &self,
+        limits: &SignerLimitMap<BS>,
+        signer: &Address,
+        curr_epoch: ChainEpoch,
+    ) -> Result<Option<SignerLimit>, ActorError> {
+        let mut limit = match limits.get(signer)? {
+            Some(limit) => limit.clone(),
+            None => return Ok(None),
+        };
+        let period_start = self.limit_period_start(limit.period, curr_epoch);
+        if period_start != limit.period_start {
+            limit.period_start = period_start;
+            limit.spent = TokenAmount::zero();
+        }
+        Ok(Some(limit))
+    }
+
+    /// Returns the expiration epoch of a pending transaction, if it has one.
+    pub fn get_txn_expiration<BS: Blockstore>(
+        &self,
+        store: &BS,
+        txn_id: TxnID,
+    ) -> Result<Option<ChainEpoch>, ActorError> {
+        let expirations = self.load_txn_expirations(store)?;
+        Ok(expirations.get(&txn_id)?.copied())
+    }
+
+    /// Records the last epoch at which a pending transaction may be approved.
+    pub fn set_txn_expiration<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        txn_id: TxnID,
+        expiration: ChainEpoch,
+    ) -> Result<(), ActorError> {
+        let mut expirations = self.load_txn_expirations(store)?;
+        expirations.set(&txn_id, expiration)?;
+        self.txn_expirations = expirations.flush()?;
+        Ok(())
+    }
+
+    /// Removes any expiration of a transaction that is no longer pending.
+    pub fn remove_txn_expiration<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        txn_id: TxnID,
+    ) -> Result<(), ActorError> {
+        let mut expirations = self.load_txn_expirations(store)?;
+        if expirations.delete(&txn_id)?.is_some() {
+            self.txn_expirations = expirations.flush()?;
+        }
+        Ok(())
+    }
+
+    /// Removes all pending transactions whose expiration epoch is before `curr_epoch`.
+    /// Returns the IDs of the removed transactions, in ascending order.
+    pub fn purge_expired_txns<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        curr_epoch: ChainEpoch,
+    ) -> Result<Vec<TxnID>, ActorError> {
+        let mut expirations = self.load_txn_expirations(store)?;
+        let mut expired = Vec::new();
+        expirations.for_each(|txn_id, expiration| {
+            if *expiration < curr_epoch {
+                expired.push(txn_id);
+            }
+            Ok(())
+        })?;
+        if expired.is_empty() {
+            return Ok(expired);
+        }
+        expired.sort_by_key(|id| id.0);
+
+        let mut txns =
+            PendingTxnMap::load(store, &self.pending_txs, PENDING_TXN_CONFIG, "pending txns")?;
+        for txn_id in &expired {
+            txns.delete(txn_id)?;
+            expirations.delete(txn_id)?;
+        }
+        self.pending_txs = txns.flush()?;
+        self.txn_expirations = expirations.flush()?;
+        Ok(expired)
+    }
+
     /// Iterates all pending transactions and removes an address from each list of approvals,
     /// if present.  If an approval list becomes empty, the pending transaction is deleted.
     pub fn purge_approvals<BS: Blockstore>(
@@ -100,6 +308,7 @@ impl State {
                 txns.set(&tx_id, txn)?;
             } else {
                 txns.delete(&tx_id)?;
+                self.remove_txn_expiration(store, tx_id)?;
             }
         }
 
@@ -52,6 +52,7 @@ pub fn check_state_invariants<BS: Blockstore>(
     // test pending transactions
     let mut max_tx_id = TxnID(-1);
     let mut pending_tx_count = 0u64;
+    let mut pending_tx_ids = HashSet::<TxnID>::new();
 
     match PendingTxnMap::load(store, &state.pending_txs, PENDING_TXN_CONFIG, "pending txns") {
         Ok(transactions) => {
@@ -80,6 +81,7 @@ pub fn check_state_invariants<BS: Blockstore>(
                     seen_approvals.len(), state.num_approvals_threshold));
 
                 pending_tx_count += 1;
+                pending_tx_ids.insert(tx_id);
 
                 Ok(())
             });
@@ -89,6 +91,46 @@ pub fn check_state_invariants<BS: Blockstore>(
         Err(e) => acc.add(format!("error loading transactions: {e}")),
     };
 
+    // test signer limits
+    match state.load_signer_limits(store) {
+        Ok(limits) => {
+            let ret = limits.for_each(|signer, limit| {
+                acc.require(
+                    signers.contains(&signer),
+                    format!("limit for {signer} is not in signers list"),
+                );
+                acc.require(
+                    limit.period > 0,
+                    format!("non-positive limit period {} for {signer}", limit.period),
+                );
+                acc.require(
+                    limit.spent <= limit.limit,
+                    format!("spent {} exceeds limit {} for {signer}", limit.spent, limit.limit),
+                );
+                Ok(())
+            });
+
+            acc.require_no_error(ret, "error iterating signer limits");
+        }
+        Err(e) => acc.add(format!("error loading signer limits: {e}")),
+    };
+
+    // test transaction expirations
+    match state.load_txn_expirations(store) {
+        Ok(expirations) => {
+            let ret = expirations.for_each(|tx_id, _| {
+                acc.require(
+                    pending_tx_ids.contains(&tx_id),
+                    format!("expiration for transaction {tx_id} which is not pending"),
+                );
+                Ok(())
+            });
+
+            acc.require_no_error(ret, "error iterating transaction expirations");
+        }
+        Err(e) => acc.add(format!("error loading transaction expirations: {e}")),
+    };
+
     acc.require(
         state.next_tx_id > max_tx_id,
         format!("next transaction id {} is not greater than pending ids", state.next_tx_id),
@@ -50,6 +50,21 @@ pub struct Transaction {
     pub approved: Vec<Address>,
 }
 
+/// Spending limit of a single signer.
+/// Plain value transfers proposed or approved by the signer are executed without reaching
+/// the approvals threshold, so long as the value is within the remaining limit for the period.
+#[derive(Clone, PartialEq, Eq, Debug, Serialize_tuple, Deserialize_tuple)]
+pub struct SignerLimit {
+    /// Maximum value that may be spent within a single period.
+    pub limit: TokenAmount,
+    /// Length of each limit period, in epochs.
+    pub period: ChainEpoch,
+    /// Start epoch of the period in which `spent` was accumulated.
+    pub period_start: ChainEpoch,
+    /// Value spent so far in the period.
+    pub spent: TokenAmount,
+}
+
 /// Data for a BLAKE2B-256 to be attached to methods referencing proposals via TXIDs.
 /// Ensures the existence of a cryptographic reference to the original proposal. Useful
 /// for offline signers and for protection when reorgs change a multisig TXID.
@@ -84,6 +99,33 @@ pub struct ProposeParams {
     pub params: RawBytes,
 }
 
+/// ProposeWithExpiration method call parameters.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct ProposeWithExpirationParams {
+    pub to: Address,
+    pub value: TokenAmount,
+    pub method: MethodNum,
+    pub params: RawBytes,
+    /// Last epoch at which the transaction may be approved.
+    pub expiration_epoch: ChainEpoch,
+}
+
+/// ProposeBatch and ExecuteBatch method call parameters.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ProposeBatchParams {
+    /// Sends to execute, in order, once the batch is approved.
+    pub sends: Vec<ProposeParams>,
+}
+
+/// ExecuteBatch method call return.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ExecuteBatchReturn {
+    /// Return values of each send in the batch, in order.
+    pub rets: Vec<RawBytes>,
+}
+
 /// Propose method call return.
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct ProposeReturn {
@@ -122,6 +164,14 @@ pub struct ApproveReturn {
     pub ret: RawBytes,
 }
 
+/// PurgeExpiredTransactions method call return.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct PurgeExpiredTransactionsReturn {
+    /// IDs of the expired transactions that were removed.
+    pub purged: Vec<TxnID>,
+}
+
 /// Add signer params.
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct AddSignerParams {
@@ -149,6 +199,16 @@ pub struct ChangeNumApprovalsThresholdParams {
     pub new_threshold: u64,
 }
 
+/// Set signer limit params.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct SetSignerLimitParams {
+    pub signer: Address,
+    /// Maximum value the signer may spend per period, or zero to remove the limit.
+    pub limit: TokenAmount,
+    /// Length of each limit period, in epochs.
+    pub period: ChainEpoch,
+}
+
 /// Lock balance call params.
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct LockBalanceParams {
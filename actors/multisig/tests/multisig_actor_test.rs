@@ -2427,6 +2427,196 @@ mod lock_balance_tests {
     }
 }
 
+mod signer_limit_tests {
+    use super::*;
+
+    #[test]
+    fn send_within_limit_executes_with_single_approval() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_balance(TokenAmount::from_atto(1000));
+
+        let period = 100;
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        h.set_signer_limit(&rt, anne, TokenAmount::from_atto(100), period).unwrap();
+
+        // A send within the limit executes immediately.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(60),
+            None,
+            ExitCode::OK,
+        );
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(60), METHOD_SEND, RawBytes::default());
+        h.assert_transactions(&rt, vec![]);
+
+        // A send exceeding the remaining limit requires the threshold.
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(60), METHOD_SEND, RawBytes::default());
+        h.assert_transactions(
+            &rt,
+            vec![(
+                TxnID(1),
+                Transaction {
+                    to: chuck,
+                    value: TokenAmount::from_atto(60),
+                    method: METHOD_SEND,
+                    params: RawBytes::default(),
+                    approved: vec![anne],
+                },
+            )],
+        );
+
+        // Calls to other methods are never executed within the limit.
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(10), 42, RawBytes::default());
+        assert_eq!(2, h.pending_transaction_count(&rt));
+
+        // The limit refills in the next period.
+        rt.set_epoch(period);
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(100),
+            None,
+            ExitCode::OK,
+        );
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(100), METHOD_SEND, RawBytes::default());
+        assert_eq!(2, h.pending_transaction_count(&rt));
+
+        // Bob has no limit.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(1), METHOD_SEND, RawBytes::default());
+        assert_eq!(3, h.pending_transaction_count(&rt));
+        check_state(&rt);
+    }
+
+    #[test]
+    fn approval_within_limit_executes() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+        let darlene = Address::new_id(104);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 3, 0, 0, vec![anne, bob, chuck]);
+        rt.set_balance(TokenAmount::from_atto(1000));
+
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        h.set_signer_limit(&rt, bob, TokenAmount::from_atto(100), 100).unwrap();
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        let send_value = TokenAmount::from_atto(50);
+        let proposal_hash =
+            h.propose_ok(&rt, darlene, send_value.clone(), METHOD_SEND, RawBytes::default());
+
+        // Bob's approval executes the transaction within his limit.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        rt.expect_send_simple(darlene, METHOD_SEND, None, send_value, None, ExitCode::OK);
+        h.approve_ok(&rt, TxnID(0), proposal_hash);
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn failed_send_does_not_use_limit() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_balance(TokenAmount::from_atto(1000));
+
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        h.set_signer_limit(&rt, anne, TokenAmount::from_atto(100), 100).unwrap();
+
+        // A failed send within the limit is applied, but isn't recorded against the limit.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(60),
+            None,
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        let ret = h
+            .propose(&rt, chuck, TokenAmount::from_atto(60), METHOD_SEND, RawBytes::default())
+            .unwrap()
+            .unwrap()
+            .deserialize::<ProposeReturn>()
+            .unwrap();
+        assert!(ret.applied);
+        assert_eq!(ExitCode::USR_ILLEGAL_ARGUMENT, ret.code);
+        h.assert_transactions(&rt, vec![]);
+
+        // So the full limit remains available.
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(60),
+            None,
+            ExitCode::OK,
+        );
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(60), METHOD_SEND, RawBytes::default());
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn limit_removed_with_signer() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 1, 0, 0, vec![anne, bob]);
+
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        h.set_signer_limit(&rt, bob, TokenAmount::from_atto(100), 100).unwrap();
+        h.remove_signer(&rt, bob, false).unwrap();
+        check_state(&rt);
+
+        // A limit can't be set for a non-signer.
+        expect_abort(
+            ExitCode::USR_FORBIDDEN,
+            h.set_signer_limit(&rt, bob, TokenAmount::from_atto(100), 100),
+        );
+        rt.reset();
+
+        // A limit must have a positive period.
+        expect_abort(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            h.set_signer_limit(&rt, anne, TokenAmount::from_atto(100), 0),
+        );
+        rt.reset();
+
+        // Only the multisig itself can set limits.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        expect_abort(
+            ExitCode::USR_FORBIDDEN,
+            h.set_signer_limit(&rt, anne, TokenAmount::from_atto(100), 100),
+        );
+        rt.reset();
+        check_state(&rt);
+    }
+}
+
 #[test]
 fn token_receiver() {
     let msig = Address::new_id(1000);
@@ -2485,3 +2675,404 @@ fn test_fallback() {
 
     rt.verify();
 }
+
+mod expiration_tests {
+    use super::*;
+
+    fn propose_expiring(
+        rt: &MockRuntime,
+        h: &util::ActorHarness,
+        to: Address,
+        value: TokenAmount,
+        expiration_epoch: ChainEpoch,
+    ) -> [u8; 32] {
+        let ret = h
+            .propose_with_expiration(
+                rt,
+                to,
+                value.clone(),
+                METHOD_SEND,
+                RawBytes::default(),
+                expiration_epoch,
+            )
+            .unwrap();
+        ret.unwrap().deserialize::<ProposeReturn>().unwrap();
+        let txn = Transaction {
+            to,
+            value,
+            method: METHOD_SEND,
+            params: RawBytes::default(),
+            approved: vec![*rt.caller.borrow()],
+        };
+        compute_proposal_hash(&txn, rt).unwrap()
+    }
+
+    #[test]
+    fn approve_before_expiration() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_balance(TokenAmount::from_atto(100));
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        let send_value = TokenAmount::from_atto(10);
+        let proposal_hash = propose_expiring(&rt, &h, chuck, send_value.clone(), 10);
+        check_state(&rt);
+
+        // Approval is still accepted at the expiration epoch.
+        rt.set_epoch(10);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        rt.expect_send_simple(chuck, METHOD_SEND, None, send_value, None, ExitCode::OK);
+        h.approve_ok(&rt, TxnID(0), proposal_hash);
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn approve_after_expiration_fails() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_balance(TokenAmount::from_atto(100));
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        let proposal_hash = propose_expiring(&rt, &h, chuck, TokenAmount::from_atto(10), 10);
+
+        rt.set_epoch(11);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        expect_abort(ExitCode::USR_FORBIDDEN, h.approve(&rt, TxnID(0), proposal_hash));
+        rt.reset();
+        assert_eq!(1, h.pending_transaction_count(&rt));
+        check_state(&rt);
+    }
+
+    #[test]
+    fn reject_expiration_in_the_past() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+
+        rt.set_epoch(10);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        expect_abort(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            h.propose_with_expiration(
+                &rt,
+                chuck,
+                TokenAmount::from_atto(10),
+                METHOD_SEND,
+                RawBytes::default(),
+                9,
+            ),
+        );
+        rt.reset();
+        assert_eq!(0, h.pending_transaction_count(&rt));
+        check_state(&rt);
+    }
+
+    #[test]
+    fn purge_expired_transactions() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        propose_expiring(&rt, &h, chuck, TokenAmount::from_atto(1), 10);
+        propose_expiring(&rt, &h, chuck, TokenAmount::from_atto(2), 20);
+        // A transaction proposed without an expiration never expires.
+        h.propose_ok(&rt, chuck, TokenAmount::from_atto(3), METHOD_SEND, RawBytes::default());
+        propose_expiring(&rt, &h, chuck, TokenAmount::from_atto(4), 5);
+        check_state(&rt);
+
+        // Nothing has expired yet.
+        rt.set_epoch(5);
+        let ret = h.purge_expired_transactions(&rt).unwrap();
+        assert!(ret.purged.is_empty());
+        assert_eq!(4, h.pending_transaction_count(&rt));
+
+        rt.set_epoch(11);
+        let ret = h.purge_expired_transactions(&rt).unwrap();
+        assert_eq!(vec![TxnID(0), TxnID(3)], ret.purged);
+        assert_eq!(2, h.pending_transaction_count(&rt));
+        check_state(&rt);
+
+        rt.set_epoch(1000);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        let ret = h.purge_expired_transactions(&rt).unwrap();
+        assert_eq!(vec![TxnID(1)], ret.purged);
+        h.assert_transactions(
+            &rt,
+            vec![(
+                TxnID(2),
+                Transaction {
+                    to: chuck,
+                    value: TokenAmount::from_atto(3),
+                    method: METHOD_SEND,
+                    params: RawBytes::default(),
+                    approved: vec![anne],
+                },
+            )],
+        );
+        check_state(&rt);
+
+        // Only signers may purge.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, chuck);
+        expect_abort(ExitCode::USR_FORBIDDEN, h.purge_expired_transactions(&rt));
+        rt.reset();
+        check_state(&rt);
+    }
+
+    #[test]
+    fn cancel_removes_expiration() {
+        let msig = Address::new_id(100);
+        let anne = Address::new_id(101);
+        let bob = Address::new_id(102);
+        let chuck = Address::new_id(103);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        let proposal_hash = propose_expiring(&rt, &h, chuck, TokenAmount::from_atto(1), 10);
+        h.cancel(&rt, TxnID(0), proposal_hash).unwrap();
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+
+        let st: State = rt.get_state();
+        assert_eq!(None, st.get_txn_expiration(&rt.store, TxnID(0)).unwrap());
+    }
+}
+
+mod batch_tests {
+    use super::*;
+    use fil_actor_multisig::{ProposeBatchParams, ProposeParams};
+
+    fn batch_sends(chuck: Address, darlene: Address) -> Vec<ProposeParams> {
+        vec![
+            ProposeParams {
+                to: chuck,
+                value: TokenAmount::from_atto(30),
+                method: METHOD_SEND,
+                params: RawBytes::default(),
+            },
+            ProposeParams {
+                to: darlene,
+                value: TokenAmount::from_atto(40),
+                method: 42,
+                params: RawBytes::from(vec![1u8, 2u8]),
+            },
+        ]
+    }
+
+    #[test]
+    fn batch_is_one_transaction_executed_on_threshold() {
+        let msig = Address::new_id(TEST_MSIG_ADDR);
+        let anne = Address::new_id(TEST_ANNE_ADDR);
+        let bob = Address::new_id(TEST_BOB_ADDR);
+        let chuck = Address::new_id(TEST_CHUCK_ADDR);
+        let darlene = Address::new_id(TEST_DARLENE_ADDR);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_balance(TokenAmount::from_atto(100));
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        let ret = h
+            .propose_batch(&rt, batch_sends(chuck, darlene))
+            .unwrap()
+            .unwrap()
+            .deserialize::<ProposeReturn>()
+            .unwrap();
+        assert!(!ret.applied);
+
+        // The whole batch is pending as a single transaction invoking ExecuteBatch.
+        let batch_params =
+            serialize(&ProposeBatchParams { sends: batch_sends(chuck, darlene) }, "batch").unwrap();
+        let txn = Transaction {
+            to: msig,
+            value: TokenAmount::zero(),
+            method: Method::ExecuteBatch as MethodNum,
+            params: batch_params.clone(),
+            approved: vec![anne],
+        };
+        h.assert_transactions(&rt, vec![(ret.txn_id, txn.clone())]);
+
+        // Final approval executes the batch through a send to the multisig itself.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, bob);
+        rt.expect_send_simple(
+            msig,
+            Method::ExecuteBatch as MethodNum,
+            to_ipld_block(batch_params),
+            TokenAmount::zero(),
+            None,
+            ExitCode::OK,
+        );
+        let proposal_hash = compute_proposal_hash(&txn, &rt).unwrap();
+        h.approve_ok(&rt, ret.txn_id, proposal_hash);
+        h.assert_transactions(&rt, vec![]);
+
+        // Executing the batch performs every send in order.
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(30),
+            None,
+            ExitCode::OK,
+        );
+        rt.expect_send_simple(
+            darlene,
+            42,
+            to_ipld_block(RawBytes::from(vec![1u8, 2u8])),
+            TokenAmount::from_atto(40),
+            to_ipld_block(RawBytes::from(vec![3u8])),
+            ExitCode::OK,
+        );
+        let exec_ret = h.execute_batch(&rt, batch_sends(chuck, darlene)).unwrap();
+        assert_eq!(vec![RawBytes::default(), RawBytes::from(vec![3u8])], exec_ret.rets);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn failed_batch_reports_exit_code_and_is_removed() {
+        let msig = Address::new_id(TEST_MSIG_ADDR);
+        let anne = Address::new_id(TEST_ANNE_ADDR);
+        let chuck = Address::new_id(TEST_CHUCK_ADDR);
+        let darlene = Address::new_id(TEST_DARLENE_ADDR);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 1, 0, 0, vec![anne]);
+        rt.set_balance(TokenAmount::from_atto(100));
+
+        let batch_params =
+            serialize(&ProposeBatchParams { sends: batch_sends(chuck, darlene) }, "batch").unwrap();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        rt.expect_send_simple(
+            msig,
+            Method::ExecuteBatch as MethodNum,
+            to_ipld_block(batch_params),
+            TokenAmount::zero(),
+            None,
+            ExitCode::USR_FORBIDDEN,
+        );
+        let ret = h
+            .propose_batch(&rt, batch_sends(chuck, darlene))
+            .unwrap()
+            .unwrap()
+            .deserialize::<ProposeReturn>()
+            .unwrap();
+        assert!(ret.applied);
+        assert_eq!(ExitCode::USR_FORBIDDEN, ret.code);
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn execute_batch_aborts_on_failed_send() {
+        let msig = Address::new_id(TEST_MSIG_ADDR);
+        let anne = Address::new_id(TEST_ANNE_ADDR);
+        let chuck = Address::new_id(TEST_CHUCK_ADDR);
+        let darlene = Address::new_id(TEST_DARLENE_ADDR);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 1, 0, 0, vec![anne]);
+        rt.set_balance(TokenAmount::from_atto(100));
+
+        rt.set_caller(*MULTISIG_ACTOR_CODE_ID, msig);
+        rt.expect_send_simple(
+            chuck,
+            METHOD_SEND,
+            None,
+            TokenAmount::from_atto(30),
+            None,
+            ExitCode::OK,
+        );
+        rt.expect_send_simple(
+            darlene,
+            42,
+            to_ipld_block(RawBytes::from(vec![1u8, 2u8])),
+            TokenAmount::from_atto(40),
+            None,
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "batch send 1",
+            h.execute_batch(&rt, batch_sends(chuck, darlene)),
+        );
+        rt.reset();
+
+        // Only the multisig itself may execute a batch.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+        expect_abort(ExitCode::USR_FORBIDDEN, h.execute_batch(&rt, batch_sends(chuck, darlene)));
+        check_state(&rt);
+    }
+
+    #[test]
+    fn propose_batch_rejects_invalid_batches() {
+        let msig = Address::new_id(TEST_MSIG_ADDR);
+        let anne = Address::new_id(TEST_ANNE_ADDR);
+        let bob = Address::new_id(TEST_BOB_ADDR);
+        let chuck = Address::new_id(TEST_CHUCK_ADDR);
+        let darlene = Address::new_id(TEST_DARLENE_ADDR);
+
+        let rt = construct_runtime(msig);
+        let h = util::ActorHarness::new();
+        h.construct_and_verify(&rt, 2, 0, 0, vec![anne, bob]);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, anne);
+
+        // Balance is insufficient for the batch total, though enough for each send.
+        rt.set_balance(TokenAmount::from_atto(69));
+        expect_abort_contains_message(
+            ExitCode::USR_INSUFFICIENT_FUNDS,
+            "insufficient balance for batch",
+            h.propose_batch(&rt, batch_sends(chuck, darlene)),
+        );
+        rt.reset();
+
+        rt.set_balance(TokenAmount::from_atto(100));
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "at least one send",
+            h.propose_batch(&rt, vec![]),
+        );
+        rt.reset();
+
+        let mut sends = batch_sends(chuck, darlene);
+        sends[1].value = TokenAmount::from_atto(-1);
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "must be non-negative",
+            h.propose_batch(&rt, sends),
+        );
+        rt.reset();
+
+        h.assert_transactions(&rt, vec![]);
+        check_state(&rt);
+    }
+}
@@ -3,7 +3,10 @@ use fil_actor_multisig::{
     PendingTxnMap, ProposeParams, ProposeReturn, RemoveSignerParams, State, SwapSignerParams,
     Transaction, TxnID, TxnIDParams, PENDING_TXN_CONFIG,
 };
-use fil_actor_multisig::{ChangeNumApprovalsThresholdParams, LockBalanceParams};
+use fil_actor_multisig::{
+    ChangeNumApprovalsThresholdParams, ExecuteBatchReturn, LockBalanceParams, ProposeBatchParams,
+    ProposeWithExpirationParams, PurgeExpiredTransactionsReturn, SetSignerLimitParams,
+};
 use fil_actors_runtime::test_utils::*;
 use fil_actors_runtime::ActorError;
 use fil_actors_runtime::INIT_ACTOR_ADDR;
@@ -133,6 +136,66 @@ impl ActorHarness {
         ret
     }
 
+    pub fn propose_with_expiration(
+        &self,
+        rt: &MockRuntime,
+        to: Address,
+        value: TokenAmount,
+        method: MethodNum,
+        params: RawBytes,
+        expiration_epoch: ChainEpoch,
+    ) -> Result<Option<IpldBlock>, ActorError> {
+        rt.expect_validate_caller_any();
+        let propose_params =
+            ProposeWithExpirationParams { to, value, method, params, expiration_epoch };
+        let ret = rt.call::<Actor>(
+            Method::ProposeWithExpiration as u64,
+            IpldBlock::serialize_cbor(&propose_params).unwrap(),
+        );
+        rt.verify();
+        ret
+    }
+
+    pub fn propose_batch(
+        &self,
+        rt: &MockRuntime,
+        sends: Vec<ProposeParams>,
+    ) -> Result<Option<IpldBlock>, ActorError> {
+        rt.expect_validate_caller_any();
+        let params = ProposeBatchParams { sends };
+        let ret = rt.call::<Actor>(
+            Method::ProposeBatch as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        );
+        rt.verify();
+        ret
+    }
+
+    pub fn execute_batch(
+        &self,
+        rt: &MockRuntime,
+        sends: Vec<ProposeParams>,
+    ) -> Result<ExecuteBatchReturn, ActorError> {
+        rt.expect_validate_caller_addr(vec![rt.receiver]);
+        let params = ProposeBatchParams { sends };
+        let ret = rt.call::<Actor>(
+            Method::ExecuteBatch as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        );
+        rt.verify();
+        Ok(ret?.unwrap().deserialize::<ExecuteBatchReturn>().unwrap())
+    }
+
+    pub fn purge_expired_transactions(
+        &self,
+        rt: &MockRuntime,
+    ) -> Result<PurgeExpiredTransactionsReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        let ret = rt.call::<Actor>(Method::PurgeExpiredTransactions as u64, None);
+        rt.verify();
+        Ok(ret?.unwrap().deserialize::<PurgeExpiredTransactionsReturn>().unwrap())
+    }
+
     pub fn approve(
         &self,
         rt: &MockRuntime,
@@ -200,6 +263,23 @@ impl ActorHarness {
         ret
     }
 
+    pub fn set_signer_limit(
+        &self,
+        rt: &MockRuntime,
+        signer: Address,
+        limit: TokenAmount,
+        period: ChainEpoch,
+    ) -> Result<Option<IpldBlock>, ActorError> {
+        rt.expect_validate_caller_addr(vec![rt.receiver]);
+        let params = SetSignerLimitParams { signer, limit, period };
+        let ret = rt.call::<Actor>(
+            Method::SetSignerLimit as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        );
+        rt.verify();
+        ret
+    }
+
     pub fn assert_transactions(
         &self,
         rt: &MockRuntime,
@@ -218,4 +298,17 @@ impl ActorHarness {
         actual_txns.sort_by_key(|(TxnID(id), _txn)| (*id));
         assert_eq!(expect_txns, actual_txns);
     }
+
+    pub fn pending_transaction_count(&self, rt: &MockRuntime) -> usize {
+        let st: State = rt.get_state();
+        let ptx =
+            PendingTxnMap::load(&rt.store, &st.pending_txs, PENDING_TXN_CONFIG, "pending").unwrap();
+        let mut count = 0;
+        ptx.for_each(|_, _| {
+            count += 1;
+            Ok(())
+        })
+        .unwrap();
+        count
+    }
 }
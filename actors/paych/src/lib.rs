@@ -1,6 +1,8 @@
 // Copyright 2019-2022 ChainSafe Systems
 // SPDX-License-Identifier: Apache-2.0, MIT
 
+use std::collections::{BTreeMap, BTreeSet};
+
 use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::runtime::{ActorCode, Runtime};
 use fil_actors_runtime::{
@@ -40,6 +42,8 @@ pub enum Method {
     UpdateChannelState = 2,
     Settle = 3,
     Collect = 4,
+    BatchUpdateChannelState = 5,
+    ExtendSettlement = 6,
 }
 
 pub const ERR_CHANNEL_STATE_UPDATE_AFTER_SETTLED: ExitCode = ExitCode::new(32);
@@ -77,191 +81,107 @@ impl Actor {
 
         rt.validate_immediate_caller_is([st.from, st.to].iter())?;
         let signer = if rt.message().caller() == st.from { st.to } else { st.from };
-        let sv = params.sv;
-
-        // Pull signature from signed voucher
-        let sig = &sv
-            .signature
-            .as_ref()
-            .ok_or_else(|| actor_error!(illegal_argument, "voucher has no signature"))?
-            .bytes;
-
-        if st.settling_at != 0 && rt.curr_epoch() >= st.settling_at {
-            return Err(ActorError::unchecked(
-                ERR_CHANNEL_STATE_UPDATE_AFTER_SETTLED,
-                "no vouchers can be processed after settling at epoch".to_string(),
-            ));
-        }
 
-        if params.secret.len() > MAX_SECRET_SIZE {
-            return Err(actor_error!(illegal_argument, "secret must be at most 256 bytes long"));
-        }
+        validate_voucher(rt, &st, &signer, &params)?;
 
-        // Generate unsigned bytes
-        let sv_bz = sv.signing_bytes().map_err(|e| {
-            ActorError::serialization(format!("failed to serialized SignedVoucher: {}", e))
-        })?;
+        rt.transaction(|st: &mut State, rt| {
+            let mut l_states = Array::load(&st.lane_states, rt.store()).map_err(|e| {
+                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load lane states")
+            })?;
 
-        // Validate signature
+            apply_voucher(rt, st, &mut l_states, params.sv)?;
 
-        if !extract_send_result(rt.send(
-            &signer,
-            ext::account::AUTHENTICATE_MESSAGE_METHOD,
-            IpldBlock::serialize_cbor(&ext::account::AuthenticateMessageParams {
-                signature: sig.to_vec(),
-                message: sv_bz,
-            })?,
-            TokenAmount::zero(),
-            None,
-            SendFlags::READ_ONLY,
-        ))
-        .and_then(deserialize_block)
-        .context("proposal authentication failed")?
-        {
-            return Err(actor_error!(illegal_argument, "voucher sig authentication failed"));
-        }
+            st.lane_states = l_states.flush().map_err(|e| {
+                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save lanes")
+            })?;
+            Ok(())
+        })
+    }
 
-        let pch_addr = rt.message().receiver();
-        let svpch_id = rt.resolve_address(&sv.channel_addr).ok_or_else(|| {
-            actor_error!(
-                illegal_argument,
-                "voucher payment channel address {} does not resolve to an ID address",
-                sv.channel_addr
-            )
-        })?;
-        if pch_addr != Address::new_id(svpch_id) {
-            return Err(actor_error!(illegal_argument;
-                    "voucher payment channel address {} does not match receiver {}",
-                    svpch_id, pch_addr));
-        }
+    /// Redeems a batch of vouchers in a single state update.
+    /// Every voucher is validated, but only the highest-nonce voucher for each lane is applied.
+    /// The whole batch is rejected if any voucher merges a lane that another voucher in the
+    /// batch redeems or merges.
+    pub fn batch_update_channel_state(
+        rt: &impl Runtime,
+        params: BatchUpdateChannelStateParams,
+    ) -> Result<BatchUpdateChannelStateReturn, ActorError> {
+        let st: State = rt.state()?;
 
-        if rt.curr_epoch() < sv.time_lock_min {
-            return Err(actor_error!(illegal_argument; "cannot use this voucher yet"));
-        }
+        rt.validate_immediate_caller_is([st.from, st.to].iter())?;
+        let signer = if rt.message().caller() == st.from { st.to } else { st.from };
 
-        if sv.time_lock_max != 0 && rt.curr_epoch() > sv.time_lock_max {
-            return Err(actor_error!(illegal_argument; "this voucher has expired"));
+        if params.updates.is_empty() {
+            return Err(actor_error!(illegal_argument, "no vouchers to redeem"));
         }
-
-        if sv.amount.is_negative() {
-            return Err(actor_error!(illegal_argument;
-                    "voucher amount must be non-negative, was {}", sv.amount));
+        if params.updates.len() > MAX_VOUCHER_BATCH_SIZE {
+            return Err(actor_error!(
+                illegal_argument,
+                "too many vouchers in batch: {} > {}",
+                params.updates.len(),
+                MAX_VOUCHER_BATCH_SIZE
+            ));
         }
 
-        if !sv.secret_pre_image.is_empty() {
-            let hashed_secret: &[u8] = &rt.hash_blake2b(&params.secret);
-            if hashed_secret != sv.secret_pre_image.as_slice() {
-                return Err(actor_error!(illegal_argument; "incorrect secret"));
+        let mut redeemed_lanes = BTreeSet::<u64>::new();
+        let mut merged_lanes = BTreeSet::<u64>::new();
+        for update in &params.updates {
+            validate_voucher(rt, &st, &signer, update)?;
+            redeemed_lanes.insert(update.sv.lane);
+            for merge in &update.sv.merges {
+                if !merged_lanes.insert(merge.lane) {
+                    return Err(actor_error!(
+                        illegal_argument,
+                        "lane {} is merged by more than one voucher in batch",
+                        merge.lane
+                    ));
+                }
             }
         }
-
-        if let Some(extra) = &sv.extra {
-            extract_send_result(rt.send_simple(
-                &extra.actor,
-                extra.method,
-                Some(IpldBlock { codec: CBOR, data: extra.data.to_vec() }),
-                TokenAmount::zero(),
-            ))
-            .map_err(|e| e.wrap("spend voucher verification failed"))?;
+        if let Some(lane) = merged_lanes.intersection(&redeemed_lanes).next() {
+            return Err(actor_error!(
+                illegal_argument,
+                "lane {} is both redeemed and merged by vouchers in batch",
+                lane
+            ));
         }
 
-        rt.transaction(|st: &mut State, rt| {
-            let mut l_states = Array::load(&st.lane_states, rt.store()).map_err(|e| {
-                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load lane states")
-            })?;
-
-            // Find the voucher lane, create and insert it in sorted order if necessary.
-            let lane_id = sv.lane;
-            let lane_state = find_lane(&l_states, lane_id)?;
-
-            let mut lane_state = if let Some(state) = lane_state {
-                if state.nonce >= sv.nonce {
-                    return Err(actor_error!(illegal_argument;
-                        "voucher has an outdated nonce, existing: {}, voucher: {}, cannot redeem",
-                        state.nonce, sv.nonce));
-                }
-                state.clone()
-            } else {
-                LaneState::default()
-            };
-
-            // The next section actually calculates the payment amounts to update
-            // the payment channel state
-            // 1. (optional) sum already redeemed value of all merging lanes
-            let mut redeemed_from_others = TokenAmount::zero();
-            for merge in sv.merges {
-                if merge.lane == sv.lane {
-                    return Err(actor_error!(illegal_argument;
-                        "voucher cannot merge lanes into it's own lane"));
+        // Select the highest-nonce voucher for each lane.
+        let mut to_apply = BTreeMap::<u64, SignedVoucher>::new();
+        for update in params.updates {
+            let sv = update.sv;
+            match to_apply.get(&sv.lane) {
+                Some(existing) if existing.nonce == sv.nonce => {
+                    return Err(actor_error!(
+                        illegal_argument,
+                        "duplicate nonce {} for lane {} in batch",
+                        sv.nonce,
+                        sv.lane
+                    ));
                 }
-                let mut other_ls = find_lane(&l_states, merge.lane)?
-                    .ok_or_else(|| {
-                        actor_error!(illegal_argument;
-                        "voucher specifies invalid merge lane {}", merge.lane)
-                    })?
-                    .clone();
-
-                if other_ls.nonce >= merge.nonce {
-                    return Err(actor_error!(illegal_argument;
-                            "merged lane in voucher has outdated nonce, cannot redeem"));
+                Some(existing) if existing.nonce > sv.nonce => {}
+                _ => {
+                    to_apply.insert(sv.lane, sv);
                 }
-
-                redeemed_from_others += &other_ls.redeemed;
-                other_ls.nonce = merge.nonce;
-                l_states.set(merge.lane, other_ls).map_err(|e| {
-                    e.downcast_default(
-                        ExitCode::USR_ILLEGAL_STATE,
-                        format!("failed to store lane {}", merge.lane),
-                    )
-                })?;
-            }
-
-            // 2. To prevent double counting, remove already redeemed amounts (from
-            // voucher or other lanes) from the voucher amount
-            lane_state.nonce = sv.nonce;
-            let balance_delta = &sv.amount - (redeemed_from_others + &lane_state.redeemed);
-
-            // 3. set new redeemed value for merged-into lane
-            lane_state.redeemed = sv.amount;
-
-            // 4. check operation validity
-            let new_send_balance = balance_delta + &st.to_send;
-
-            if new_send_balance < TokenAmount::zero() {
-                return Err(actor_error!(illegal_argument;
-                    "voucher would leave channel balance negative"));
-            }
-
-            if new_send_balance > rt.current_balance() {
-                return Err(actor_error!(illegal_argument;
-                    "not enough funds in channel to cover voucher"));
             }
+        }
 
-            // 5. add new redemption ToSend
-            st.to_send = new_send_balance;
+        let to_send = rt.transaction(|st: &mut State, rt| {
+            let mut l_states = Array::load(&st.lane_states, rt.store()).map_err(|e| {
+                e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to load lane states")
+            })?;
 
-            // update channel settlingAt and MinSettleHeight if delayed by voucher
-            if sv.min_settle_height != 0 {
-                if st.settling_at != 0 && st.settling_at < sv.min_settle_height {
-                    st.settling_at = sv.min_settle_height;
-                }
-                if st.min_settle_height < sv.min_settle_height {
-                    st.min_settle_height = sv.min_settle_height;
-                }
+            for sv in to_apply.into_values() {
+                apply_voucher(rt, st, &mut l_states, sv)?;
             }
 
-            l_states.set(lane_id, lane_state).map_err(|e| {
-                e.downcast_default(
-                    ExitCode::USR_ILLEGAL_STATE,
-                    format!("failed to store lane {}", lane_id),
-                )
-            })?;
-
             st.lane_states = l_states.flush().map_err(|e| {
                 e.downcast_default(ExitCode::USR_ILLEGAL_STATE, "failed to save lanes")
             })?;
-            Ok(())
-        })
+            Ok(st.to_send.clone())
+        })?;
+
+        Ok(BatchUpdateChannelStateReturn { to_send })
     }
 
     pub fn settle(rt: &impl Runtime) -> Result<(), ActorError> {
@@ -276,11 +196,45 @@ impl Actor {
             if st.settling_at < st.min_settle_height {
                 st.settling_at = st.min_settle_height;
             }
+            st.settle_extension_limit = st.settling_at + SETTLE_DELAY;
 
             Ok(())
         })
     }
 
+    /// Delays the epoch at which a settling channel can be collected, e.g. to allow
+    /// a voucher in flight to be redeemed.
+    /// The new epoch must be later than the current one and no more than SETTLE_DELAY
+    /// beyond the epoch set when settlement began.
+    pub fn extend_settlement(
+        rt: &impl Runtime,
+        params: ExtendSettlementParams,
+    ) -> Result<(), ActorError> {
+        rt.transaction(|st: &mut State, rt| {
+            rt.validate_immediate_caller_is([st.from, st.to].iter())?;
+
+            if st.settling_at == 0 {
+                return Err(actor_error!(forbidden; "channel not settling"));
+            }
+            if rt.curr_epoch() >= st.settling_at {
+                return Err(actor_error!(forbidden; "channel already settled"));
+            }
+            if params.settling_at <= st.settling_at {
+                return Err(actor_error!(illegal_argument;
+                    "new settling epoch {} must be after current {}",
+                    params.settling_at, st.settling_at));
+            }
+            if params.settling_at > st.settle_extension_limit {
+                return Err(actor_error!(illegal_argument;
+                    "new settling epoch {} exceeds limit {}",
+                    params.settling_at, st.settle_extension_limit));
+            }
+
+            st.settling_at = params.settling_at;
+            Ok(())
+        })
+    }
+
     pub fn collect(rt: &impl Runtime) -> Result<(), ActorError> {
         let st: State = rt.state()?;
         rt.validate_immediate_caller_is(&[st.from, st.to])?;
@@ -303,6 +257,204 @@ impl Actor {
     }
 }
 
+/// Checks that a voucher may be redeemed by the caller at the current epoch,
+/// including its signature by `signer`, secret, and any extra verification call.
+fn validate_voucher(
+    rt: &impl Runtime,
+    st: &State,
+    signer: &Address,
+    params: &UpdateChannelStateParams,
+) -> Result<(), ActorError> {
+    let sv = &params.sv;
+
+    // Pull signature from signed voucher
+    let sig = &sv
+        .signature
+        .as_ref()
+        .ok_or_else(|| actor_error!(illegal_argument, "voucher has no signature"))?
+        .bytes;
+
+    if st.settling_at != 0 && rt.curr_epoch() >= st.settling_at {
+        return Err(ActorError::unchecked(
+            ERR_CHANNEL_STATE_UPDATE_AFTER_SETTLED,
+            "no vouchers can be processed after settling at epoch".to_string(),
+        ));
+    }
+
+    if params.secret.len() > MAX_SECRET_SIZE {
+        return Err(actor_error!(illegal_argument, "secret must be at most 256 bytes long"));
+    }
+
+    // Generate unsigned bytes
+    let sv_bz = sv.signing_bytes().map_err(|e| {
+        ActorError::serialization(format!("failed to serialized SignedVoucher: {}", e))
+    })?;
+
+    // Validate signature.
+    // The signer is an ID address, which the signature syscall cannot resolve to a key, so
+    // every signer authenticates the voucher itself. Account actors check the signature
+    // against their key address, while contracts (e.g. f4 payers) apply their own scheme.
+    if !extract_send_result(rt.send(
+        signer,
+        ext::account::AUTHENTICATE_MESSAGE_METHOD,
+        IpldBlock::serialize_cbor(&ext::account::AuthenticateMessageParams {
+            signature: sig.to_vec(),
+            message: sv_bz,
+        })?,
+        TokenAmount::zero(),
+        None,
+        SendFlags::READ_ONLY,
+    ))
+    .and_then(deserialize_block)
+    .context("proposal authentication failed")?
+    {
+        return Err(actor_error!(illegal_argument, "voucher sig authentication failed"));
+    }
+
+    let pch_addr = rt.message().receiver();
+    let svpch_id = rt.resolve_address(&sv.channel_addr).ok_or_else(|| {
+        actor_error!(
+            illegal_argument,
+            "voucher payment channel address {} does not resolve to an ID address",
+            sv.channel_addr
+        )
+    })?;
+    if pch_addr != Address::new_id(svpch_id) {
+        return Err(actor_error!(illegal_argument;
+                "voucher payment channel address {} does not match receiver {}",
+                svpch_id, pch_addr));
+    }
+
+    if rt.curr_epoch() < sv.time_lock_min {
+        return Err(actor_error!(illegal_argument; "cannot use this voucher yet"));
+    }
+
+    if sv.time_lock_max != 0 && rt.curr_epoch() > sv.time_lock_max {
+        return Err(actor_error!(illegal_argument; "this voucher has expired"));
+    }
+
+    if sv.amount.is_negative() {
+        return Err(actor_error!(illegal_argument;
+                "voucher amount must be non-negative, was {}", sv.amount));
+    }
+
+    if !sv.secret_pre_image.is_empty() {
+        let hashed_secret: &[u8] = &rt.hash_blake2b(&params.secret);
+        if hashed_secret != sv.secret_pre_image.as_slice() {
+            return Err(actor_error!(illegal_argument; "incorrect secret"));
+        }
+    }
+
+    if let Some(extra) = &sv.extra {
+        extract_send_result(rt.send_simple(
+            &extra.actor,
+            extra.method,
+            Some(IpldBlock { codec: CBOR, data: extra.data.to_vec() }),
+            TokenAmount::zero(),
+        ))
+        .map_err(|e| e.wrap("spend voucher verification failed"))?;
+    }
+    Ok(())
+}
+
+/// Applies a validated voucher to the lane states, updating the amount to send.
+fn apply_voucher<BS>(
+    rt: &impl Runtime,
+    st: &mut State,
+    l_states: &mut Array<LaneState, BS>,
+    sv: SignedVoucher,
+) -> Result<(), ActorError>
+where
+    BS: Blockstore,
+{
+    // Find the voucher lane, create and insert it in sorted order if necessary.
+    let lane_id = sv.lane;
+    let lane_state = find_lane(l_states, lane_id)?;
+
+    let mut lane_state = if let Some(state) = lane_state {
+        if state.nonce >= sv.nonce {
+            return Err(actor_error!(illegal_argument;
+                "voucher has an outdated nonce, existing: {}, voucher: {}, cannot redeem",
+                state.nonce, sv.nonce));
+        }
+        state.clone()
+    } else {
+        LaneState::default()
+    };
+
+    // The next section actually calculates the payment amounts to update
+    // the payment channel state
+    // 1. (optional) sum already redeemed value of all merging lanes
+    let mut redeemed_from_others = TokenAmount::zero();
+    for merge in sv.merges {
+        if merge.lane == sv.lane {
+            return Err(actor_error!(illegal_argument;
+                "voucher cannot merge lanes into it's own lane"));
+        }
+        let mut other_ls = find_lane(l_states, merge.lane)?
+            .ok_or_else(|| {
+                actor_error!(illegal_argument;
+                "voucher specifies invalid merge lane {}", merge.lane)
+            })?
+            .clone();
+
+        if other_ls.nonce >= merge.nonce {
+            return Err(actor_error!(illegal_argument;
+                    "merged lane in voucher has outdated nonce, cannot redeem"));
+        }
+
+        redeemed_from_others += &other_ls.redeemed;
+        other_ls.nonce = merge.nonce;
+        l_states.set(merge.lane, other_ls).map_err(|e| {
+            e.downcast_default(
+                ExitCode::USR_ILLEGAL_STATE,
+                format!("failed to store lane {}", merge.lane),
+            )
+        })?;
+    }
+
+    // 2. To prevent double counting, remove already redeemed amounts (from
+    // voucher or other lanes) from the voucher amount
+    lane_state.nonce = sv.nonce;
+    let balance_delta = &sv.amount - (redeemed_from_others + &lane_state.redeemed);
+
+    // 3. set new redeemed value for merged-into lane
+    lane_state.redeemed = sv.amount;
+
+    // 4. check operation validity
+    let new_send_balance = balance_delta + &st.to_send;
+
+    if new_send_balance < TokenAmount::zero() {
+        return Err(actor_error!(illegal_argument;
+            "voucher would leave channel balance negative"));
+    }
+
+    // The channel may be topped up with a plain value transfer at any time,
+    // which raises this ceiling.
+    if new_send_balance > rt.current_balance() {
+        return Err(actor_error!(illegal_argument;
+            "not enough funds in channel to cover voucher"));
+    }
+
+    // 5. add new redemption ToSend
+    st.to_send = new_send_balance;
+
+    // update channel settlingAt and MinSettleHeight if delayed by voucher
+    if sv.min_settle_height != 0 {
+        if st.settling_at != 0 && st.settling_at < sv.min_settle_height {
+            st.settling_at = sv.min_settle_height;
+        }
+        if st.min_settle_height < sv.min_settle_height {
+            st.min_settle_height = sv.min_settle_height;
+        }
+    }
+
+    l_states.set(lane_id, lane_state).map_err(|e| {
+        e.downcast_default(ExitCode::USR_ILLEGAL_STATE, format!("failed to store lane {}", lane_id))
+    })?;
+    Ok(())
+}
+
 #[inline]
 fn find_lane<'a, BS>(
     ls: &'a Array<LaneState, BS>,
@@ -332,5 +484,7 @@ impl ActorCode for Actor {
         UpdateChannelState => update_channel_state,
         Settle => settle,
         Collect => collect,
+        BatchUpdateChannelState => batch_update_channel_state,
+        ExtendSettlement => extend_settlement,
     }
 }
@@ -25,6 +25,9 @@ pub struct State {
     pub min_settle_height: ChainEpoch,
     /// Collections of lane states for the channel, maintained in ID order.
     pub lane_states: Cid, // AMT<LaneState>
+    /// Latest epoch to which `settling_at` may be extended by `ExtendSettlement`.
+    /// Set on `Settle`, zero otherwise.
+    pub settle_extension_limit: ChainEpoch,
 }
 
 impl State {
@@ -36,6 +39,7 @@ impl State {
             settling_at: 0,
             min_settle_height: 0,
             lane_states: empty_arr_cid,
+            settle_extension_limit: 0,
         }
     }
 }
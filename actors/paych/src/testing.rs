@@ -36,6 +36,14 @@ pub fn check_state_invariants<BS: Blockstore>(
         ),
     );
 
+    acc.require(
+        (state.settling_at == 0) == (state.settle_extension_limit == 0),
+        format!(
+            "settle extension limit {} inconsistent with settling at {}",
+            state.settle_extension_limit, state.settling_at
+        ),
+    );
+
     match Amt::<LaneState, _>::load(&state.lane_states, store) {
         Ok(lanes) => {
             let ret = lanes.for_each(|i, lane| {
@@ -22,6 +22,9 @@ pub const MAX_SECRET_SIZE: usize = 256;
 
 pub const LANE_STATES_AMT_BITWIDTH: u32 = 3;
 
+// Maximum number of vouchers that can be redeemed in a single batch update.
+pub const MAX_VOUCHER_BATCH_SIZE: usize = 256;
+
 /// Constructor parameters for payment channel actor
 #[derive(Serialize_tuple, Deserialize_tuple)]
 pub struct ConstructorParams {
@@ -126,3 +129,21 @@ impl From<SignedVoucher> for UpdateChannelStateParams {
         UpdateChannelStateParams { secret: vec![], sv }
     }
 }
+
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct BatchUpdateChannelStateParams {
+    pub updates: Vec<UpdateChannelStateParams>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple)]
+pub struct BatchUpdateChannelStateReturn {
+    /// The total amount to be sent to the recipient after applying the batch.
+    pub to_send: TokenAmount,
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct ExtendSettlementParams {
+    /// The new epoch at which the channel can be collected.
+    pub settling_at: ChainEpoch,
+}
@@ -9,7 +9,8 @@ use derive_builder::Builder;
 use fil_actor_paych::ext::account::{AuthenticateMessageParams, AUTHENTICATE_MESSAGE_METHOD};
 use fil_actor_paych::testing::check_state_invariants;
 use fil_actor_paych::{
-    Actor as PaychActor, ConstructorParams, LaneState, Merge, Method, ModVerifyParams,
+    Actor as PaychActor, BatchUpdateChannelStateParams, BatchUpdateChannelStateReturn,
+    ConstructorParams, ExtendSettlementParams, LaneState, Merge, Method, ModVerifyParams,
     SignedVoucher, State as PState, UpdateChannelStateParams, MAX_LANE, SETTLE_DELAY,
 };
 
@@ -463,10 +464,83 @@ mod update_channel_state_redeem {
             settling_at: state.settling_at,
             min_settle_height: state.min_settle_height,
             lane_states: construct_lane_state_amt(&rt, vec![exp_ls]),
+            settle_extension_limit: state.settle_extension_limit,
         };
         verify_state(&rt, Some(1), exp_state);
     }
 
+    #[test]
+    fn redeem_voucher_signed_by_contract_payer() {
+        let paych_addr = Address::new_id(PAYCH_ID);
+        let payer_addr = Address::new_id(PAYER_ID);
+        let payee_addr = Address::new_id(PAYEE_ID);
+        let mut actor_code_cids = HashMap::default();
+        actor_code_cids.insert(payer_addr, *EVM_ACTOR_CODE_ID);
+        actor_code_cids.insert(payee_addr, *ACCOUNT_ACTOR_CODE_ID);
+        let rt = MockRuntime {
+            receiver: paych_addr,
+            caller: RefCell::new(INIT_ACTOR_ADDR),
+            caller_type: RefCell::new(*INIT_ACTOR_CODE_ID),
+            actor_code_cids: RefCell::new(actor_code_cids),
+            balance: RefCell::new(TokenAmount::from_atto(100)),
+            epoch: RefCell::new(2),
+            ..Default::default()
+        };
+        construct_and_verify(&rt, payer_addr, payee_addr);
+
+        let sv = SignedVoucher {
+            time_lock_min: 0,
+            time_lock_max: 0,
+            lane: 0,
+            nonce: 1,
+            amount: TokenAmount::from_atto(10),
+            signature: Some(Signature::new_secp256k1(vec![7; 65])),
+            secret_pre_image: Default::default(),
+            channel_addr: paych_addr,
+            extra: Default::default(),
+            min_settle_height: Default::default(),
+            merges: Default::default(),
+        };
+        let params =
+            IpldBlock::serialize_cbor(&UpdateChannelStateParams::from(sv.clone())).unwrap();
+
+        // The contract rejects the voucher.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, payee_addr);
+        rt.expect_validate_caller_addr(vec![payer_addr, payee_addr]);
+        rt.expect_send(
+            payer_addr,
+            AUTHENTICATE_MESSAGE_METHOD,
+            IpldBlock::serialize_cbor(&AuthenticateMessageParams {
+                signature: sv.clone().signature.unwrap().bytes,
+                message: sv.signing_bytes().unwrap(),
+            })
+            .unwrap(),
+            TokenAmount::zero(),
+            None,
+            SendFlags::READ_ONLY,
+            IpldBlock::serialize_cbor(&false).unwrap(),
+            ExitCode::OK,
+            None,
+        );
+        expect_abort(
+            &rt,
+            Method::UpdateChannelState as u64,
+            params.clone(),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.verify();
+
+        // The contract accepts the voucher.
+        rt.expect_validate_caller_addr(vec![payer_addr, payee_addr]);
+        expect_authenticate_message(&rt, payer_addr, sv, ExitCode::OK);
+        call(&rt, Method::UpdateChannelState as u64, params);
+        rt.verify();
+
+        let state: PState = rt.get_state();
+        assert_eq!(TokenAmount::from_atto(10), state.to_send);
+        check_state(&rt);
+    }
+
     #[test]
     fn redeem_voucher_correct_lane() {
         let (rt, mut sv) = require_create_channel_with_lanes(3);
@@ -505,6 +579,43 @@ mod update_channel_state_redeem {
         check_state(&rt);
     }
 
+    #[test]
+    fn redeem_voucher_after_top_up() {
+        let (rt, mut sv) = require_create_channel_with_lanes(1);
+        let state: PState = rt.get_state();
+        let payee_addr = Address::new_id(PAYEE_ID);
+        let payer_addr = Address::new_id(PAYER_ID);
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, payee_addr);
+
+        sv.amount = &*rt.balance.borrow() + TokenAmount::from_atto(1);
+
+        // The voucher exceeds the channel balance.
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        expect_authenticate_message(&rt, payer_addr, sv.clone(), ExitCode::OK);
+        expect_abort(
+            &rt,
+            Method::UpdateChannelState as u64,
+            IpldBlock::serialize_cbor(&UpdateChannelStateParams::from(sv.clone())).unwrap(),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.verify();
+
+        // Funds transferred to the channel raise the redemption ceiling.
+        rt.add_balance(TokenAmount::from_atto(1));
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        expect_authenticate_message(&rt, payer_addr, sv.clone(), ExitCode::OK);
+        call(
+            &rt,
+            Method::UpdateChannelState as u64,
+            IpldBlock::serialize_cbor(&UpdateChannelStateParams::from(sv.clone())).unwrap(),
+        );
+        rt.verify();
+
+        let state: PState = rt.get_state();
+        assert_eq!(sv.amount, state.to_send);
+        check_state(&rt);
+    }
+
     #[test]
     fn redeem_voucher_nonce_reuse() {
         let (rt, mut sv) = require_create_channel_with_lanes(3);
@@ -672,6 +783,119 @@ mod merge_tests {
     }
 }
 
+mod batch_update_channel_state {
+    use super::*;
+
+    fn voucher(base: &SignedVoucher, lane: u64, nonce: u64, amount: u64) -> SignedVoucher {
+        SignedVoucher { lane, nonce, amount: TokenAmount::from_atto(amount), ..base.clone() }
+    }
+
+    fn batch_params(vouchers: &[SignedVoucher]) -> Option<IpldBlock> {
+        let updates = vouchers.iter().cloned().map(UpdateChannelStateParams::from).collect();
+        IpldBlock::serialize_cbor(&BatchUpdateChannelStateParams { updates }).unwrap()
+    }
+
+    fn setup(num_lanes: u64) -> (MockRuntime, SignedVoucher, PState) {
+        let (rt, sv) = require_create_channel_with_lanes(num_lanes);
+        let state: PState = rt.get_state();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.to);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        (rt, sv, state)
+    }
+
+    #[test]
+    fn applies_highest_nonce_voucher_per_lane() {
+        let (rt, sv, state) = setup(2);
+        let lane0: LaneState = get_lane_state(&rt, &state.lane_states, 0);
+        let lane1: LaneState = get_lane_state(&rt, &state.lane_states, 1);
+
+        let vouchers = vec![voucher(&sv, 0, 6, 20), voucher(&sv, 0, 5, 10), voucher(&sv, 1, 5, 30)];
+        for v in &vouchers {
+            expect_authenticate_message(&rt, state.from, v.clone(), ExitCode::OK);
+        }
+
+        let ret: BatchUpdateChannelStateReturn =
+            call(&rt, Method::BatchUpdateChannelState as u64, batch_params(&vouchers))
+                .unwrap()
+                .deserialize()
+                .unwrap();
+        rt.verify();
+
+        let expected_to_send = state.to_send.clone() + TokenAmount::from_atto(20)
+            - lane0.redeemed.clone()
+            + TokenAmount::from_atto(30)
+            - lane1.redeemed.clone();
+        assert_eq!(expected_to_send, ret.to_send);
+
+        let state: PState = rt.get_state();
+        assert_eq!(expected_to_send, state.to_send);
+        assert_eq!(
+            LaneState { redeemed: TokenAmount::from_atto(20), nonce: 6 },
+            get_lane_state(&rt, &state.lane_states, 0)
+        );
+        assert_eq!(
+            LaneState { redeemed: TokenAmount::from_atto(30), nonce: 5 },
+            get_lane_state(&rt, &state.lane_states, 1)
+        );
+        check_state(&rt);
+    }
+
+    #[test]
+    fn rejects_merge_conflict() {
+        let (rt, sv, state) = setup(3);
+
+        let mut merging = voucher(&sv, 0, 10, 50);
+        merging.merges = vec![Merge { lane: 1, nonce: 10 }];
+        let vouchers = vec![merging, voucher(&sv, 1, 10, 20)];
+        for v in &vouchers {
+            expect_authenticate_message(&rt, state.from, v.clone(), ExitCode::OK);
+        }
+
+        expect_abort(
+            &rt,
+            Method::BatchUpdateChannelState as u64,
+            batch_params(&vouchers),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.verify();
+
+        let after: PState = rt.get_state();
+        assert_eq!(state.lane_states, after.lane_states);
+        check_state(&rt);
+    }
+
+    #[test]
+    fn rejects_duplicate_nonce() {
+        let (rt, sv, state) = setup(1);
+
+        let vouchers = vec![voucher(&sv, 0, 10, 20), voucher(&sv, 0, 10, 30)];
+        for v in &vouchers {
+            expect_authenticate_message(&rt, state.from, v.clone(), ExitCode::OK);
+        }
+
+        expect_abort(
+            &rt,
+            Method::BatchUpdateChannelState as u64,
+            batch_params(&vouchers),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.verify();
+        check_state(&rt);
+    }
+
+    #[test]
+    fn rejects_empty_batch() {
+        let (rt, _, _) = setup(1);
+        expect_abort(
+            &rt,
+            Method::BatchUpdateChannelState as u64,
+            batch_params(&[]),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.verify();
+    }
+}
+
 mod update_channel_state_extra {
     use super::*;
     use fvm_ipld_encoding::CBOR;
@@ -889,6 +1113,99 @@ mod actor_settle {
         check_state(&rt);
     }
 
+    #[test]
+    fn extend_settlement() {
+        let (rt, _sv) = require_create_channel_with_lanes(1);
+        rt.epoch.replace(EP);
+        let mut state: PState = rt.get_state();
+
+        // Can't extend before settling.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.to);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        let params = ExtendSettlementParams { settling_at: EP + SETTLE_DELAY };
+        expect_abort(
+            &rt,
+            Method::ExtendSettlement as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+            ExitCode::USR_FORBIDDEN,
+        );
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.from);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        call(&rt, Method::Settle as u64, None);
+        state = rt.get_state();
+        let original = state.settling_at;
+        assert_eq!(original + SETTLE_DELAY, state.settle_extension_limit);
+
+        // Either party may extend.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.to);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        let params = ExtendSettlementParams { settling_at: original + 10 };
+        call(&rt, Method::ExtendSettlement as u64, IpldBlock::serialize_cbor(&params).unwrap());
+        state = rt.get_state();
+        assert_eq!(original + 10, state.settling_at);
+        check_state(&rt);
+
+        // Extensions must be later than the current settling epoch.
+        for settling_at in [original + 5, original + 10] {
+            rt.expect_validate_caller_addr(vec![state.from, state.to]);
+            let params = ExtendSettlementParams { settling_at };
+            expect_abort(
+                &rt,
+                Method::ExtendSettlement as u64,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+            );
+        }
+
+        // Extensions are capped at SETTLE_DELAY beyond the original.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.from);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        let params = ExtendSettlementParams { settling_at: original + SETTLE_DELAY + 1 };
+        expect_abort(
+            &rt,
+            Method::ExtendSettlement as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+        );
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        let params = ExtendSettlementParams { settling_at: original + SETTLE_DELAY };
+        call(&rt, Method::ExtendSettlement as u64, IpldBlock::serialize_cbor(&params).unwrap());
+        state = rt.get_state();
+        assert_eq!(original + SETTLE_DELAY, state.settling_at);
+
+        // Can't extend once settled.
+        rt.epoch.replace(state.settling_at);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        expect_abort(
+            &rt,
+            Method::ExtendSettlement as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+            ExitCode::USR_FORBIDDEN,
+        );
+        check_state(&rt);
+    }
+
+    #[test]
+    fn extend_settlement_rejects_non_participant() {
+        let (rt, _sv) = require_create_channel_with_lanes(1);
+        rt.epoch.replace(EP);
+        let state: PState = rt.get_state();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, state.from);
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        call(&rt, Method::Settle as u64, None);
+
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(1001));
+        rt.expect_validate_caller_addr(vec![state.from, state.to]);
+        let params = ExtendSettlementParams { settling_at: EP + SETTLE_DELAY + 1 };
+        expect_abort(
+            &rt,
+            Method::ExtendSettlement as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+            ExitCode::USR_FORBIDDEN,
+        );
+    }
+
     #[test]
     fn voucher_invalid_after_settling() {
         const ERR_CHANNEL_STATE_UPDATE_AFTER_SETTLED: ExitCode = ExitCode::new(32);
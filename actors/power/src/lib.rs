@@ -4,9 +4,11 @@
 use fil_actors_runtime::reward::ThisEpochRewardReturn;
 use fvm_ipld_encoding::ipld_block::IpldBlock;
 use fvm_ipld_encoding::RawBytes;
+use fvm_shared::address::Address;
 use fvm_shared::bigint::bigint_ser::BigIntSer;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
+use fvm_shared::sector::RegisteredPoStProof;
 use fvm_shared::{MethodNum, METHOD_CONSTRUCTOR};
 use log::{debug, error};
 use num_derive::FromPrimitive;
@@ -16,8 +18,9 @@ use ext::init;
 use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::runtime::{ActorCode, Runtime};
 use fil_actors_runtime::{
-    actor_dispatch, actor_error, deserialize_block, extract_send_result, ActorDowncast, ActorError,
-    Multimap, CRON_ACTOR_ADDR, INIT_ACTOR_ADDR, REWARD_ACTOR_ADDR, SYSTEM_ACTOR_ADDR,
+    actor_dispatch, actor_error, deserialize_block, extract_send_result, ActorContext,
+    ActorDowncast, ActorError, Multimap, CRON_ACTOR_ADDR, INIT_ACTOR_ADDR, REWARD_ACTOR_ADDR,
+    SYSTEM_ACTOR_ADDR,
 };
 
 pub use self::policy::*;
@@ -53,9 +56,11 @@ pub enum Method {
     // Method numbers derived from FRC-0042 standards
     CreateMinerExported = frc42_dispatch::method_hash!("CreateMiner"),
     NetworkRawPowerExported = frc42_dispatch::method_hash!("NetworkRawPower"),
+    NetworkPowerAtExported = frc42_dispatch::method_hash!("NetworkPowerAt"),
     MinerRawPowerExported = frc42_dispatch::method_hash!("MinerRawPower"),
     MinerCountExported = frc42_dispatch::method_hash!("MinerCount"),
     MinerConsensusCountExported = frc42_dispatch::method_hash!("MinerConsensusCount"),
+    BatchCreateMinerExported = frc42_dispatch::method_hash!("BatchCreateMiner"),
 }
 
 pub const ERR_TOO_MANY_PROVE_COMMITS: ExitCode = ExitCode::new(32);
@@ -75,6 +80,10 @@ impl Actor {
         Ok(())
     }
 
+    /// Creates a new miner actor via the init actor, configured with the given peer ID and
+    /// multiaddrs at construction so no follow-up ChangePeerID/ChangeMultiaddrs is needed.
+    /// The miner constructor validates the peer info; if it rejects them the whole call aborts
+    /// and no miner or power claim is created.
     fn create_miner(
         rt: &impl Runtime,
         params: CreateMinerParams,
@@ -82,54 +91,54 @@ impl Actor {
         rt.validate_immediate_caller_accept_any()?;
         let value = rt.message().value_received();
 
-        let constructor_params = RawBytes::serialize(ext::miner::MinerConstructorParams {
-            owner: params.owner,
-            worker: params.worker,
-            window_post_proof_type: params.window_post_proof_type,
-            peer_id: params.peer,
-            multi_addresses: params.multiaddrs,
-            control_addresses: Default::default(),
-        })?;
-
-        let miner_actor_code_cid = rt.get_code_cid_for_type(Type::Miner);
-        let ext::init::ExecReturn { id_address, robust_address } =
-            deserialize_block(extract_send_result(rt.send_simple(
-                &INIT_ACTOR_ADDR,
-                ext::init::EXEC_METHOD,
-                IpldBlock::serialize_cbor(&init::ExecParams {
-                    code_cid: miner_actor_code_cid,
-                    constructor_params,
-                })?,
-                value,
-            ))?)?;
-
         let window_post_proof_type = params.window_post_proof_type;
-        rt.transaction(|st: &mut State, rt| {
-            let mut claims = st.load_claims(rt.store())?;
-            set_claim(
-                &mut claims,
-                &id_address,
-                Claim {
-                    window_post_proof_type,
-                    quality_adj_power: Default::default(),
-                    raw_byte_power: Default::default(),
-                },
-            )?;
-            st.miner_count += 1;
+        let ret = exec_miner(rt, params, value)?;
+        register_new_miners(rt, &[(ret.id_address, window_post_proof_type)])?;
+        Ok(ret)
+    }
 
-            st.update_stats_for_new_miner(rt.policy(), window_post_proof_type).map_err(|e| {
-                actor_error!(
-                    illegal_state,
-                    "failed to update power stats for new miner {}: {}",
-                    &id_address,
-                    e
-                )
-            })?;
+    /// Creates several miner actors in a single invocation, returning their addresses in the
+    /// order of the parameters. Each miner is constructed by the init actor exactly as for
+    /// CreateMiner, but the power actor's claims and miner count are updated once for the whole
+    /// batch. If any miner fails to construct, the whole batch aborts and no miner is created.
+    /// At most MAX_MINERS_PER_BATCH_CREATE miners may be created at once.
+    /// No value may be sent, miners should be funded separately after creation.
+    fn batch_create_miner(
+        rt: &impl Runtime,
+        params: BatchCreateMinerParams,
+    ) -> Result<BatchCreateMinerReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        if params.miners.is_empty() {
+            return Err(actor_error!(illegal_argument, "no miners to create"));
+        }
+        if params.miners.len() as u64 > MAX_MINERS_PER_BATCH_CREATE {
+            return Err(actor_error!(
+                illegal_argument,
+                "too many miners to create in a single message: {} > {}",
+                params.miners.len(),
+                MAX_MINERS_PER_BATCH_CREATE
+            ));
+        }
+        let value = rt.message().value_received();
+        if !value.is_zero() {
+            return Err(actor_error!(
+                illegal_argument,
+                "batch miner creation cannot receive value, received {}",
+                value
+            ));
+        }
 
-            st.save_claims(&mut claims)?;
-            Ok(())
-        })?;
-        Ok(CreateMinerReturn { id_address, robust_address })
+        let mut miners = Vec::with_capacity(params.miners.len());
+        let mut new_claims = Vec::with_capacity(params.miners.len());
+        for (i, miner_params) in params.miners.into_iter().enumerate() {
+            let window_post_proof_type = miner_params.window_post_proof_type;
+            let ret = exec_miner(rt, miner_params, TokenAmount::zero())
+                .with_context(|| format!("failed to create miner at index {}", i))?;
+            new_claims.push((ret.id_address, window_post_proof_type));
+            miners.push(ret);
+        }
+        register_new_miners(rt, &new_claims)?;
+        Ok(BatchCreateMinerReturn { miners })
     }
 
     /// Adds or removes claimed power for the calling actor.
@@ -212,13 +221,14 @@ impl Actor {
 
         Self::process_deferred_cron_events(rt, rewret)?;
 
-        let this_epoch_raw_byte_power = rt.transaction(|st: &mut State, _| {
+        let this_epoch_raw_byte_power = rt.transaction(|st: &mut State, rt| {
             let (raw_byte_power, qa_power) = st.current_total_power();
             st.this_epoch_pledge_collateral = st.total_pledge_collateral.clone();
             st.this_epoch_quality_adj_power = qa_power;
             st.this_epoch_raw_byte_power = raw_byte_power;
             // Can assume delta is one since cron is invoked every epoch.
             st.update_smoothed_estimate(1);
+            st.record_power_snapshot(rt.store(), rt.curr_epoch())?;
 
             Ok(IpldBlock::serialize_cbor(&BigIntSer(&st.this_epoch_raw_byte_power))?)
         })?;
@@ -282,6 +292,37 @@ impl Actor {
         Ok(NetworkRawPowerReturn { raw_byte_power: st.this_epoch_raw_byte_power })
     }
 
+    /// Returns the network power totals recorded at the end of the given epoch.
+    /// If no snapshot was recorded at that epoch, returns the closest earlier snapshot.
+    /// Only snapshots from the most recent POWER_SNAPSHOT_EPOCHS epochs are retained.
+    fn network_power_at(
+        rt: &impl Runtime,
+        params: NetworkPowerAtParams,
+    ) -> Result<NetworkPowerAtReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let curr_epoch = rt.curr_epoch();
+        if params.epoch > curr_epoch {
+            return Err(actor_error!(
+                illegal_argument,
+                "epoch {} is after current epoch {}",
+                params.epoch,
+                curr_epoch
+            ));
+        }
+
+        let st: State = rt.state()?;
+        let snapshot =
+            st.power_snapshot_at(rt.store(), params.epoch, curr_epoch)?.ok_or_else(|| {
+                actor_error!(not_found, "no power snapshot retained for epoch {}", params.epoch)
+            })?;
+
+        Ok(NetworkPowerAtReturn {
+            epoch: snapshot.epoch,
+            raw_byte_power: snapshot.raw_byte_power,
+            quality_adj_power: snapshot.quality_adj_power,
+        })
+    }
+
     /// Returns the raw power claimed by the specified miner,
     /// and whether the miner has more than the consensus minimum amount of storage active.
     /// The raw power is defined as the active (i.e. non-faulty) byte commitments of the miner.
@@ -419,6 +460,70 @@ impl Actor {
     }
 }
 
+/// Constructs a new miner actor via the init actor, forwarding the given value to it.
+fn exec_miner(
+    rt: &impl Runtime,
+    params: CreateMinerParams,
+    value: TokenAmount,
+) -> Result<CreateMinerReturn, ActorError> {
+    let constructor_params = RawBytes::serialize(ext::miner::MinerConstructorParams {
+        owner: params.owner,
+        worker: params.worker,
+        window_post_proof_type: params.window_post_proof_type,
+        peer_id: params.peer,
+        multi_addresses: params.multiaddrs,
+        control_addresses: Default::default(),
+    })?;
+
+    let miner_actor_code_cid = rt.get_code_cid_for_type(Type::Miner);
+    let ext::init::ExecReturn { id_address, robust_address } =
+        deserialize_block(extract_send_result(rt.send_simple(
+            &INIT_ACTOR_ADDR,
+            ext::init::EXEC_METHOD,
+            IpldBlock::serialize_cbor(&init::ExecParams {
+                code_cid: miner_actor_code_cid,
+                constructor_params,
+            })?,
+            value,
+        ))?)?;
+    Ok(CreateMinerReturn { id_address, robust_address })
+}
+
+/// Records empty claims for newly created miners and updates the miner count and stats
+/// in a single state transaction.
+fn register_new_miners(
+    rt: &impl Runtime,
+    miners: &[(Address, RegisteredPoStProof)],
+) -> Result<(), ActorError> {
+    rt.transaction(|st: &mut State, rt| {
+        let mut claims = st.load_claims(rt.store())?;
+        for (id_address, window_post_proof_type) in miners {
+            set_claim(
+                &mut claims,
+                id_address,
+                Claim {
+                    window_post_proof_type: *window_post_proof_type,
+                    quality_adj_power: Default::default(),
+                    raw_byte_power: Default::default(),
+                },
+            )?;
+            st.miner_count += 1;
+
+            st.update_stats_for_new_miner(rt.policy(), *window_post_proof_type).map_err(|e| {
+                actor_error!(
+                    illegal_state,
+                    "failed to update power stats for new miner {}: {}",
+                    id_address,
+                    e
+                )
+            })?;
+        }
+
+        st.save_claims(&mut claims)?;
+        Ok(())
+    })
+}
+
 impl ActorCode for Actor {
     type Methods = Method;
 
@@ -435,8 +540,10 @@ impl ActorCode for Actor {
         UpdatePledgeTotal => update_pledge_total,
         CurrentTotalPower => current_total_power,
         NetworkRawPowerExported => network_raw_power,
+        NetworkPowerAtExported => network_power_at,
         MinerRawPowerExported => miner_raw_power,
         MinerCountExported => miner_count,
         MinerConsensusCountExported => miner_consensus_count,
+        BatchCreateMinerExported => batch_create_miner,
     }
 }
@@ -4,6 +4,10 @@
 /// Minimum power of an individual miner to meet the threshold for leader election.
 pub const CONSENSUS_MINER_MIN_MINERS: i64 = 4;
 
+/// Number of epochs of network power snapshots retained by the power actor (one day).
+/// The snapshot ring buffer never holds more than this many entries.
+pub const POWER_SNAPSHOT_EPOCHS: i64 = 2880;
+
 /// Maximum number of prove commits a miner can submit in one epoch
 ///
 /// We bound this to 200 to limit the number of prove partitions we may need to update in a
@@ -11,3 +15,9 @@ pub const CONSENSUS_MINER_MIN_MINERS: i64 = 4;
 ///
 /// To support onboarding 1EiB/year, we need to allow at least 32 prove commits per epoch.
 pub const MAX_MINER_PROVE_COMMITS_PER_EPOCH: u64 = 200;
+
+/// Maximum number of miners that may be created in a single BatchCreateMiner invocation.
+///
+/// Each miner is constructed with a call through the init actor, so this bounds the work
+/// done by one message.
+pub const MAX_MINERS_PER_BATCH_CREATE: u64 = 32;
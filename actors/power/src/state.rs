@@ -25,11 +25,11 @@ use fil_actors_runtime::builtin::reward::smooth::{
 };
 use fil_actors_runtime::runtime::Policy;
 use fil_actors_runtime::{
-    actor_error, ActorContext, ActorDowncast, ActorError, AsActorError, Config, Map2, Multimap,
-    DEFAULT_HAMT_CONFIG,
+    actor_error, ActorContext, ActorDowncast, ActorError, Array, AsActorError, Config, Map2,
+    Multimap, DEFAULT_HAMT_CONFIG,
 };
 
-use super::CONSENSUS_MINER_MIN_MINERS;
+use super::{CONSENSUS_MINER_MIN_MINERS, POWER_SNAPSHOT_EPOCHS};
 
 lazy_static! {
     /// genesis power in bytes = 750,000 GiB
@@ -44,6 +44,9 @@ pub const CRON_QUEUE_AMT_BITWIDTH: u32 = 6;
 pub type ClaimsMap<BS> = Map2<BS, Address, Claim>;
 pub const CLAIMS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
 
+pub type PowerSnapshotArray<'bs, BS> = Array<'bs, PowerSnapshot, BS>;
+pub const POWER_SNAPSHOTS_AMT_BITWIDTH: u32 = 5;
+
 /// Storage power actor state
 #[derive(Default, Serialize_tuple, Deserialize_tuple, Clone, Debug)]
 pub struct State {
@@ -78,8 +81,13 @@ pub struct State {
     /// Claimed power for each miner.
     pub claims: Cid, // Map, HAMT[address]Claim
 
-    // Deprecated as of FIP 0084
+    // Deprecated as of FIP 0084, after which miners verify PoRep proofs synchronously
+    // and no proofs are queued for batch verification in cron.
     pub proof_validation_batch: Option<Cid>,
+
+    /// Network power totals recorded at each cron tick, as a ring buffer indexed by
+    /// epoch modulo POWER_SNAPSHOT_EPOCHS.
+    pub power_snapshots: Cid, // AMT[ChainEpoch % POWER_SNAPSHOT_EPOCHS]PowerSnapshot
 }
 
 impl State {
@@ -88,9 +96,17 @@ impl State {
         let empty_mmap = Multimap::new(store, CRON_QUEUE_HAMT_BITWIDTH, CRON_QUEUE_AMT_BITWIDTH)
             .root()
             .context_code(ExitCode::USR_ILLEGAL_STATE, "Failed to get empty multimap cid")?;
+        let empty_snapshots =
+            PowerSnapshotArray::<BS>::new_with_bit_width(store, POWER_SNAPSHOTS_AMT_BITWIDTH)
+                .flush()
+                .context_code(
+                    ExitCode::USR_ILLEGAL_STATE,
+                    "Failed to create empty power snapshots array",
+                )?;
         Ok(State {
             cron_event_queue: empty_mmap,
             claims: empty_claims,
+            power_snapshots: empty_snapshots,
             this_epoch_qa_power_smoothed: FilterEstimate::new(
                 INITIAL_QA_POWER_ESTIMATE_POSITION.clone(),
                 INITIAL_QA_POWER_ESTIMATE_VELOCITY.clone(),
@@ -334,6 +350,67 @@ impl State {
             .ok_or_else(|| anyhow!("failed to delete claim for {miner}: doesn't exist"))?;
         Ok(())
     }
+
+    pub fn load_power_snapshots<'bs, BS: Blockstore>(
+        &self,
+        store: &'bs BS,
+    ) -> Result<PowerSnapshotArray<'bs, BS>, ActorError> {
+        PowerSnapshotArray::load(&self.power_snapshots, store)
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to load power snapshots")
+    }
+
+    /// Records this epoch's network power totals, overwriting the snapshot taken
+    /// POWER_SNAPSHOT_EPOCHS epochs ago.
+    pub(super) fn record_power_snapshot<BS: Blockstore>(
+        &mut self,
+        store: &BS,
+        epoch: ChainEpoch,
+    ) -> Result<(), ActorError> {
+        let mut snapshots = self.load_power_snapshots(store)?;
+        snapshots
+            .set(
+                power_snapshot_index(epoch),
+                PowerSnapshot {
+                    epoch,
+                    raw_byte_power: self.this_epoch_raw_byte_power.clone(),
+                    quality_adj_power: self.this_epoch_quality_adj_power.clone(),
+                },
+            )
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to set power snapshot")?;
+        self.power_snapshots = snapshots
+            .flush()
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to flush power snapshots")?;
+        Ok(())
+    }
+
+    /// Returns the most recent retained snapshot taken at or before `epoch`, if any.
+    /// Only snapshots taken within POWER_SNAPSHOT_EPOCHS of `curr_epoch` are retained.
+    pub fn power_snapshot_at<BS: Blockstore>(
+        &self,
+        store: &BS,
+        epoch: ChainEpoch,
+        curr_epoch: ChainEpoch,
+    ) -> Result<Option<PowerSnapshot>, ActorError> {
+        let snapshots = self.load_power_snapshots(store)?;
+        let earliest = std::cmp::max(0, curr_epoch - POWER_SNAPSHOT_EPOCHS + 1);
+        for e in (earliest..=epoch).rev() {
+            let snapshot = snapshots
+                .get(power_snapshot_index(e))
+                .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to get power snapshot")?;
+            if let Some(snapshot) = snapshot {
+                // A slot may hold an older snapshot if cron was not invoked at this epoch.
+                if snapshot.epoch == e {
+                    return Ok(Some(snapshot.clone()));
+                }
+            }
+        }
+        Ok(None)
+    }
+}
+
+/// Index of the ring buffer slot holding the power snapshot for an epoch.
+pub fn power_snapshot_index(epoch: ChainEpoch) -> u64 {
+    epoch.rem_euclid(POWER_SNAPSHOT_EPOCHS) as u64
 }
 
 pub(super) fn load_cron_events<BS: Blockstore>(
@@ -391,6 +468,16 @@ pub struct Claim {
     pub quality_adj_power: StoragePower,
 }
 
+/// Network power totals as of the end of an epoch.
+#[derive(Debug, Serialize_tuple, Deserialize_tuple, Clone, PartialEq, Eq)]
+pub struct PowerSnapshot {
+    pub epoch: ChainEpoch,
+    #[serde(with = "bigint_ser")]
+    pub raw_byte_power: StoragePower,
+    #[serde(with = "bigint_ser")]
+    pub quality_adj_power: StoragePower,
+}
+
 #[derive(Clone, Debug, Serialize_tuple, Deserialize_tuple)]
 pub struct CronEvent {
     pub miner_addr: Address,
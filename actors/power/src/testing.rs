@@ -8,8 +8,8 @@ use num_traits::{Signed, Zero};
 use fil_actors_runtime::{parse_uint_key, runtime::Policy, MessageAccumulator, Multimap};
 
 use crate::{
-    consensus_miner_min_power, Claim, ClaimsMap, CronEvent, State, CLAIMS_CONFIG,
-    CRON_QUEUE_AMT_BITWIDTH, CRON_QUEUE_HAMT_BITWIDTH,
+    consensus_miner_min_power, power_snapshot_index, Claim, ClaimsMap, CronEvent,
+    PowerSnapshotArray, State, CLAIMS_CONFIG, CRON_QUEUE_AMT_BITWIDTH, CRON_QUEUE_HAMT_BITWIDTH,
 };
 
 pub struct MinerCronEvent {
@@ -83,6 +83,7 @@ pub fn check_state_invariants<BS: Blockstore>(
     let crons = check_cron_invariants(state, store, &acc);
     let claims = check_claims_invariants(policy, state, store, &acc);
     check_proofs_invariants(state, &acc);
+    check_power_snapshots_invariants(state, store, &acc);
 
     (StateSummary { crons, claims }, acc)
 }
@@ -206,3 +207,23 @@ fn check_proofs_invariants(state: &State, acc: &MessageAccumulator) {
         acc.add("proof validation batch should be empty after FIP 0084");
     }
 }
+
+fn check_power_snapshots_invariants<BS: Blockstore>(
+    state: &State,
+    store: &BS,
+    acc: &MessageAccumulator,
+) {
+    match PowerSnapshotArray::load(&state.power_snapshots, store) {
+        Ok(snapshots) => {
+            let ret = snapshots.for_each(|index, snapshot| {
+                acc.require(
+                    power_snapshot_index(snapshot.epoch) == index,
+                    format!("power snapshot for epoch {} stored at index {index}", snapshot.epoch),
+                );
+                Ok(())
+            });
+            acc.require_no_error(ret, "error iterating power snapshots");
+        }
+        Err(e) => acc.add(format!("error loading power snapshots: {e}")),
+    }
+}
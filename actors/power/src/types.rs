@@ -40,6 +40,19 @@ pub struct CreateMinerReturn {
     pub robust_address: Address,
 }
 
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct BatchCreateMinerParams {
+    pub miners: Vec<CreateMinerParams>,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct BatchCreateMinerReturn {
+    /// Addresses of the created miners, in the order of the parameters.
+    pub miners: Vec<CreateMinerReturn>,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
 pub struct UpdateClaimedPowerParams {
     #[serde(with = "bigint_ser")]
@@ -77,6 +90,22 @@ pub struct NetworkRawPowerReturn {
     pub raw_byte_power: StoragePower,
 }
 
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+#[serde(transparent)]
+pub struct NetworkPowerAtParams {
+    pub epoch: ChainEpoch,
+}
+
+#[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
+pub struct NetworkPowerAtReturn {
+    /// Epoch at which the returned totals were recorded.
+    pub epoch: ChainEpoch,
+    #[serde(with = "bigint_ser")]
+    pub raw_byte_power: StoragePower,
+    #[serde(with = "bigint_ser")]
+    pub quality_adj_power: StoragePower,
+}
+
 #[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone, Eq, PartialEq)]
 #[serde(transparent)]
 pub struct MinerRawPowerParams {
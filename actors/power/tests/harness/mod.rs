@@ -27,8 +27,8 @@ use fil_actor_power::CRON_QUEUE_AMT_BITWIDTH;
 use fil_actor_power::CRON_QUEUE_HAMT_BITWIDTH;
 use fil_actor_power::{epoch_key, MinerCountReturn};
 use fil_actor_power::{
-    ext, Claim, CreateMinerParams, CreateMinerReturn, CurrentTotalPowerReturn, Method, State,
-    UpdateClaimedPowerParams,
+    ext, Claim, CreateMinerParams, CreateMinerReturn, CurrentTotalPowerReturn, Method,
+    NetworkPowerAtParams, NetworkPowerAtReturn, State, UpdateClaimedPowerParams,
 };
 use fil_actor_power::{CronEvent, MinerConsensusCountReturn};
 use fil_actors_runtime::builtin::reward::{FilterEstimate, ThisEpochRewardReturn};
@@ -301,6 +301,20 @@ impl Harness {
         ret
     }
 
+    pub fn network_power_at(
+        &self,
+        rt: &MockRuntime,
+        epoch: ChainEpoch,
+    ) -> Result<NetworkPowerAtReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        let ret = rt.call::<PowerActor>(
+            Method::NetworkPowerAtExported as u64,
+            IpldBlock::serialize_cbor(&NetworkPowerAtParams { epoch }).unwrap(),
+        );
+        rt.verify();
+        Ok(ret?.unwrap().deserialize().unwrap())
+    }
+
     pub fn update_claimed_power(
         &self,
         rt: &MockRuntime,
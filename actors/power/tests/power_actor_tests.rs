@@ -2,8 +2,8 @@ use fil_actor_power::ext::init::{ExecParams, EXEC_METHOD};
 use fil_actor_power::ext::miner::MinerConstructorParams;
 use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::test_utils::{
-    expect_abort, expect_abort_contains_message, ACCOUNT_ACTOR_CODE_ID, EVM_ACTOR_CODE_ID,
-    MINER_ACTOR_CODE_ID, SYSTEM_ACTOR_CODE_ID,
+    expect_abort, expect_abort_contains_message, MockRuntime, ACCOUNT_ACTOR_CODE_ID,
+    EVM_ACTOR_CODE_ID, MINER_ACTOR_CODE_ID, SYSTEM_ACTOR_CODE_ID,
 };
 use fil_actors_runtime::{runtime::Policy, INIT_ACTOR_ADDR};
 use fvm_ipld_encoding::{BytesDe, RawBytes};
@@ -18,9 +18,11 @@ use num_traits::Zero;
 use std::ops::Neg;
 
 use fil_actor_power::{
-    consensus_miner_min_power, Actor as PowerActor, Actor, CreateMinerParams, CreateMinerReturn,
-    EnrollCronEventParams, Method, MinerRawPowerParams, MinerRawPowerReturn, NetworkRawPowerReturn,
-    State, UpdateClaimedPowerParams, CONSENSUS_MINER_MIN_MINERS,
+    consensus_miner_min_power, Actor as PowerActor, Actor, BatchCreateMinerParams,
+    BatchCreateMinerReturn, CreateMinerParams, CreateMinerReturn, EnrollCronEventParams, Method,
+    MinerRawPowerParams, MinerRawPowerReturn, NetworkRawPowerReturn, State,
+    UpdateClaimedPowerParams, CONSENSUS_MINER_MIN_MINERS, MAX_MINERS_PER_BATCH_CREATE,
+    POWER_SNAPSHOT_EPOCHS,
 };
 
 use fvm_ipld_encoding::ipld_block::IpldBlock;
@@ -133,6 +135,177 @@ fn create_miner_given_send_to_init_actor_fails_should_fail() {
     h.check_state(&rt);
 }
 
+#[test]
+fn batch_create_miners() {
+    let (h, rt) = setup();
+    let miners = [
+        (Address::new_id(201), Address::new_actor(b"a1")),
+        (Address::new_id(202), Address::new_actor(b"a2")),
+    ];
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *OWNER);
+    rt.expect_validate_caller_any();
+    let mut create_params = vec![];
+    for (i, (id_address, robust_address)) in miners.iter().enumerate() {
+        let peer = format!("miner{}", i).into_bytes();
+        expect_exec_miner(
+            &rt,
+            &peer,
+            CreateMinerReturn { id_address: *id_address, robust_address: *robust_address },
+            ExitCode::OK,
+        );
+        create_params.push(new_create_miner_params(peer));
+    }
+
+    let ret: BatchCreateMinerReturn = rt
+        .call::<PowerActor>(
+            Method::BatchCreateMinerExported as u64,
+            IpldBlock::serialize_cbor(&BatchCreateMinerParams { miners: create_params }).unwrap(),
+        )
+        .unwrap()
+        .unwrap()
+        .deserialize()
+        .unwrap();
+    rt.verify();
+
+    let expected: Vec<_> = miners
+        .iter()
+        .map(|(id_address, robust_address)| CreateMinerReturn {
+            id_address: *id_address,
+            robust_address: *robust_address,
+        })
+        .collect();
+    assert_eq!(expected, ret.miners);
+    for (id_address, _) in &miners {
+        let claim = h.get_claim(&rt, id_address).unwrap();
+        assert_eq!(RegisteredPoStProof::StackedDRGWindow32GiBV1P1, claim.window_post_proof_type);
+    }
+    assert_eq!(2, h.miner_count(&rt));
+    h.check_state(&rt);
+}
+
+#[test]
+fn batch_create_miners_aborts_if_any_miner_fails() {
+    let (h, rt) = setup();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *OWNER);
+    rt.expect_validate_caller_any();
+    expect_exec_miner(
+        &rt,
+        b"miner0",
+        CreateMinerReturn { id_address: *MINER, robust_address: *ACTOR },
+        ExitCode::OK,
+    );
+    expect_exec_miner(
+        &rt,
+        b"miner1",
+        CreateMinerReturn { id_address: *MINER, robust_address: *ACTOR },
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+    );
+
+    let params = BatchCreateMinerParams {
+        miners: vec![
+            new_create_miner_params(b"miner0".to_vec()),
+            new_create_miner_params(b"miner1".to_vec()),
+        ],
+    };
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "failed to create miner at index 1",
+        rt.call::<PowerActor>(
+            Method::BatchCreateMinerExported as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        ),
+    );
+    rt.verify();
+
+    assert_eq!(0, h.miner_count(&rt));
+    assert!(h.list_miners(&rt).is_empty());
+    h.check_state(&rt);
+}
+
+#[test]
+fn batch_create_miners_rejects_value() {
+    let (h, rt) = setup();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *OWNER);
+    rt.set_received(TokenAmount::from_atto(10));
+    rt.set_balance(TokenAmount::from_atto(10));
+    rt.expect_validate_caller_any();
+
+    let params =
+        BatchCreateMinerParams { miners: vec![new_create_miner_params(b"miner0".to_vec())] };
+    expect_abort(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        rt.call::<PowerActor>(
+            Method::BatchCreateMinerExported as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        ),
+    );
+    rt.verify();
+    h.check_state(&rt);
+}
+
+#[test]
+fn batch_create_miners_rejects_too_many_miners() {
+    let (h, rt) = setup();
+
+    rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *OWNER);
+    rt.expect_validate_caller_any();
+
+    let params = BatchCreateMinerParams {
+        miners: (0..=MAX_MINERS_PER_BATCH_CREATE)
+            .map(|i| new_create_miner_params(format!("miner{}", i).into_bytes()))
+            .collect(),
+    };
+    expect_abort_contains_message(
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+        "too many miners to create",
+        rt.call::<PowerActor>(
+            Method::BatchCreateMinerExported as u64,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        ),
+    );
+    rt.verify();
+
+    assert_eq!(0, h.miner_count(&rt));
+    h.check_state(&rt);
+}
+
+fn new_create_miner_params(peer: Vec<u8>) -> CreateMinerParams {
+    CreateMinerParams {
+        owner: *OWNER,
+        worker: *OWNER,
+        window_post_proof_type: RegisteredPoStProof::StackedDRGWindow32GiBV1P1,
+        peer,
+        multiaddrs: vec![],
+    }
+}
+
+fn expect_exec_miner(rt: &MockRuntime, peer: &[u8], ret: CreateMinerReturn, exit_code: ExitCode) {
+    let message_params = ExecParams {
+        code_cid: *MINER_ACTOR_CODE_ID,
+        constructor_params: RawBytes::serialize(MinerConstructorParams {
+            owner: *OWNER,
+            worker: *OWNER,
+            window_post_proof_type: RegisteredPoStProof::StackedDRGWindow32GiBV1P1,
+            peer_id: peer.to_vec(),
+            multi_addresses: vec![],
+            control_addresses: Default::default(),
+        })
+        .unwrap(),
+    };
+    let ret = if exit_code.is_success() { IpldBlock::serialize_cbor(&ret).unwrap() } else { None };
+    rt.expect_send_simple(
+        INIT_ACTOR_ADDR,
+        EXEC_METHOD,
+        IpldBlock::serialize_cbor(&message_params).unwrap(),
+        TokenAmount::zero(),
+        ret,
+        exit_code,
+    );
+}
+
 #[test]
 fn claimed_power_given_caller_is_not_storage_miner_should_fail() {
     let (h, rt) = setup();
@@ -715,6 +888,66 @@ mod cron_tests {
         h.check_state(&rt);
     }
 
+    #[test]
+    fn network_power_snapshots_recorded_at_cron() {
+        let (mut h, rt) = setup();
+        let power_unit = consensus_miner_min_power(
+            &Policy::default(),
+            RegisteredPoStProof::StackedDRGWindow2KiBV1P1,
+        )
+        .unwrap();
+
+        let miners: Vec<Address> = (101..105).map(Address::new_id).collect();
+        for miner in &miners {
+            h.create_miner_basic(&rt, OWNER, OWNER, *miner).unwrap();
+            h.update_claimed_power(&rt, *miner, &power_unit, &power_unit);
+        }
+        let first_power: BigInt = &power_unit * 4u8;
+        h.on_epoch_tick_end(&rt, 1, &first_power);
+
+        // Epochs 2 and 3 are null rounds.
+        h.update_claimed_power(&rt, miners[0], &power_unit, &power_unit);
+        let second_power: BigInt = &power_unit * 5u8;
+        h.on_epoch_tick_end(&rt, 4, &second_power);
+        h.check_state(&rt);
+
+        let ret = h.network_power_at(&rt, 1).unwrap();
+        assert_eq!(1, ret.epoch);
+        assert_eq!(first_power, ret.raw_byte_power);
+        assert_eq!(first_power, ret.quality_adj_power);
+
+        // A null round returns the closest earlier snapshot.
+        let ret = h.network_power_at(&rt, 3).unwrap();
+        assert_eq!(1, ret.epoch);
+        assert_eq!(first_power, ret.raw_byte_power);
+
+        let ret = h.network_power_at(&rt, 4).unwrap();
+        assert_eq!(4, ret.epoch);
+        assert_eq!(second_power, ret.raw_byte_power);
+        assert_eq!(second_power, ret.quality_adj_power);
+
+        expect_abort(ExitCode::USR_NOT_FOUND, h.network_power_at(&rt, 0));
+        rt.reset();
+        expect_abort(ExitCode::USR_ILLEGAL_ARGUMENT, h.network_power_at(&rt, 5));
+        rt.reset();
+
+        // Snapshots older than the retention window are dropped.
+        rt.set_epoch(1 + POWER_SNAPSHOT_EPOCHS);
+        expect_abort(ExitCode::USR_NOT_FOUND, h.network_power_at(&rt, 1));
+        rt.reset();
+        assert_eq!(4, h.network_power_at(&rt, 4).unwrap().epoch);
+
+        // A new snapshot overwrites the slot of the snapshot taken one window earlier.
+        h.on_epoch_tick_end(&rt, 4 + POWER_SNAPSHOT_EPOCHS, &second_power);
+        assert_eq!(
+            4 + POWER_SNAPSHOT_EPOCHS,
+            h.network_power_at(&rt, 4 + POWER_SNAPSHOT_EPOCHS).unwrap().epoch
+        );
+        expect_abort(ExitCode::USR_NOT_FOUND, h.network_power_at(&rt, 4));
+        rt.reset();
+        h.check_state(&rt);
+    }
+
     #[test]
     fn event_scheduled_in_null_round_called_next_round() {
         let (mut h, rt) = setup();
@@ -44,6 +44,7 @@ pub enum Method {
     AwardBlockReward = 2,
     ThisEpochReward = 3,
     UpdateNetworkKPI = 4,
+    ThisEpochRewardViewExported = frc42_dispatch::method_hash!("ThisEpochRewardView"),
 }
 
 /// Reward Actor
@@ -185,6 +186,20 @@ impl Actor {
         })
     }
 
+    /// Returns the full set of reward parameters for the current epoch, including the
+    /// smoothed reward estimate, as a stable structure independent of the state layout.
+    fn this_epoch_reward_view(rt: &impl Runtime) -> Result<ThisEpochRewardViewReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let st: State = rt.state()?;
+        Ok(ThisEpochRewardViewReturn {
+            this_epoch_reward: st.this_epoch_reward,
+            this_epoch_reward_smoothed: st.this_epoch_reward_smoothed,
+            this_epoch_baseline_power: st.this_epoch_baseline_power,
+            total_storage_power_reward: st.total_storage_power_reward,
+            effective_network_time: st.effective_network_time,
+        })
+    }
+
     /// Called at the end of each epoch by the power actor (in turn by its cron hook).
     /// This is only invoked for non-empty tipsets, but catches up any number of null
     /// epochs to compute the next epoch reward.
@@ -227,5 +242,6 @@ impl ActorCode for Actor {
         AwardBlockReward => award_block_reward,
         ThisEpochReward => this_epoch_reward,
         UpdateNetworkKPI => update_network_kpi,
+        ThisEpochRewardViewExported => this_epoch_reward_view,
     }
 }
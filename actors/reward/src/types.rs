@@ -1,10 +1,13 @@
 // Copyright 2019-2022 ChainSafe Systems
 // SPDX-License-Identifier: Apache-2.0, MIT
 
+use fil_actors_runtime::builtin::reward::FilterEstimate;
 use fvm_ipld_encoding::tuple::*;
 use fvm_shared::address::Address;
-use fvm_shared::bigint::bigint_ser::BigIntDe;
+use fvm_shared::bigint::bigint_ser::{self, BigIntDe};
+use fvm_shared::clock::ChainEpoch;
 use fvm_shared::econ::TokenAmount;
+use fvm_shared::sector::StoragePower;
 
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 #[serde(transparent)]
@@ -22,6 +25,18 @@ pub struct AwardBlockRewardParams {
 
 pub use fil_actors_runtime::builtin::reward::ThisEpochRewardReturn;
 
+/// A stable view of the reward actor's current epoch reward parameters.
+/// The estimate is serialized as the `FilterEstimate` tuple used by the smoothing functions.
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ThisEpochRewardViewReturn {
+    pub this_epoch_reward: TokenAmount,
+    pub this_epoch_reward_smoothed: FilterEstimate,
+    #[serde(with = "bigint_ser")]
+    pub this_epoch_baseline_power: StoragePower,
+    pub total_storage_power_reward: TokenAmount,
+    pub effective_network_time: ChainEpoch,
+}
+
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 #[serde(transparent)]
 pub struct UpdateNetworkKPIParams {
@@ -5,7 +5,7 @@ use std::cell::RefCell;
 
 use fil_actor_reward::{
     ext, Actor as RewardActor, AwardBlockRewardParams, Method, State, ThisEpochRewardReturn,
-    BASELINE_INITIAL_VALUE, PENALTY_MULTIPLIER,
+    ThisEpochRewardViewReturn, BASELINE_INITIAL_VALUE, PENALTY_MULTIPLIER,
 };
 use fil_actors_runtime::test_utils::*;
 use fil_actors_runtime::EXPECTED_LEADERS_PER_EPOCH;
@@ -312,6 +312,27 @@ mod test_this_epoch_reward {
         assert_eq!(state.this_epoch_baseline_power, resp.this_epoch_baseline_power);
         assert_eq!(state.this_epoch_reward_smoothed, resp.this_epoch_reward_smoothed);
     }
+
+    #[test]
+    fn view_reflects_state_after_kpi_update() {
+        let power = StoragePower::from(1);
+        let rt = construct_and_verify(&power);
+        rt.epoch.replace(1);
+        update_network_kpi(&rt, &power);
+
+        let state: State = rt.get_state();
+        let resp: ThisEpochRewardViewReturn = this_epoch_reward_view(&rt);
+
+        assert_eq!(state.this_epoch_reward, resp.this_epoch_reward);
+        assert_eq!(state.this_epoch_reward_smoothed, resp.this_epoch_reward_smoothed);
+        assert_eq!(state.this_epoch_baseline_power, resp.this_epoch_baseline_power);
+        assert_eq!(state.total_storage_power_reward, resp.total_storage_power_reward);
+        assert_eq!(state.effective_network_time, resp.effective_network_time);
+
+        // The smoothed estimate agrees with the narrower ThisEpochReward method.
+        let narrow = this_epoch_reward(&rt);
+        assert_eq!(narrow.this_epoch_reward_smoothed, resp.this_epoch_reward_smoothed);
+    }
 }
 
 #[test]
@@ -402,6 +423,15 @@ fn this_epoch_reward(rt: &MockRuntime) -> ThisEpochRewardReturn {
     resp
 }
 
+fn this_epoch_reward_view(rt: &MockRuntime) -> ThisEpochRewardViewReturn {
+    rt.expect_validate_caller_any();
+    let serialized_result =
+        rt.call::<RewardActor>(Method::ThisEpochRewardViewExported as u64, None).unwrap();
+    let resp: ThisEpochRewardViewReturn = serialized_result.unwrap().deserialize().unwrap();
+    rt.verify();
+    resp
+}
+
 fn update_network_kpi(rt: &MockRuntime, curr_raw_power: &StoragePower) {
     rt.set_caller(*POWER_ACTOR_CODE_ID, STORAGE_POWER_ACTOR_ADDR);
     rt.expect_validate_caller_addr(vec![STORAGE_POWER_ACTOR_ADDR]);
@@ -21,6 +21,8 @@ fil_actors_runtime::wasm_trampoline!(Actor);
 #[repr(u64)]
 pub enum Method {
     Constructor = METHOD_CONSTRUCTOR,
+    // Method numbers derived from FRC-0042 standards
+    GetBuiltinActorCodeCidsExported = frc42_dispatch::method_hash!("GetBuiltinActorCodeCids"),
 }
 
 /// System actor state.
@@ -50,6 +52,13 @@ impl State {
     }
 }
 
+#[derive(Deserialize_tuple, Serialize_tuple, Debug, Clone, PartialEq, Eq)]
+#[serde(transparent)]
+pub struct GetBuiltinActorCodeCidsReturn {
+    /// Builtin actor names and code CIDs, in manifest order.
+    pub builtin_actors: Vec<(String, Cid)>,
+}
+
 /// System actor.
 pub struct Actor;
 
@@ -62,6 +71,18 @@ impl Actor {
         rt.create(&state)?;
         Ok(())
     }
+
+    /// Returns the builtin actor registry of the current network version.
+    pub fn get_builtin_actor_code_cids(
+        rt: &impl Runtime,
+    ) -> Result<GetBuiltinActorCodeCidsReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let state: State = rt.state()?;
+        let builtin_actors = state
+            .get_builtin_actors(rt.store())
+            .map_err(|e| actor_error!(illegal_state, "failed to load builtin actors: {}", e))?;
+        Ok(GetBuiltinActorCodeCidsReturn { builtin_actors })
+    }
 }
 
 impl ActorCode for Actor {
@@ -73,6 +94,7 @@ impl ActorCode for Actor {
 
     actor_dispatch! {
         Constructor => constructor,
+        GetBuiltinActorCodeCidsExported => get_builtin_actor_code_cids,
     }
 }
 
@@ -80,12 +102,17 @@ impl ActorCode for Actor {
 mod tests {
     use std::cell::RefCell;
 
+    use cid::multihash;
+    use fvm_ipld_encoding::CborStore;
+    use fvm_shared::address::Address;
     use fvm_shared::MethodNum;
 
-    use fil_actors_runtime::test_utils::{MockRuntime, SYSTEM_ACTOR_CODE_ID};
+    use fil_actors_runtime::test_utils::{
+        MockRuntime, ACCOUNT_ACTOR_CODE_ID, EVM_ACTOR_CODE_ID, SYSTEM_ACTOR_CODE_ID,
+    };
     use fil_actors_runtime::SYSTEM_ACTOR_ADDR;
 
-    use crate::{Actor, Method, State};
+    use crate::{Actor, GetBuiltinActorCodeCidsReturn, Method, State};
 
     pub fn new_runtime() -> MockRuntime {
         MockRuntime {
@@ -107,4 +134,31 @@ mod tests {
         let builtin_actors = state.get_builtin_actors(&rt.store).unwrap();
         assert!(builtin_actors.is_empty());
     }
+
+    #[test]
+    fn get_builtin_actor_code_cids() {
+        let rt = new_runtime();
+        rt.expect_validate_caller_addr(vec![SYSTEM_ACTOR_ADDR]);
+        rt.call::<Actor>(Method::Constructor as MethodNum, None).unwrap();
+
+        let builtin_actors = vec![
+            ("account".to_string(), *ACCOUNT_ACTOR_CODE_ID),
+            ("evm".to_string(), *EVM_ACTOR_CODE_ID),
+        ];
+        let mut state: State = rt.get_state();
+        state.builtin_actors =
+            rt.store.put_cbor(&builtin_actors, multihash::Code::Blake2b256).unwrap();
+        rt.replace_state(&state);
+
+        rt.set_caller(*EVM_ACTOR_CODE_ID, Address::new_id(1000));
+        rt.expect_validate_caller_any();
+        let ret: GetBuiltinActorCodeCidsReturn = rt
+            .call::<Actor>(Method::GetBuiltinActorCodeCidsExported as MethodNum, None)
+            .unwrap()
+            .unwrap()
+            .deserialize()
+            .unwrap();
+        rt.verify();
+        assert_eq!(builtin_actors, ret.builtin_actors);
+    }
 }
@@ -23,9 +23,9 @@ use fil_actors_runtime::cbor::deserialize;
 use fil_actors_runtime::runtime::builtins::Type;
 use fil_actors_runtime::runtime::{ActorCode, Policy, Runtime};
 use fil_actors_runtime::{
-    actor_dispatch, actor_error, deserialize_block, extract_send_result, resolve_to_actor_id,
-    ActorError, BatchReturn, DATACAP_TOKEN_ACTOR_ADDR, STORAGE_MARKET_ACTOR_ADDR,
-    SYSTEM_ACTOR_ADDR, VERIFIED_REGISTRY_ACTOR_ADDR,
+    actor_dispatch, actor_error, deserialize_block, extract_send_result, parse_uint_key,
+    resolve_to_actor_id, ActorError, BatchReturn, MapMap, DATACAP_TOKEN_ACTOR_ADDR,
+    STORAGE_MARKET_ACTOR_ADDR, SYSTEM_ACTOR_ADDR, VERIFIED_REGISTRY_ACTOR_ADDR,
 };
 use fil_actors_runtime::{ActorContext, AsActorError, BatchReturnGen};
 
@@ -36,6 +36,7 @@ use crate::state::{
 
 pub use self::state::Allocation;
 pub use self::state::Claim;
+pub use self::state::ClientTermDefaults;
 pub use self::state::State;
 pub use self::types::*;
 
@@ -66,12 +67,24 @@ pub enum Method {
     GetClaims = 10,
     ExtendClaimTerms = 11,
     RemoveExpiredClaims = 12,
+    ExtendClaimTermsExt = 13,
+    RemoveExpiredClaimsBatch = 14,
+    SetClientDefaults = 15,
+    ListClaims = 16,
+    DelegateAllowance = 17,
+    GetAllocations = 18,
     // Method numbers derived from FRC-0042 standards
     AddVerifiedClientExported = frc42_dispatch::method_hash!("AddVerifiedClient"),
     RemoveExpiredAllocationsExported = frc42_dispatch::method_hash!("RemoveExpiredAllocations"),
     GetClaimsExported = frc42_dispatch::method_hash!("GetClaims"),
     ExtendClaimTermsExported = frc42_dispatch::method_hash!("ExtendClaimTerms"),
     RemoveExpiredClaimsExported = frc42_dispatch::method_hash!("RemoveExpiredClaims"),
+    ExtendClaimTermsExtExported = frc42_dispatch::method_hash!("ExtendClaimTermsExt"),
+    RemoveExpiredClaimsBatchExported = frc42_dispatch::method_hash!("RemoveExpiredClaimsBatch"),
+    SetClientDefaultsExported = frc42_dispatch::method_hash!("SetClientDefaults"),
+    ListClaimsExported = frc42_dispatch::method_hash!("ListClaims"),
+    DelegateAllowanceExported = frc42_dispatch::method_hash!("DelegateAllowance"),
+    GetAllocationsExported = frc42_dispatch::method_hash!("GetAllocations"),
     UniversalReceiverHook = frc42_dispatch::method_hash!("Receive"),
 }
 
@@ -126,9 +139,12 @@ impl Actor {
         }
 
         // Store the new verifier and allowance (over-writing).
+        // A verifier added by the root key is no longer considered a delegate.
         rt.transaction(|st: &mut State, rt| {
             st.put_verifier(rt.store(), &verifier_addr, &params.allowance)
-                .context("failed to add verifier")
+                .context("failed to add verifier")?;
+            st.set_verifier_delegator(rt.store(), verifier, None)
+                .context("failed to clear verifier delegator")
         })?;
 
         emit::verifier_balance(rt, verifier, &params.allowance, None)
@@ -143,12 +159,114 @@ impl Actor {
 
         rt.transaction(|st: &mut State, rt| {
             rt.validate_immediate_caller_is(std::iter::once(&st.root_key))?;
-            st.remove_verifier(rt.store(), &verifier_addr).context("failed to remove verifier")
+
+            // Removing a verifier must not strand allowance it has delegated.
+            let delegates = st.get_verifier_delegates(rt.store(), verifier)?;
+            if !delegates.is_empty() {
+                return Err(actor_error!(
+                    forbidden,
+                    "verifier {} has delegated allowance to verifiers {:?}, which must be removed first",
+                    verifier_addr,
+                    delegates
+                ));
+            }
+            st.remove_verifier(rt.store(), &verifier_addr).context("failed to remove verifier")?;
+            st.set_verifier_delegator(rt.store(), verifier, None)
+                .context("failed to clear verifier delegator")
         })?;
 
         emit::verifier_balance(rt, verifier, &DataCap::zero(), None)
     }
 
+    /// Transfers part of the calling verifier's allowance to a delegate verifier, adding the
+    /// delegate if it is not already a verifier. The delegate may grant DataCap to clients,
+    /// or delegate further, only from the allowance it holds.
+    /// A verifier with delegates cannot be removed until its delegates have been removed.
+    pub fn delegate_allowance(
+        rt: &impl Runtime,
+        params: DelegateAllowanceParams,
+    ) -> Result<(), ActorError> {
+        // The caller will be verified by checking the verifiers table.
+        rt.validate_immediate_caller_accept_any()?;
+
+        if params.allowance < rt.policy().minimum_verified_allocation_size {
+            return Err(actor_error!(
+                illegal_argument,
+                "allowance {} below minimum deal size for delegate {}",
+                params.allowance,
+                params.address
+            ));
+        }
+
+        let verifier_addr = rt.message().caller();
+        let verifier = verifier_addr.id().unwrap();
+        let delegate = resolve_to_actor_id(rt, &params.address, true)?;
+        let delegate_addr = Address::new_id(delegate);
+        if delegate == verifier {
+            return Err(actor_error!(illegal_argument, "verifier cannot delegate to itself"));
+        }
+
+        let st: State = rt.state()?;
+        if delegate_addr == st.root_key {
+            return Err(actor_error!(illegal_argument, "root key cannot be a delegate"));
+        }
+        // Disallow existing clients as delegates.
+        if st.get_verifier_cap(rt.store(), &delegate_addr)?.is_none() {
+            let token_balance = balance(rt, &delegate_addr)?;
+            if token_balance.is_positive() {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "verified client {} cannot become a verifier",
+                    delegate_addr
+                ));
+            }
+        }
+
+        let (new_verifier_cap, new_delegate_cap) = rt.transaction(|st: &mut State, rt| {
+            let verifier_cap =
+                st.get_verifier_cap(rt.store(), &verifier_addr)?.ok_or_else(|| {
+                    actor_error!(not_found, "caller {} is not a verifier", verifier_addr)
+                })?;
+            if verifier_cap < params.allowance {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cannot delegate DataCap {} exceeding allowance {}",
+                    params.allowance,
+                    verifier_cap
+                ));
+            }
+
+            // An existing verifier can only be topped up by the verifier that delegated to it.
+            let delegate_cap = match st.get_verifier_cap(rt.store(), &delegate_addr)? {
+                Some(cap) => {
+                    if st.get_verifier_delegator(rt.store(), delegate)? != Some(verifier) {
+                        return Err(actor_error!(
+                            illegal_argument,
+                            "verifier {} is not a delegate of {}",
+                            delegate_addr,
+                            verifier_addr
+                        ));
+                    }
+                    cap
+                }
+                None => DataCap::zero(),
+            };
+
+            let new_verifier_cap = verifier_cap - &params.allowance;
+            let new_delegate_cap = delegate_cap + &params.allowance;
+            st.put_verifier(rt.store(), &verifier_addr, &new_verifier_cap)
+                .context("failed to update verifier allowance")?;
+            st.put_verifier(rt.store(), &delegate_addr, &new_delegate_cap)
+                .context("failed to update delegate allowance")?;
+            st.set_verifier_delegator(rt.store(), delegate, Some(verifier))
+                .context("failed to record verifier delegator")?;
+            Ok((new_verifier_cap, new_delegate_cap))
+        })?;
+
+        emit::verifier_balance(rt, verifier, &new_verifier_cap, None)?;
+        emit::verifier_balance(rt, delegate, &new_delegate_cap, None)
+    }
+
     pub fn add_verified_client(
         rt: &impl Runtime,
         params: AddVerifiedClientParams,
@@ -515,6 +633,108 @@ impl Actor {
         Ok(GetClaimsReturn { batch_info: batch_gen.gen(), claims })
     }
 
+    /// Returns a page of a provider's claims.
+    /// Claims are returned in a deterministic order, which is not sorted by claim ID.
+    /// The returned cursor remains valid for fetching the next page as long as no claims
+    /// are added or removed for the provider.
+    pub fn list_claims(
+        rt: &impl Runtime,
+        params: ListClaimsParams,
+    ) -> Result<ListClaimsReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let limit = params.limit.unwrap_or(LIST_CLAIMS_MAX_LIMIT);
+        if limit == 0 || limit > LIST_CLAIMS_MAX_LIMIT {
+            return Err(actor_error!(
+                illegal_argument,
+                "limit {} must be between 1 and {}",
+                limit,
+                LIST_CLAIMS_MAX_LIMIT
+            ));
+        }
+
+        let st: State = rt.state()?;
+        let mut st_claims = st.load_claims(rt.store())?;
+        if let Some(cursor) = params.cursor {
+            if state::get_claim(&mut st_claims, params.provider, cursor)?.is_none() {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cursor {} is not a claim of provider {}",
+                    cursor,
+                    params.provider
+                ));
+            }
+        }
+        let mut claims = Vec::new();
+        let (_, next) = st_claims
+            .for_each_in_ranged(params.provider, params.cursor, Some(limit as usize), |k, claim| {
+                let id = parse_uint_key(k)
+                    .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to parse uint key")?;
+                claims.push((id, claim.clone()));
+                Ok(())
+            })
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to iterate claims")?;
+        let next_cursor = next
+            .map(|k| parse_uint_key(&k))
+            .transpose()
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to parse uint key")?;
+
+        Ok(ListClaimsReturn { claims, next_cursor })
+    }
+
+    /// Lists a page of a client's outstanding allocations.
+    /// Allocations are returned in a deterministic order, which is not sorted by allocation ID.
+    /// The returned cursor remains valid for fetching the next page as long as no allocations
+    /// are added or removed for the client.
+    /// Allocations past their expiration are included and flagged as expired.
+    pub fn get_allocations(
+        rt: &impl Runtime,
+        params: GetAllocationsParams,
+    ) -> Result<GetAllocationsReturn, ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let limit = params.limit.unwrap_or(GET_ALLOCATIONS_MAX_LIMIT);
+        if limit == 0 || limit > GET_ALLOCATIONS_MAX_LIMIT {
+            return Err(actor_error!(
+                illegal_argument,
+                "limit {} must be between 1 and {}",
+                limit,
+                GET_ALLOCATIONS_MAX_LIMIT
+            ));
+        }
+
+        let curr_epoch = rt.curr_epoch();
+        let st: State = rt.state()?;
+        let mut st_allocs = st.load_allocs(rt.store())?;
+        if let Some(cursor) = params.cursor {
+            if state::get_allocation(&mut st_allocs, params.client, cursor)?.is_none() {
+                return Err(actor_error!(
+                    illegal_argument,
+                    "cursor {} is not an allocation of client {}",
+                    cursor,
+                    params.client
+                ));
+            }
+        }
+        let mut allocations = Vec::new();
+        let (_, next) = st_allocs
+            .for_each_in_ranged(params.client, params.cursor, Some(limit as usize), |k, alloc| {
+                let id = parse_uint_key(k)
+                    .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to parse uint key")?;
+                allocations.push(ClientAllocation {
+                    id,
+                    allocation: alloc.clone(),
+                    expired: curr_epoch >= alloc.expiration,
+                });
+                Ok(())
+            })
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to iterate allocations")?;
+        let next_cursor = next
+            .map(|k| parse_uint_key(&k))
+            .transpose()
+            .context_code(ExitCode::USR_ILLEGAL_STATE, "failed to parse uint key")?;
+
+        Ok(GetAllocationsReturn { allocations, next_cursor })
+    }
+
     /// Extends the maximum term of some claims up to the largest value they could have been
     /// originally allocated.
     /// Callable only by the claims' client.
@@ -528,60 +748,20 @@ impl Actor {
     ) -> Result<ExtendClaimTermsReturn, ActorError> {
         // Permissions are checked per-claim.
         rt.validate_immediate_caller_accept_any()?;
-        let caller_id = rt.message().caller().id().unwrap();
-        let term_limit = rt.policy().maximum_verified_allocation_term;
-        let mut batch_gen = BatchReturnGen::new(params.terms.len());
-        rt.transaction(|st: &mut State, rt| {
-            let mut st_claims = st.load_claims(rt.store())?;
-            for term in params.terms {
-                // Confirm the new term limit is allowed.
-                if term.term_max > term_limit {
-                    batch_gen.add_fail(ExitCode::USR_ILLEGAL_ARGUMENT);
-                    info!(
-                        "term_max {} for claim {} exceeds maximum {}",
-                        term.term_max, term.claim_id, term_limit,
-                    );
-                    continue;
-                }
-
-                let maybe_claim = state::get_claim(&mut st_claims, term.provider, term.claim_id)?;
-                if let Some(claim) = maybe_claim {
-                    // Confirm the caller is the claim's client.
-                    if claim.client != caller_id {
-                        batch_gen.add_fail(ExitCode::USR_FORBIDDEN);
-                        info!(
-                            "client {} for claim {} does not match caller {}",
-                            claim.client, term.claim_id, caller_id,
-                        );
-                        continue;
-                    }
-                    // Confirm the new term limit is no less than the old one.
-                    if term.term_max < claim.term_max {
-                        batch_gen.add_fail(ExitCode::USR_ILLEGAL_ARGUMENT);
-                        info!(
-                            "term_max {} for claim {} is less than current {}",
-                            term.term_max, term.claim_id, claim.term_max,
-                        );
-                        continue;
-                    }
+        extend_claims(rt, params.terms, false)
+    }
 
-                    let new_claim = Claim { term_max: term.term_max, ..*claim };
-                    st_claims.put(term.provider, term.claim_id, new_claim.clone()).context_code(
-                        ExitCode::USR_ILLEGAL_STATE,
-                        "HAMT put failure storing new claims",
-                    )?;
-                    batch_gen.add_success();
-                    emit::claim_updated(rt, term.claim_id, &new_claim)?;
-                } else {
-                    batch_gen.add_fail(ExitCode::USR_NOT_FOUND);
-                    info!("no claim {} for provider {}", term.claim_id, term.provider);
-                }
-            }
-            st.save_claims(&mut st_claims)?;
-            Ok(())
-        })
-        .context("state transaction failed")?;
-        Ok(batch_gen.gen())
+    /// Extends the maximum term of some claims, as for ExtendClaimTerms.
+    /// If fail_fast is set, the whole call aborts with the exit code of the first
+    /// entry that cannot be extended. Otherwise, successful extensions are committed
+    /// and failures are reported in the returned batch result.
+    pub fn extend_claim_terms_ext(
+        rt: &impl Runtime,
+        params: ExtendClaimTermsExtParams,
+    ) -> Result<ExtendClaimTermsReturn, ActorError> {
+        // Permissions are checked per-claim.
+        rt.validate_immediate_caller_accept_any()?;
+        extend_claims(rt, params.terms, params.fail_fast)
     }
 
     // A claim may be removed after its maximum term has elapsed (by anyone).
@@ -592,46 +772,60 @@ impl Actor {
     ) -> Result<RemoveExpiredClaimsReturn, ActorError> {
         // Since the claims are expired, this is safe to be called by anyone.
         rt.validate_immediate_caller_accept_any()?;
-        let curr_epoch = rt.curr_epoch();
-        let mut batch_ret = BatchReturn::empty();
-        let mut considered = Vec::<ClaimID>::new();
         rt.transaction(|st: &mut State, rt| {
             let mut claims = st.load_claims(rt.store())?;
-            let to_remove: Vec<&ClaimID>;
-            if params.claim_ids.is_empty() {
-                // Find all expired claims for the provider.
-                considered = expiration::find_expired(&mut claims, params.provider, curr_epoch)?;
-                batch_ret = BatchReturn::ok(considered.len() as u32);
-                to_remove = considered.iter().collect();
-            } else {
-                considered = params.claim_ids.clone();
-                batch_ret = expiration::check_expired(
-                    &mut claims,
-                    &params.claim_ids,
-                    params.provider,
-                    curr_epoch,
-                )?;
-                to_remove = batch_ret.successes(&params.claim_ids);
-            }
-
-            for id in to_remove {
-                let removed = claims
-                    .remove(params.provider, *id)
-                    .context_code(
-                        ExitCode::USR_ILLEGAL_STATE,
-                        format!("failed to remove claim {}", id),
-                    )?
-                    .unwrap();
-
-                emit::claim_removed(rt, *id, &removed)?;
-            }
+            let ret =
+                remove_expired_provider_claims(rt, &mut claims, params.provider, params.claim_ids)?;
+            st.save_claims(&mut claims)?;
+            Ok(ret)
+        })
+        .context("state transaction failed")
+    }
 
+    // Removes expired claims for a batch of providers (by anyone).
+    // Each entry is processed as for RemoveExpiredClaims, returning a result for each entry.
+    pub fn remove_expired_claims_batch(
+        rt: &impl Runtime,
+        params: RemoveExpiredClaimsBatchParams,
+    ) -> Result<RemoveExpiredClaimsBatchReturn, ActorError> {
+        // Since the claims are expired, this is safe to be called by anyone.
+        rt.validate_immediate_caller_accept_any()?;
+        rt.transaction(|st: &mut State, rt| {
+            let mut claims = st.load_claims(rt.store())?;
+            let results = params
+                .entries
+                .into_iter()
+                .map(|entry| {
+                    remove_expired_provider_claims(rt, &mut claims, entry.provider, entry.claim_ids)
+                })
+                .collect::<Result<Vec<_>, _>>()?;
             st.save_claims(&mut claims)?;
-            Ok(())
+            Ok(RemoveExpiredClaimsBatchReturn { results })
         })
-        .context("state transaction failed")?;
+        .context("state transaction failed")
+    }
 
-        Ok(RemoveExpiredClaimsReturn { considered, results: batch_ret })
+    // Sets the default term_min and term_max applied to the calling client's future
+    // allocation requests that leave those terms as USE_CLIENT_DEFAULT_TERM.
+    // Setting both terms to USE_CLIENT_DEFAULT_TERM removes the client's defaults.
+    pub fn set_client_defaults(
+        rt: &impl Runtime,
+        params: SetClientDefaultsParams,
+    ) -> Result<(), ActorError> {
+        rt.validate_immediate_caller_accept_any()?;
+        let client = rt.message().caller().id().unwrap();
+
+        let terms = if params.term_min == USE_CLIENT_DEFAULT_TERM
+            && params.term_max == USE_CLIENT_DEFAULT_TERM
+        {
+            None
+        } else {
+            validate_terms(params.term_min, params.term_max, rt.policy())?;
+            Some(ClientTermDefaults { term_min: params.term_min, term_max: params.term_max })
+        };
+
+        rt.transaction(|st: &mut State, rt| st.set_client_term_defaults(rt.store(), client, terms))
+            .context("state transaction failed")
     }
 
     // Receives data cap tokens (only) and creates allocations according to one or more
@@ -659,9 +853,12 @@ impl Actor {
             deserialize(&tokens_received.operator_data, "allocation requests")?;
         let mut datacap_total = DataCap::zero();
 
-        // Construct new allocation records.
+        // Construct new allocation records, filling unspecified terms from the client's defaults.
+        let st: State = rt.state()?;
+        let term_defaults = st.get_client_term_defaults(rt.store(), client)?;
         let mut new_allocs = Vec::with_capacity(reqs.allocations.len());
         for req in &reqs.allocations {
+            let req = &apply_client_term_defaults(req, term_defaults.as_ref());
             validate_new_allocation(req, rt.policy(), curr_epoch)?;
             // Require the provider for new allocations to be a miner actor.
             // This doesn't matter much, but is more ergonomic to fail rather than lock up datacap.
@@ -678,7 +875,6 @@ impl Actor {
             datacap_total += DataCap::from(req.size.0);
         }
 
-        let st: State = rt.state()?;
         let mut claims = st.load_claims(rt.store())?;
         let mut updated_claims = Vec::<(ClaimID, Claim)>::new();
         let mut extension_total = DataCap::zero();
@@ -938,47 +1134,74 @@ fn validate_tokens_received(
 }
 
 // Validates an allocation request.
-fn validate_new_allocation(
-    req: &AllocationRequest,
+fn validate_terms(
+    term_min: ChainEpoch,
+    term_max: ChainEpoch,
     policy: &Policy,
-    curr_epoch: ChainEpoch,
 ) -> Result<(), ActorError> {
-    // Size must be at least the policy minimum.
-    if DataCap::from(req.size.0) < policy.minimum_verified_allocation_size {
-        return Err(actor_error!(
-            illegal_argument,
-            "allocation size {} below minimum {}",
-            req.size.0,
-            policy.minimum_verified_allocation_size
-        ));
-    }
     // Term must be at least the policy minimum.
-    if req.term_min < policy.minimum_verified_allocation_term {
+    if term_min < policy.minimum_verified_allocation_term {
         return Err(actor_error!(
             illegal_argument,
             "allocation term min {} below limit {}",
-            req.term_min,
+            term_min,
             policy.minimum_verified_allocation_term
         ));
     }
     // Term cannot exceed the policy maximum.
-    if req.term_max > policy.maximum_verified_allocation_term {
+    if term_max > policy.maximum_verified_allocation_term {
         return Err(actor_error!(
             illegal_argument,
             "allocation term max {} above limit {}",
-            req.term_max,
+            term_max,
             policy.maximum_verified_allocation_term
         ));
     }
     // Term range must be non-empty.
-    if req.term_min > req.term_max {
+    if term_min > term_max {
         return Err(actor_error!(
             illegal_argument,
             "allocation term min {} exceeds term max {}",
-            req.term_min,
-            req.term_max
+            term_min,
+            term_max
         ));
     }
+    Ok(())
+}
+
+// Replaces any unspecified terms of an allocation request with the client's defaults.
+// Terms specified explicitly in the request are kept.
+fn apply_client_term_defaults(
+    req: &AllocationRequest,
+    defaults: Option<&ClientTermDefaults>,
+) -> AllocationRequest {
+    let mut req = req.clone();
+    if let Some(defaults) = defaults {
+        if req.term_min == USE_CLIENT_DEFAULT_TERM {
+            req.term_min = defaults.term_min;
+        }
+        if req.term_max == USE_CLIENT_DEFAULT_TERM {
+            req.term_max = defaults.term_max;
+        }
+    }
+    req
+}
+
+fn validate_new_allocation(
+    req: &AllocationRequest,
+    policy: &Policy,
+    curr_epoch: ChainEpoch,
+) -> Result<(), ActorError> {
+    // Size must be at least the policy minimum.
+    if DataCap::from(req.size.0) < policy.minimum_verified_allocation_size {
+        return Err(actor_error!(
+            illegal_argument,
+            "allocation size {} below minimum {}",
+            req.size.0,
+            policy.minimum_verified_allocation_size
+        ));
+    }
+    validate_terms(req.term_min, req.term_max, policy)?;
 
     // Allocation must expire in the future.
     if req.expiration < curr_epoch {
@@ -1091,6 +1314,120 @@ fn can_claim_alloc(
         && sector_lifetime <= alloc.term_max
 }
 
+// Removes the specified claims of a provider that have expired.
+// If no claims are specified, all of the provider's expired claims are removed.
+fn remove_expired_provider_claims<BS: Blockstore>(
+    rt: &impl Runtime,
+    claims: &mut MapMap<BS, Claim, ActorID, ClaimID>,
+    provider: ActorID,
+    claim_ids: Vec<ClaimID>,
+) -> Result<RemoveExpiredClaimsReturn, ActorError> {
+    let curr_epoch = rt.curr_epoch();
+    let (considered, results) = if claim_ids.is_empty() {
+        // Find all expired claims for the provider.
+        let considered = expiration::find_expired(claims, provider, curr_epoch)?;
+        let results = BatchReturn::ok(considered.len() as u32);
+        (considered, results)
+    } else {
+        let results = expiration::check_expired(claims, &claim_ids, provider, curr_epoch)?;
+        (claim_ids, results)
+    };
+
+    for id in results.successes(&considered) {
+        let removed = claims
+            .remove(provider, *id)
+            .context_code(ExitCode::USR_ILLEGAL_STATE, format!("failed to remove claim {}", id))?
+            .unwrap();
+
+        emit::claim_removed(rt, *id, &removed)?;
+    }
+    Ok(RemoveExpiredClaimsReturn { considered, results })
+}
+
+// Extends the maximum term of the claims on behalf of the calling client.
+// Failed entries are recorded in the batch result, unless fail_fast is set,
+// in which case the first failure aborts.
+fn extend_claims(
+    rt: &impl Runtime,
+    terms: Vec<ClaimTerm>,
+    fail_fast: bool,
+) -> Result<BatchReturn, ActorError> {
+    let caller_id = rt.message().caller().id().unwrap();
+    let term_limit = rt.policy().maximum_verified_allocation_term;
+    let mut batch_gen = BatchReturnGen::new(terms.len());
+    let fail = |batch_gen: &mut BatchReturnGen, code: ExitCode, msg: String| {
+        if fail_fast {
+            return Err(ActorError::unchecked(code, msg));
+        }
+        info!("{}", msg);
+        batch_gen.add_fail(code);
+        Ok(())
+    };
+    rt.transaction(|st: &mut State, rt| {
+        let mut st_claims = st.load_claims(rt.store())?;
+        for term in terms {
+            // Confirm the new term limit is allowed.
+            if term.term_max > term_limit {
+                fail(
+                    &mut batch_gen,
+                    ExitCode::USR_ILLEGAL_ARGUMENT,
+                    format!(
+                        "term_max {} for claim {} exceeds maximum {}",
+                        term.term_max, term.claim_id, term_limit,
+                    ),
+                )?;
+                continue;
+            }
+
+            let maybe_claim = state::get_claim(&mut st_claims, term.provider, term.claim_id)?;
+            if let Some(claim) = maybe_claim {
+                // Confirm the caller is the claim's client.
+                if claim.client != caller_id {
+                    fail(
+                        &mut batch_gen,
+                        ExitCode::USR_FORBIDDEN,
+                        format!(
+                            "client {} for claim {} does not match caller {}",
+                            claim.client, term.claim_id, caller_id,
+                        ),
+                    )?;
+                    continue;
+                }
+                // Confirm the new term limit is no less than the old one.
+                if term.term_max < claim.term_max {
+                    fail(
+                        &mut batch_gen,
+                        ExitCode::USR_ILLEGAL_ARGUMENT,
+                        format!(
+                            "term_max {} for claim {} is less than current {}",
+                            term.term_max, term.claim_id, claim.term_max,
+                        ),
+                    )?;
+                    continue;
+                }
+
+                let new_claim = Claim { term_max: term.term_max, ..*claim };
+                st_claims.put(term.provider, term.claim_id, new_claim.clone()).context_code(
+                    ExitCode::USR_ILLEGAL_STATE,
+                    "HAMT put failure storing new claims",
+                )?;
+                batch_gen.add_success();
+                emit::claim_updated(rt, term.claim_id, &new_claim)?;
+            } else {
+                fail(
+                    &mut batch_gen,
+                    ExitCode::USR_NOT_FOUND,
+                    format!("no claim {} for provider {}", term.claim_id, term.provider),
+                )?;
+            }
+        }
+        st.save_claims(&mut st_claims)?;
+        Ok(())
+    })
+    .context("state transaction failed")?;
+    Ok(batch_gen.gen())
+}
+
 impl ActorCode for Actor {
     type Methods = Method;
 
@@ -1109,6 +1446,12 @@ impl ActorCode for Actor {
         GetClaims|GetClaimsExported => get_claims,
         ExtendClaimTerms|ExtendClaimTermsExported => extend_claim_terms,
         RemoveExpiredClaims|RemoveExpiredClaimsExported => remove_expired_claims,
+        ExtendClaimTermsExt|ExtendClaimTermsExtExported => extend_claim_terms_ext,
+        RemoveExpiredClaimsBatch|RemoveExpiredClaimsBatchExported => remove_expired_claims_batch,
+        SetClientDefaults|SetClientDefaultsExported => set_client_defaults,
+        ListClaims|ListClaimsExported => list_claims,
+        GetAllocations|GetAllocationsExported => get_allocations,
+        DelegateAllowance|DelegateAllowanceExported => delegate_allowance,
         UniversalReceiverHook => universal_receiver_hook,
     }
 }
@@ -13,7 +13,8 @@ use fvm_shared::sector::SectorNumber;
 use fvm_shared::{ActorID, HAMT_BIT_WIDTH};
 
 use fil_actors_runtime::{
-    actor_error, ActorError, AsActorError, Config, Map2, MapMap, DEFAULT_HAMT_CONFIG,
+    actor_error, ActorError, AsActorError, Config, Map2, MapMap, SetMultimap, SetMultimapConfig,
+    DEFAULT_HAMT_CONFIG,
 };
 
 use crate::{AddrPairKey, AllocationID, ClaimID};
@@ -25,6 +26,16 @@ pub const DATACAP_MAP_CONFIG: Config = DEFAULT_HAMT_CONFIG;
 pub type RemoveDataCapProposalMap<BS> = Map2<BS, AddrPairKey, RemoveDataCapProposalID>;
 pub const REMOVE_DATACAP_PROPOSALS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
 
+pub type ClientTermDefaultsMap<BS> = Map2<BS, ActorID, ClientTermDefaults>;
+pub const CLIENT_TERM_DEFAULTS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
+
+pub type VerifierDelegatorsMap<BS> = Map2<BS, ActorID, ActorID>;
+pub const VERIFIER_DELEGATORS_CONFIG: Config = DEFAULT_HAMT_CONFIG;
+
+pub type VerifierDelegatesMap<BS> = SetMultimap<BS, ActorID, ActorID>;
+pub const VERIFIER_DELEGATES_CONFIG: SetMultimapConfig =
+    SetMultimapConfig { outer: DEFAULT_HAMT_CONFIG, inner: DEFAULT_HAMT_CONFIG };
+
 #[derive(Serialize_tuple, Deserialize_tuple, Debug, Clone)]
 pub struct State {
     pub root_key: Address,
@@ -38,6 +49,13 @@ pub struct State {
     pub next_allocation_id: u64,
     // Maps provider IDs to allocations claimed by that provider.
     pub claims: Cid, // HAMT[ActorID]HAMT[ClaimID]Claim
+    // Maps client IDs to the default terms for that client's new allocations.
+    pub client_term_defaults: Cid, // HAMT[ActorID]ClientTermDefaults
+    // Maps verifiers that received their allowance by delegation to the delegating verifier.
+    pub verifier_delegators: Cid, // HAMT[ActorID]ActorID
+    // Maps delegating verifiers to the set of verifiers they delegated allowance to.
+    // This is the inverse of verifier_delegators.
+    pub verifier_delegates: Cid, // HAMT[ActorID]HAMT[ActorID]
 }
 
 impl State {
@@ -49,6 +67,12 @@ impl State {
                 .map_err(|e| {
                     actor_error!(illegal_state, "failed to create empty multi map: {}", e)
                 })?;
+        let empty_term_defaults =
+            ClientTermDefaultsMap::empty(store, CLIENT_TERM_DEFAULTS_CONFIG, "empty").flush()?;
+        let empty_delegators =
+            VerifierDelegatorsMap::empty(store, VERIFIER_DELEGATORS_CONFIG, "empty").flush()?;
+        let empty_delegates =
+            VerifierDelegatesMap::empty(store, VERIFIER_DELEGATES_CONFIG, "empty").flush()?;
 
         Ok(State {
             root_key,
@@ -57,6 +81,9 @@ impl State {
             allocations: empty_allocs_claims,
             next_allocation_id: 1,
             claims: empty_allocs_claims,
+            client_term_defaults: empty_term_defaults,
+            verifier_delegators: empty_delegators,
+            verifier_delegates: empty_delegates,
         })
     }
 
@@ -196,6 +223,127 @@ impl State {
         self.save_claims(&mut st_claims)?;
         Ok(())
     }
+
+    pub fn load_client_term_defaults<BS: Blockstore>(
+        &self,
+        store: BS,
+    ) -> Result<ClientTermDefaultsMap<BS>, ActorError> {
+        ClientTermDefaultsMap::load(
+            store,
+            &self.client_term_defaults,
+            CLIENT_TERM_DEFAULTS_CONFIG,
+            "client term defaults",
+        )
+    }
+
+    pub fn get_client_term_defaults(
+        &self,
+        store: &impl Blockstore,
+        client: ActorID,
+    ) -> Result<Option<ClientTermDefaults>, ActorError> {
+        let defaults = self.load_client_term_defaults(store)?;
+        Ok(defaults.get(&client)?.cloned())
+    }
+
+    // Sets the default terms for a client's new allocations, or removes them if None.
+    pub fn set_client_term_defaults(
+        &mut self,
+        store: &impl Blockstore,
+        client: ActorID,
+        terms: Option<ClientTermDefaults>,
+    ) -> Result<(), ActorError> {
+        let mut defaults = self.load_client_term_defaults(store)?;
+        match terms {
+            Some(terms) => {
+                defaults.set(&client, terms)?;
+            }
+            None => {
+                defaults.delete(&client)?;
+            }
+        }
+        self.client_term_defaults = defaults.flush()?;
+        Ok(())
+    }
+
+    pub fn load_verifier_delegators<BS: Blockstore>(
+        &self,
+        store: BS,
+    ) -> Result<VerifierDelegatorsMap<BS>, ActorError> {
+        VerifierDelegatorsMap::load(
+            store,
+            &self.verifier_delegators,
+            VERIFIER_DELEGATORS_CONFIG,
+            "verifier delegators",
+        )
+    }
+
+    pub fn load_verifier_delegates<BS: Blockstore>(
+        &self,
+        store: BS,
+    ) -> Result<VerifierDelegatesMap<BS>, ActorError> {
+        VerifierDelegatesMap::load(
+            store,
+            &self.verifier_delegates,
+            VERIFIER_DELEGATES_CONFIG,
+            "verifier delegates",
+        )
+    }
+
+    // Returns the verifier that delegated allowance to a verifier, if any.
+    pub fn get_verifier_delegator(
+        &self,
+        store: &impl Blockstore,
+        verifier: ActorID,
+    ) -> Result<Option<ActorID>, ActorError> {
+        let delegators = self.load_verifier_delegators(store)?;
+        Ok(delegators.get(&verifier)?.copied())
+    }
+
+    // Records the verifier that delegated allowance to a verifier, or removes the record if None.
+    pub fn set_verifier_delegator(
+        &mut self,
+        store: &impl Blockstore,
+        verifier: ActorID,
+        delegator: Option<ActorID>,
+    ) -> Result<(), ActorError> {
+        let mut delegators = self.load_verifier_delegators(store)?;
+        let previous = delegators.get(&verifier)?.copied();
+        if previous == delegator {
+            return Ok(());
+        }
+
+        let mut delegates = self.load_verifier_delegates(store)?;
+        if let Some(previous) = previous {
+            delegates.remove(&previous, verifier)?;
+        }
+        match delegator {
+            Some(delegator) => {
+                delegators.set(&verifier, delegator)?;
+                delegates.put(&delegator, verifier)?;
+            }
+            None => {
+                delegators.delete(&verifier)?;
+            }
+        }
+        self.verifier_delegators = delegators.flush()?;
+        self.verifier_delegates = delegates.flush()?;
+        Ok(())
+    }
+
+    // Returns the verifiers to which a verifier has delegated allowance.
+    pub fn get_verifier_delegates(
+        &self,
+        store: &impl Blockstore,
+        verifier: ActorID,
+    ) -> Result<Vec<ActorID>, ActorError> {
+        let delegates = self.load_verifier_delegates(store)?;
+        let mut ret = vec![];
+        delegates.for_each_in(&verifier, |delegate| {
+            ret.push(delegate);
+            Ok(())
+        })?;
+        Ok(ret)
+    }
 }
 #[derive(Serialize_tuple, Deserialize_tuple, Clone, Debug, PartialEq, Eq)]
 pub struct Claim {
@@ -237,6 +385,13 @@ pub struct Allocation {
     pub expiration: ChainEpoch,
 }
 
+// Default terms applied to a client's allocation requests that leave their terms unspecified.
+#[derive(Serialize_tuple, Deserialize_tuple, Clone, Debug, PartialEq, Eq)]
+pub struct ClientTermDefaults {
+    pub term_min: ChainEpoch,
+    pub term_max: ChainEpoch,
+}
+
 pub fn get_allocation<'a, BS>(
     allocations: &'a mut MapMap<BS, Allocation, ActorID, AllocationID>,
     client: ActorID,
@@ -133,6 +133,77 @@ pub fn check_state_invariants<BS: Blockstore>(
         Err(e) => acc.add(format!("error loading claims {e}")),
     }
 
+    match state.load_client_term_defaults(&store) {
+        Ok(defaults) => {
+            let ret = defaults.for_each(|client, terms| {
+                acc.require(
+                    terms.term_min >= MINIMUM_VERIFIED_ALLOCATION_TERM,
+                    format!("client {client} default term min {} too small", terms.term_min),
+                );
+                acc.require(
+                    terms.term_max <= MAXIMUM_VERIFIED_ALLOCATION_TERM,
+                    format!("client {client} default term max {} too large", terms.term_max),
+                );
+                acc.require(
+                    terms.term_min <= terms.term_max,
+                    format!(
+                        "client {client} default term min {} exceeds max {}",
+                        terms.term_min, terms.term_max
+                    ),
+                );
+                Ok(())
+            });
+            acc.require_no_error(ret, "error iterating client term defaults");
+        }
+        Err(e) => acc.add(format!("error loading client term defaults {e}")),
+    }
+
+    let mut all_delegators = HashMap::<ActorID, ActorID>::new();
+    match state.load_verifier_delegators(&store) {
+        Ok(delegators) => {
+            let ret = delegators.for_each(|delegate, delegator| {
+                acc.require(
+                    all_verifiers.contains_key(&Address::new_id(delegate)),
+                    format!("delegate {delegate} is not a verifier"),
+                );
+                acc.require(
+                    all_verifiers.contains_key(&Address::new_id(*delegator)),
+                    format!("delegator {delegator} of verifier {delegate} is not a verifier"),
+                );
+                all_delegators.insert(delegate, *delegator);
+                Ok(())
+            });
+            acc.require_no_error(ret, "error iterating verifier delegators");
+        }
+        Err(e) => acc.add(format!("error loading verifier delegators {e}")),
+    }
+
+    match state.load_verifier_delegates(&store) {
+        Ok(delegates) => {
+            let mut delegate_count = 0;
+            let ret = delegates.for_each(|delegator, _| {
+                delegates.for_each_in(&delegator, |delegate| {
+                    acc.require(
+                        all_delegators.get(&delegate) == Some(&delegator),
+                        format!("delegate {delegate} of {delegator} has no matching delegator"),
+                    );
+                    delegate_count += 1;
+                    Ok(())
+                })
+            });
+            acc.require_no_error(ret, "error iterating verifier delegates");
+            acc.require(
+                delegate_count == all_delegators.len(),
+                format!(
+                    "{} verifier delegates don't match {} delegators",
+                    delegate_count,
+                    all_delegators.len()
+                ),
+            );
+        }
+        Err(e) => acc.add(format!("error loading verifier delegates {e}")),
+    }
+
     (
         StateSummary { verifiers: all_verifiers, allocations: all_allocations, claims: all_claims },
         acc,
@@ -14,7 +14,7 @@ use fvm_shared::sector::StoragePower;
 use fvm_shared::ActorID;
 use std::fmt::{Debug, Formatter};
 
-use crate::Claim;
+use crate::{Allocation, Claim};
 
 pub type AllocationID = u64;
 pub type ClaimID = u64;
@@ -36,6 +36,8 @@ pub type AddVerifierParams = VerifierParams;
 
 pub type AddVerifiedClientParams = VerifierParams;
 
+pub type DelegateAllowanceParams = VerifierParams;
+
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 #[serde(transparent)]
 pub struct RemoveVerifierParams {
@@ -187,10 +189,22 @@ pub struct ExtendClaimTermsParams {
 
 pub type ExtendClaimTermsReturn = BatchReturn;
 
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ExtendClaimTermsExtParams {
+    pub terms: Vec<ClaimTerm>,
+    // Whether to abort on the first entry that cannot be extended,
+    // rather than committing the entries that succeed.
+    pub fail_fast: bool,
+}
+
 //
 // Receiver hook payload
 //
 
+// An allocation request term_min or term_max with this value is replaced by the
+// corresponding default term set by the client with SetClientDefaults.
+pub const USE_CLIENT_DEFAULT_TERM: ChainEpoch = 0;
+
 // A request to create an allocation with datacap tokens.
 // See Allocation state for description of field semantics.
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
@@ -230,6 +244,14 @@ pub struct AllocationsResponse {
     pub new_allocations: Vec<AllocationID>,
 }
 
+// Sets the calling client's default allocation terms.
+// Setting both terms to USE_CLIENT_DEFAULT_TERM removes the defaults.
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct SetClientDefaultsParams {
+    pub term_min: ChainEpoch,
+    pub term_max: ChainEpoch,
+}
+
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct GetClaimsParams {
     pub provider: ActorID,
@@ -242,6 +264,57 @@ pub struct GetClaimsReturn {
     pub claims: Vec<Claim>,
 }
 
+/// Maximum number of claims returned by a single ListClaims call.
+pub const LIST_CLAIMS_MAX_LIMIT: u64 = 1000;
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ListClaimsParams {
+    pub provider: ActorID,
+    /// Claim at which to start, as returned by a previous call.
+    /// None starts from the beginning.
+    pub cursor: Option<ClaimID>,
+    /// Maximum number of claims to return, up to LIST_CLAIMS_MAX_LIMIT.
+    /// None returns the maximum.
+    pub limit: Option<u64>,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ListClaimsReturn {
+    pub claims: Vec<(ClaimID, Claim)>,
+    /// Cursor from which to fetch the next page, or None if there are no more claims.
+    pub next_cursor: Option<ClaimID>,
+}
+
+/// Maximum number of allocations returned by a single GetAllocations call.
+pub const GET_ALLOCATIONS_MAX_LIMIT: u64 = 1000;
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct GetAllocationsParams {
+    pub client: ActorID,
+    /// Allocation at which to start, as returned by a previous call.
+    /// None starts from the beginning.
+    pub cursor: Option<AllocationID>,
+    /// Maximum number of allocations to return, up to GET_ALLOCATIONS_MAX_LIMIT.
+    /// None returns the maximum.
+    pub limit: Option<u64>,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct ClientAllocation {
+    pub id: AllocationID,
+    pub allocation: Allocation,
+    /// Whether the allocation's expiration has passed, so it can no longer be claimed
+    /// and may be removed to reclaim its datacap.
+    pub expired: bool,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+pub struct GetAllocationsReturn {
+    pub allocations: Vec<ClientAllocation>,
+    /// Cursor from which to fetch the next page, or None if there are no more allocations.
+    pub next_cursor: Option<AllocationID>,
+}
+
 #[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
 pub struct RemoveExpiredClaimsParams {
     // Provider to clean up (need not be the caller)
@@ -258,3 +331,17 @@ pub struct RemoveExpiredClaimsReturn {
     // Results for each processed claim.
     pub results: BatchReturn,
 }
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct RemoveExpiredClaimsBatchParams {
+    // Providers and claims to clean up, each processed as for RemoveExpiredClaims.
+    pub entries: Vec<RemoveExpiredClaimsParams>,
+}
+
+#[derive(Clone, Debug, PartialEq, Eq, Serialize_tuple, Deserialize_tuple)]
+#[serde(transparent)]
+pub struct RemoveExpiredClaimsBatchReturn {
+    // Results for each entry, in the order given.
+    pub results: Vec<RemoveExpiredClaimsReturn>,
+}
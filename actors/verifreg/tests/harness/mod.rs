@@ -25,10 +25,13 @@ use fil_actor_verifreg::testing::check_state_invariants;
 use fil_actor_verifreg::{
     ext, Actor as VerifregActor, AddVerifiedClientParams, AddVerifierParams, Allocation,
     AllocationClaim, AllocationID, AllocationRequest, AllocationRequests, AllocationsResponse,
-    Claim, ClaimAllocationsParams, ClaimAllocationsReturn, ClaimExtensionRequest, ClaimID, DataCap,
-    ExtendClaimTermsParams, ExtendClaimTermsReturn, GetClaimsParams, GetClaimsReturn, Method,
-    RemoveExpiredAllocationsParams, RemoveExpiredAllocationsReturn, RemoveExpiredClaimsParams,
-    RemoveExpiredClaimsReturn, SectorAllocationClaims, State,
+    Claim, ClaimAllocationsParams, ClaimAllocationsReturn, ClaimExtensionRequest, ClaimID,
+    ClaimTerm, DataCap, DelegateAllowanceParams, ExtendClaimTermsExtParams, ExtendClaimTermsParams,
+    ExtendClaimTermsReturn, GetAllocationsParams, GetAllocationsReturn, GetClaimsParams,
+    GetClaimsReturn, ListClaimsParams, ListClaimsReturn, Method, RemoveExpiredAllocationsParams,
+    RemoveExpiredAllocationsReturn, RemoveExpiredClaimsBatchParams, RemoveExpiredClaimsBatchReturn,
+    RemoveExpiredClaimsParams, RemoveExpiredClaimsReturn, SectorAllocationClaims,
+    SetClientDefaultsParams, State, USE_CLIENT_DEFAULT_TERM,
 };
 use fil_actors_runtime::cbor::serialize;
 use fil_actors_runtime::runtime::builtins::Type;
@@ -184,6 +187,70 @@ impl Harness {
         Ok(())
     }
 
+    // Delegates allowance from a verifier to a delegate, which is expected to be a new verifier
+    // unless its existing allowance is given.
+    pub fn delegate_allowance(
+        &self,
+        rt: &MockRuntime,
+        verifier: &Address,
+        delegate: &Address,
+        allowance: &DataCap,
+        existing_delegate_cap: Option<&DataCap>,
+    ) -> Result<(), ActorError> {
+        rt.expect_validate_caller_any();
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, *verifier);
+        let delegate_resolved = rt.get_id_address(delegate).unwrap_or(*delegate);
+        if existing_delegate_cap.is_none() {
+            // Expect checking the delegate's token balance.
+            rt.expect_send(
+                DATACAP_TOKEN_ACTOR_ADDR,
+                ext::datacap::Method::Balance as MethodNum,
+                IpldBlock::serialize_cbor(&delegate_resolved).unwrap(),
+                TokenAmount::zero(),
+                None,
+                SendFlags::READ_ONLY,
+                IpldBlock::serialize_cbor(&BigIntSer(&DataCap::zero())).unwrap(),
+                ExitCode::OK,
+                None,
+            );
+        }
+
+        let verifier_cap = rt
+            .get_state::<State>()
+            .get_verifier_cap(rt.store(), verifier)
+            .unwrap()
+            .unwrap_or_default();
+        let delegate_cap = existing_delegate_cap.cloned().unwrap_or_default();
+        if &verifier_cap >= allowance {
+            rt.expect_emitted_event(
+                EventBuilder::new()
+                    .typ("verifier-balance")
+                    .field_indexed("verifier", &verifier.id().unwrap())
+                    .field("balance", &BigIntSer(&(&verifier_cap - allowance)))
+                    .build()?,
+            );
+            rt.expect_emitted_event(
+                EventBuilder::new()
+                    .typ("verifier-balance")
+                    .field_indexed("verifier", &delegate_resolved.id().unwrap())
+                    .field("balance", &BigIntSer(&(&delegate_cap + allowance)))
+                    .build()?,
+            );
+        }
+
+        let params = DelegateAllowanceParams { address: *delegate, allowance: allowance.clone() };
+        let ret = rt.call::<VerifregActor>(
+            Method::DelegateAllowance as MethodNum,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+        assert!(ret.is_none());
+        rt.verify();
+
+        assert_eq!(verifier_cap - allowance, self.get_verifier_allowance(rt, verifier));
+        assert_eq!(delegate_cap + allowance, self.get_verifier_allowance(rt, &delegate_resolved));
+        Ok(())
+    }
+
     pub fn assert_verifier_allowance(
         &self,
         rt: &MockRuntime,
@@ -406,22 +473,7 @@ impl Harness {
         expect_removed: Vec<(ClaimID, Claim)>,
     ) -> Result<RemoveExpiredClaimsReturn, ActorError> {
         rt.expect_validate_caller_any();
-
-        for (id, claim) in expect_removed {
-            expect_claim_emitted(
-                rt,
-                "claim-removed",
-                id,
-                claim.client,
-                claim.provider,
-                &claim.data,
-                claim.size.0,
-                claim.sector,
-                claim.term_min,
-                claim.term_max,
-                claim.term_start,
-            )
-        }
+        expect_claims_removed(rt, expect_removed);
 
         let params = RemoveExpiredClaimsParams { provider, claim_ids };
         let ret = rt
@@ -436,6 +488,28 @@ impl Harness {
         Ok(ret)
     }
 
+    pub fn remove_expired_claims_batch(
+        &self,
+        rt: &MockRuntime,
+        entries: Vec<RemoveExpiredClaimsParams>,
+        expect_removed: Vec<(ClaimID, Claim)>,
+    ) -> Result<RemoveExpiredClaimsBatchReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        expect_claims_removed(rt, expect_removed);
+
+        let params = RemoveExpiredClaimsBatchParams { entries };
+        let ret = rt
+            .call::<VerifregActor>(
+                Method::RemoveExpiredClaimsBatch as MethodNum,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .expect("failed to deserialize remove expired claims batch return");
+        rt.verify();
+        Ok(ret)
+    }
+
     pub fn load_claim(&self, rt: &MockRuntime, provider: ActorID, id: ClaimID) -> Option<Claim> {
         let st: State = rt.get_state();
         let mut claims = st.load_claims(rt.store()).unwrap();
@@ -472,7 +546,18 @@ impl Harness {
         }
 
         let allocs_req: AllocationRequests = payload.operator_data.deserialize().unwrap();
+        let st: State = rt.get_state();
+        let term_defaults = st.get_client_term_defaults(rt.store(), payload.from).unwrap();
         for (alloc, id) in allocs_req.allocations.iter().zip(expected_alloc_ids.iter()) {
+            let mut alloc = alloc.clone();
+            if let Some(defaults) = &term_defaults {
+                if alloc.term_min == USE_CLIENT_DEFAULT_TERM {
+                    alloc.term_min = defaults.term_min;
+                }
+                if alloc.term_max == USE_CLIENT_DEFAULT_TERM {
+                    alloc.term_max = defaults.term_max;
+                }
+            }
             expect_allocation_emitted(
                 rt,
                 "allocation",
@@ -522,6 +607,25 @@ impl Harness {
         Ok(())
     }
 
+    pub fn set_client_defaults(
+        &self,
+        rt: &MockRuntime,
+        client: ActorID,
+        term_min: ChainEpoch,
+        term_max: ChainEpoch,
+    ) -> Result<(), ActorError> {
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(client));
+        rt.expect_validate_caller_any();
+        let params = SetClientDefaultsParams { term_min, term_max };
+        let ret = rt.call::<VerifregActor>(
+            Method::SetClientDefaults as MethodNum,
+            IpldBlock::serialize_cbor(&params).unwrap(),
+        )?;
+        assert!(ret.is_none());
+        rt.verify();
+        Ok(())
+    }
+
     // Creates a claim directly in state.
     pub fn create_claim(&self, rt: &MockRuntime, claim: &Claim) -> Result<ClaimID, ActorError> {
         let mut st: State = rt.get_state();
@@ -536,6 +640,48 @@ impl Harness {
         Ok(id)
     }
 
+    pub fn list_claims(
+        &self,
+        rt: &MockRuntime,
+        provider: ActorID,
+        cursor: Option<ClaimID>,
+        limit: Option<u64>,
+    ) -> Result<ListClaimsReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        let params = ListClaimsParams { provider, cursor, limit };
+        let ret = rt
+            .call::<VerifregActor>(
+                Method::ListClaims as MethodNum,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .expect("failed to deserialize list claims return");
+        rt.verify();
+        Ok(ret)
+    }
+
+    pub fn get_allocations(
+        &self,
+        rt: &MockRuntime,
+        client: ActorID,
+        cursor: Option<AllocationID>,
+        limit: Option<u64>,
+    ) -> Result<GetAllocationsReturn, ActorError> {
+        rt.expect_validate_caller_any();
+        let params = GetAllocationsParams { client, cursor, limit };
+        let ret = rt
+            .call::<VerifregActor>(
+                Method::GetAllocations as MethodNum,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .expect("failed to deserialize get allocations return");
+        rt.verify();
+        Ok(ret)
+    }
+
     pub fn get_claims(
         &self,
         rt: &MockRuntime,
@@ -562,24 +708,7 @@ impl Harness {
         params: &ExtendClaimTermsParams,
         expected: Vec<(ClaimID, Claim)>,
     ) -> Result<ExtendClaimTermsReturn, ActorError> {
-        for (id, mut new_claim) in expected {
-            let ext = params.terms.iter().find(|c| c.claim_id == id).unwrap();
-            new_claim.term_max = ext.term_max;
-            expect_claim_emitted(
-                rt,
-                "claim-updated",
-                id,
-                new_claim.client,
-                new_claim.provider,
-                &new_claim.data,
-                new_claim.size.0,
-                new_claim.sector,
-                new_claim.term_min,
-                new_claim.term_max,
-                new_claim.term_start,
-            )
-        }
-
+        expect_claims_updated(rt, &params.terms, expected);
         rt.expect_validate_caller_any();
         let ret = rt
             .call::<VerifregActor>(
@@ -592,6 +721,46 @@ impl Harness {
         rt.verify();
         Ok(ret)
     }
+
+    pub fn extend_claim_terms_ext(
+        &self,
+        rt: &MockRuntime,
+        params: &ExtendClaimTermsExtParams,
+        expected: Vec<(ClaimID, Claim)>,
+    ) -> Result<ExtendClaimTermsReturn, ActorError> {
+        expect_claims_updated(rt, &params.terms, expected);
+        rt.expect_validate_caller_any();
+        let ret = rt
+            .call::<VerifregActor>(
+                Method::ExtendClaimTermsExt as MethodNum,
+                IpldBlock::serialize_cbor(&params).unwrap(),
+            )?
+            .unwrap()
+            .deserialize()
+            .expect("failed to deserialize extend claim terms return");
+        rt.verify();
+        Ok(ret)
+    }
+}
+
+fn expect_claims_updated(rt: &MockRuntime, terms: &[ClaimTerm], expected: Vec<(ClaimID, Claim)>) {
+    for (id, mut new_claim) in expected {
+        let ext = terms.iter().find(|c| c.claim_id == id).unwrap();
+        new_claim.term_max = ext.term_max;
+        expect_claim_emitted(
+            rt,
+            "claim-updated",
+            id,
+            new_claim.client,
+            new_claim.provider,
+            &new_claim.data,
+            new_claim.size.0,
+            new_claim.sector,
+            new_claim.term_min,
+            new_claim.term_max,
+            new_claim.term_start,
+        )
+    }
 }
 
 #[allow(clippy::too_many_arguments)]
@@ -624,6 +793,24 @@ pub fn expect_allocation_emitted(
 }
 
 #[allow(clippy::too_many_arguments)]
+pub fn expect_claims_removed(rt: &MockRuntime, removed: Vec<(ClaimID, Claim)>) {
+    for (id, claim) in removed {
+        expect_claim_emitted(
+            rt,
+            "claim-removed",
+            id,
+            claim.client,
+            claim.provider,
+            &claim.data,
+            claim.size.0,
+            claim.sector,
+            claim.term_min,
+            claim.term_max,
+            claim.term_start,
+        )
+    }
+}
+
 pub fn expect_claim_emitted(
     rt: &MockRuntime,
     typ: &str,
@@ -239,6 +239,125 @@ mod verifiers {
     }
 }
 
+mod delegation {
+    use fvm_shared::address::Address;
+    use fvm_shared::error::ExitCode;
+
+    use fil_actor_verifreg::{DataCap, State};
+    use fil_actors_runtime::test_utils::*;
+    use harness::*;
+    use util::*;
+
+    use crate::*;
+
+    #[test]
+    fn delegate_allowance_to_new_verifier() {
+        let (h, rt) = new_harness();
+        let allowance = verifier_allowance(&rt);
+        h.add_verifier(&rt, &VERIFIER, &(&allowance * 3)).unwrap();
+
+        h.delegate_allowance(&rt, &VERIFIER, &VERIFIER2, &allowance, None).unwrap();
+        let st: State = rt.get_state();
+        assert_eq!(
+            Some(VERIFIER.id().unwrap()),
+            st.get_verifier_delegator(rt.store(), VERIFIER2.id().unwrap()).unwrap()
+        );
+
+        // The delegator can top up its delegate.
+        h.delegate_allowance(&rt, &VERIFIER, &VERIFIER2, &allowance, Some(&allowance)).unwrap();
+        h.assert_verifier_allowance(&rt, &VERIFIER, &allowance);
+        h.assert_verifier_allowance(&rt, &VERIFIER2, &(&allowance * 2));
+
+        // The delegate can grant its allowance to clients.
+        let client_allowance = client_allowance(&rt);
+        h.add_client(&rt, &VERIFIER2, &CLIENT, &client_allowance, &(&allowance * 2)).unwrap();
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn delegate_cannot_exceed_granted_allowance() {
+        let (h, rt) = new_harness();
+        let allowance = verifier_allowance(&rt);
+        let sub_delegate = Address::new_id(203);
+        h.add_verifier(&rt, &VERIFIER, &(&allowance * 3)).unwrap();
+        h.delegate_allowance(&rt, &VERIFIER, &VERIFIER2, &allowance, None).unwrap();
+
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "exceeding allowance",
+            h.delegate_allowance(&rt, &VERIFIER2, &sub_delegate, &(&allowance + 1), None),
+        );
+        rt.reset();
+
+        // The delegate may re-delegate what it was granted.
+        h.delegate_allowance(&rt, &VERIFIER2, &sub_delegate, &allowance, None).unwrap();
+        h.assert_verifier_allowance(&rt, &VERIFIER2, &DataCap::zero());
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn delegate_requires_verifier_caller() {
+        let (h, rt) = new_harness();
+        let allowance = verifier_allowance(&rt);
+        expect_abort_contains_message(
+            ExitCode::USR_NOT_FOUND,
+            "is not a verifier",
+            h.delegate_allowance(&rt, &CLIENT, &VERIFIER2, &allowance, None),
+        );
+        rt.reset();
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn cannot_top_up_unrelated_verifier() {
+        let (h, rt) = new_harness();
+        let allowance = verifier_allowance(&rt);
+        h.add_verifier(&rt, &VERIFIER, &(&allowance * 2)).unwrap();
+        h.add_verifier(&rt, &VERIFIER2, &allowance).unwrap();
+
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "is not a delegate of",
+            h.delegate_allowance(&rt, &VERIFIER, &VERIFIER2, &allowance, Some(&allowance)),
+        );
+        rt.reset();
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "cannot delegate to itself",
+            h.delegate_allowance(&rt, &VERIFIER, &VERIFIER, &allowance, Some(&allowance)),
+        );
+        rt.reset();
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn cannot_remove_verifier_with_delegates() {
+        let (h, rt) = new_harness();
+        let allowance = verifier_allowance(&rt);
+        h.add_verifier(&rt, &VERIFIER, &(&allowance * 2)).unwrap();
+        h.delegate_allowance(&rt, &VERIFIER, &VERIFIER2, &allowance, None).unwrap();
+        let st: State = rt.get_state();
+        assert_eq!(
+            vec![VERIFIER2.id().unwrap()],
+            st.get_verifier_delegates(rt.store(), VERIFIER.id().unwrap()).unwrap()
+        );
+
+        expect_abort_contains_message(
+            ExitCode::USR_FORBIDDEN,
+            "which must be removed first",
+            h.remove_verifier(&rt, &VERIFIER),
+        );
+        rt.reset();
+
+        h.remove_verifier(&rt, &VERIFIER2).unwrap();
+        h.remove_verifier(&rt, &VERIFIER).unwrap();
+        let st: State = rt.get_state();
+        assert_eq!(None, st.get_verifier_delegator(rt.store(), VERIFIER2.id().unwrap()).unwrap());
+        assert!(st.get_verifier_delegates(rt.store(), VERIFIER.id().unwrap()).unwrap().is_empty());
+        h.check_state(&rt);
+    }
+}
+
 mod clients {
     use fvm_ipld_encoding::ipld_block::IpldBlock;
     use fvm_shared::address::{Address, BLS_PUB_LEN};
@@ -518,6 +637,7 @@ mod clients {
 }
 
 mod allocs_claims {
+    use std::collections::HashMap;
     use std::str::FromStr;
 
     use cid::Cid;
@@ -529,8 +649,9 @@ mod allocs_claims {
     use num_traits::Zero;
 
     use fil_actor_verifreg::{
-        Actor, AllocationID, ClaimTerm, DataCap, ExtendClaimTermsParams, GetClaimsParams, Method,
-        State,
+        Actor, AllocationID, ClaimTerm, DataCap, ExtendClaimTermsExtParams, ExtendClaimTermsParams,
+        GetClaimsParams, Method, RemoveExpiredClaimsParams, State, GET_ALLOCATIONS_MAX_LIMIT,
+        LIST_CLAIMS_MAX_LIMIT,
     };
     use fil_actor_verifreg::{Claim, ExtendClaimTermsReturn};
     use fil_actors_runtime::runtime::policy_constants::{
@@ -908,6 +1029,137 @@ mod allocs_claims {
         h.check_state(&rt);
     }
 
+    #[test]
+    fn list_claims_paginated() {
+        let (h, rt) = new_harness();
+        let size = MINIMUM_VERIFIED_ALLOCATION_SIZE as u64;
+        let min_term = MINIMUM_VERIFIED_ALLOCATION_TERM;
+        let max_term = min_term + 1000;
+
+        let mut expected = HashMap::new();
+        for i in 0..5 {
+            let claim =
+                make_claim(&i.to_string(), CLIENT1, PROVIDER1, size, min_term, max_term, 0, 0);
+            expected.insert(h.create_claim(&rt, &claim).unwrap(), claim);
+        }
+        let other = make_claim("other", CLIENT1, PROVIDER2, size, min_term, max_term, 0, 0);
+        let other_id = h.create_claim(&rt, &other).unwrap();
+
+        // Page through the provider's claims two at a time.
+        let mut found = HashMap::new();
+        let mut cursor = None;
+        let mut pages = 0;
+        loop {
+            rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(CLIENT2));
+            let ret = h.list_claims(&rt, PROVIDER1, cursor, Some(2)).unwrap();
+            assert!(ret.claims.len() <= 2);
+            for (id, claim) in ret.claims {
+                assert!(found.insert(id, claim).is_none());
+            }
+            pages += 1;
+            cursor = ret.next_cursor;
+            if cursor.is_none() {
+                break;
+            }
+        }
+        assert_eq!(3, pages);
+        assert_eq!(expected, found);
+
+        // A single page holds everything by default.
+        let ret = h.list_claims(&rt, PROVIDER1, None, None).unwrap();
+        assert_eq!(5, ret.claims.len());
+        assert_eq!(None, ret.next_cursor);
+
+        // No claims for an unknown provider.
+        let ret = h.list_claims(&rt, 999, None, None).unwrap();
+        assert!(ret.claims.is_empty());
+        assert_eq!(None, ret.next_cursor);
+
+        // A cursor that isn't one of the provider's claims is rejected.
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "cursor",
+            h.list_claims(&rt, PROVIDER1, Some(other_id), None),
+        );
+        rt.reset();
+
+        for limit in [0, LIST_CLAIMS_MAX_LIMIT + 1] {
+            expect_abort_contains_message(
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+                "limit",
+                h.list_claims(&rt, PROVIDER1, None, Some(limit)),
+            );
+            rt.reset();
+        }
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn get_allocations_paginated() {
+        let (h, rt) = new_harness();
+        let size = MINIMUM_VERIFIED_ALLOCATION_SIZE as u64;
+
+        let mut expected = HashMap::new();
+        for i in 0..5 {
+            let mut alloc = make_alloc(&i.to_string(), CLIENT1, PROVIDER1, size);
+            alloc.expiration = 100 + i;
+            expected.insert(h.create_alloc(&rt, &alloc).unwrap(), alloc);
+        }
+        let other_id = h.create_alloc(&rt, &make_alloc("other", CLIENT2, PROVIDER1, size)).unwrap();
+
+        // Page through the client's allocations two at a time.
+        rt.set_epoch(102);
+        let mut found = HashMap::new();
+        let mut cursor = None;
+        let mut pages = 0;
+        loop {
+            rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(PROVIDER2));
+            let ret = h.get_allocations(&rt, CLIENT1, cursor, Some(2)).unwrap();
+            assert!(ret.allocations.len() <= 2);
+            for entry in ret.allocations {
+                // Expired allocations are listed, but flagged.
+                assert_eq!(entry.allocation.expiration <= 102, entry.expired);
+                assert!(found.insert(entry.id, entry.allocation).is_none());
+            }
+            pages += 1;
+            cursor = ret.next_cursor;
+            if cursor.is_none() {
+                break;
+            }
+        }
+        assert_eq!(3, pages);
+        assert_eq!(expected, found);
+
+        // A single page holds everything by default.
+        let ret = h.get_allocations(&rt, CLIENT1, None, None).unwrap();
+        assert_eq!(5, ret.allocations.len());
+        assert_eq!(3, ret.allocations.iter().filter(|a| a.expired).count());
+        assert_eq!(None, ret.next_cursor);
+
+        // No allocations for an unknown client.
+        let ret = h.get_allocations(&rt, 999, None, None).unwrap();
+        assert!(ret.allocations.is_empty());
+        assert_eq!(None, ret.next_cursor);
+
+        // A cursor that isn't one of the client's allocations is rejected.
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "cursor",
+            h.get_allocations(&rt, CLIENT1, Some(other_id), None),
+        );
+        rt.reset();
+
+        for limit in [0, GET_ALLOCATIONS_MAX_LIMIT + 1] {
+            expect_abort_contains_message(
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+                "limit",
+                h.get_allocations(&rt, CLIENT1, None, Some(limit)),
+            );
+            rt.reset();
+        }
+        h.check_state(&rt);
+    }
+
     #[test]
     fn extend_claims_basic() {
         let (h, rt) = new_harness();
@@ -1037,6 +1289,54 @@ mod allocs_claims {
         h.check_state(&rt);
     }
 
+    #[test]
+    fn extend_claims_ext_partial_and_fail_fast() {
+        let (h, rt) = new_harness();
+        let size = MINIMUM_VERIFIED_ALLOCATION_SIZE as u64;
+        let sector = 0;
+        let start = 0;
+        let min_term = MINIMUM_VERIFIED_ALLOCATION_TERM;
+        let max_term = min_term + 1000;
+
+        let claim1 = make_claim("1", CLIENT1, PROVIDER1, size, min_term, max_term, start, sector);
+        let claim2 = make_claim("2", CLIENT1, PROVIDER1, size, min_term, max_term, start, sector);
+        let id1 = h.create_claim(&rt, &claim1).unwrap();
+        let id2 = h.create_claim(&rt, &claim2).unwrap();
+
+        let terms = vec![
+            ClaimTerm { provider: PROVIDER1, claim_id: id1, term_max: max_term + 1 },
+            ClaimTerm {
+                provider: PROVIDER1,
+                claim_id: id2,
+                term_max: MAXIMUM_VERIFIED_ALLOCATION_TERM + 1,
+            },
+        ];
+
+        // Failing fast aborts the whole batch and leaves state untouched.
+        {
+            let params = ExtendClaimTermsExtParams { terms: terms.clone(), fail_fast: true };
+            rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(CLIENT1));
+            expect_abort_contains_message(
+                ExitCode::USR_ILLEGAL_ARGUMENT,
+                "exceeds maximum",
+                h.extend_claim_terms_ext(&rt, &params, vec![(id1, claim1.clone())]),
+            );
+            rt.reset();
+            assert_claim(&rt, PROVIDER1, id1, &claim1);
+            assert_claim(&rt, PROVIDER1, id2, &claim2);
+        }
+        // Otherwise the valid entries are committed.
+        {
+            let params = ExtendClaimTermsExtParams { terms, fail_fast: false };
+            rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(CLIENT1));
+            let ret = h.extend_claim_terms_ext(&rt, &params, vec![(id1, claim1.clone())]).unwrap();
+            assert_eq!(ret.codes(), vec![ExitCode::OK, ExitCode::USR_ILLEGAL_ARGUMENT]);
+            assert_claim(&rt, PROVIDER1, id1, &Claim { term_max: max_term + 1, ..claim1 });
+            assert_claim(&rt, PROVIDER1, id2, &claim2);
+        }
+        h.check_state(&rt);
+    }
+
     #[test]
     fn expire_claims() {
         let (h, rt) = new_harness();
@@ -1115,6 +1415,83 @@ mod allocs_claims {
         h.check_state(&rt);
     }
 
+    #[test]
+    fn expire_claims_batch() {
+        let (h, rt) = new_harness();
+        let term_start = 0;
+        let term_min = MINIMUM_VERIFIED_ALLOCATION_TERM;
+        let sector = 0;
+
+        // expires at term_start + term_min + 100
+        let claim1 = make_claim(
+            "1",
+            CLIENT1,
+            PROVIDER1,
+            ALLOC_SIZE,
+            term_min,
+            term_min + 100,
+            term_start,
+            sector,
+        );
+        // expires at term_start + 200 + term_min (i.e. 100 epochs later)
+        let claim2 = make_claim(
+            "2",
+            CLIENT1,
+            PROVIDER1,
+            ALLOC_SIZE,
+            term_min,
+            term_min,
+            term_start + 200,
+            sector,
+        );
+        // expires at term_start + term_min + 100, for another provider
+        let claim3 = make_claim(
+            "3",
+            CLIENT2,
+            PROVIDER2,
+            ALLOC_SIZE,
+            term_min,
+            term_min + 100,
+            term_start,
+            sector,
+        );
+        let id1 = h.create_claim(&rt, &claim1).unwrap();
+        let id2 = h.create_claim(&rt, &claim2).unwrap();
+        let id3 = h.create_claim(&rt, &claim3).unwrap();
+
+        // Anyone can clean up expired claims across providers.
+        rt.set_caller(*ACCOUNT_ACTOR_CODE_ID, Address::new_id(CLIENT2));
+        rt.set_epoch(term_start + term_min + 100);
+        let ret = h
+            .remove_expired_claims_batch(
+                &rt,
+                vec![
+                    RemoveExpiredClaimsParams { provider: PROVIDER1, claim_ids: vec![id1, id2] },
+                    // claim 3 doesn't belong to provider 1
+                    RemoveExpiredClaimsParams { provider: PROVIDER1, claim_ids: vec![id3] },
+                    RemoveExpiredClaimsParams { provider: PROVIDER2, claim_ids: vec![] },
+                ],
+                vec![(id1, claim1), (id3, claim3)],
+            )
+            .unwrap();
+        assert_eq!(3, ret.results.len());
+        assert_eq!(vec![id1, id2], ret.results[0].considered);
+        assert_eq!(vec![ExitCode::OK, ExitCode::USR_FORBIDDEN], ret.results[0].results.codes());
+        assert_eq!(vec![id3], ret.results[1].considered);
+        assert_eq!(vec![ExitCode::USR_NOT_FOUND], ret.results[1].results.codes());
+        assert_eq!(vec![id3], ret.results[2].considered);
+        assert_eq!(vec![ExitCode::OK], ret.results[2].results.codes());
+
+        assert!(h.load_claim(&rt, PROVIDER1, id1).is_none());
+        assert!(h.load_claim(&rt, PROVIDER1, id2).is_some());
+        assert!(h.load_claim(&rt, PROVIDER2, id3).is_none());
+
+        // An empty batch is a no-op.
+        let ret = h.remove_expired_claims_batch(&rt, vec![], vec![]).unwrap();
+        assert!(ret.results.is_empty());
+        h.check_state(&rt);
+    }
+
     #[test]
     fn claims_restricted_correctly() {
         let (h, rt) = new_harness();
@@ -1187,7 +1564,9 @@ mod datacap {
     use fvm_shared::error::ExitCode;
     use fvm_shared::{ActorID, MethodNum};
 
-    use fil_actor_verifreg::{Actor as VerifregActor, Claim, Method, State};
+    use fil_actor_verifreg::{
+        Actor as VerifregActor, Allocation, Claim, Method, State, USE_CLIENT_DEFAULT_TERM,
+    };
     use fil_actors_runtime::cbor::serialize;
     use fil_actors_runtime::runtime::policy_constants::{
         MAXIMUM_VERIFIED_ALLOCATION_EXPIRATION, MAXIMUM_VERIFIED_ALLOCATION_TERM,
@@ -1400,6 +1779,111 @@ mod datacap {
         h.check_state(&rt);
     }
 
+    #[test]
+    fn receive_tokens_with_client_term_defaults() {
+        let (h, rt) = new_harness();
+        add_miner(&rt, PROVIDER1);
+
+        let default_min = MINIMUM_VERIFIED_ALLOCATION_TERM + 100;
+        let default_max = MINIMUM_VERIFIED_ALLOCATION_TERM + 1000;
+        h.set_client_defaults(&rt, CLIENT1, default_min, default_max).unwrap();
+
+        let mut reqs = vec![
+            make_alloc_req(&rt, PROVIDER1, SIZE),
+            make_alloc_req(&rt, PROVIDER1, SIZE),
+            make_alloc_req(&rt, PROVIDER1, SIZE),
+        ];
+        reqs[0].term_min = USE_CLIENT_DEFAULT_TERM;
+        reqs[0].term_max = USE_CLIENT_DEFAULT_TERM;
+        // Explicit terms take precedence over the defaults.
+        reqs[1].term_max = USE_CLIENT_DEFAULT_TERM;
+        let payload = make_receiver_hook_token_payload(CLIENT1, reqs.clone(), vec![], SIZE * 3);
+        h.receive_tokens(&rt, payload, BatchReturn::ok(3), BATCH_EMPTY, vec![1, 2, 3], 0).unwrap();
+
+        let expected = alloc_from_req(CLIENT1, &reqs[0]);
+        assert_allocation(
+            &rt,
+            CLIENT1,
+            1,
+            &Allocation { term_min: default_min, term_max: default_max, ..expected },
+        );
+        let expected = alloc_from_req(CLIENT1, &reqs[1]);
+        assert_allocation(&rt, CLIENT1, 2, &Allocation { term_max: default_max, ..expected });
+        assert_allocation(&rt, CLIENT1, 3, &alloc_from_req(CLIENT1, &reqs[2]));
+
+        // Another client's defaults are not applied.
+        let payload = make_receiver_hook_token_payload(CLIENT2, reqs[..1].to_vec(), vec![], SIZE);
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "allocation term min 0 below limit",
+            h.receive_tokens(&rt, payload, BATCH_EMPTY, BATCH_EMPTY, vec![], 0),
+        );
+        rt.reset();
+
+        // Defaults are removed by setting both terms to the sentinel.
+        h.set_client_defaults(&rt, CLIENT1, USE_CLIENT_DEFAULT_TERM, USE_CLIENT_DEFAULT_TERM)
+            .unwrap();
+        let st: State = rt.get_state();
+        assert_eq!(None, st.get_client_term_defaults(rt.store(), CLIENT1).unwrap());
+        let payload = make_receiver_hook_token_payload(CLIENT1, reqs[..1].to_vec(), vec![], SIZE);
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "allocation term min 0 below limit",
+            h.receive_tokens(&rt, payload, BATCH_EMPTY, BATCH_EMPTY, vec![], 0),
+        );
+        rt.reset();
+        h.check_state(&rt);
+    }
+
+    #[test]
+    fn invalid_client_term_defaults() {
+        let (h, rt) = new_harness();
+
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "allocation term min 518399 below limit 518400",
+            h.set_client_defaults(
+                &rt,
+                CLIENT1,
+                MINIMUM_VERIFIED_ALLOCATION_TERM - 1,
+                MAXIMUM_VERIFIED_ALLOCATION_TERM,
+            ),
+        );
+        rt.reset();
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "allocation term max 5259486 above limit 5259485",
+            h.set_client_defaults(
+                &rt,
+                CLIENT1,
+                MINIMUM_VERIFIED_ALLOCATION_TERM,
+                MAXIMUM_VERIFIED_ALLOCATION_TERM + 1,
+            ),
+        );
+        rt.reset();
+        expect_abort_contains_message(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            "exceeds term max",
+            h.set_client_defaults(
+                &rt,
+                CLIENT1,
+                MINIMUM_VERIFIED_ALLOCATION_TERM + 1,
+                MINIMUM_VERIFIED_ALLOCATION_TERM,
+            ),
+        );
+        rt.reset();
+        // A single unspecified term is not a valid default.
+        expect_abort(
+            ExitCode::USR_ILLEGAL_ARGUMENT,
+            h.set_client_defaults(&rt, CLIENT1, USE_CLIENT_DEFAULT_TERM, EPOCHS_IN_YEAR),
+        );
+        rt.reset();
+
+        let st: State = rt.get_state();
+        assert_eq!(None, st.get_client_term_defaults(rt.store(), CLIENT1).unwrap());
+        h.check_state(&rt);
+    }
+
     #[test]
     fn receive_alloc_requires_miner_actor() {
         let (h, rt) = new_harness();
@@ -44,7 +44,7 @@ impl Expect {
     }
     pub fn market_activate_deals(
         from: ActorID,
-        deals: Vec<DealID>,
+        deals: Vec<(DealID, Cid)>,
         client_id: ActorID,
         sector_number: SectorNumber,
         sector_expiry: ChainEpoch,
@@ -54,7 +54,7 @@ impl Expect {
         let params = IpldBlock::serialize_cbor(&BatchActivateDealsParams {
             sectors: vec![SectorDeals {
                 sector_number,
-                deal_ids: deals.clone(),
+                deal_ids: deals.iter().map(|(deal_id, _)| *deal_id).collect(),
                 sector_expiry,
                 sector_type,
             }],
@@ -64,7 +64,9 @@ impl Expect {
 
         let events: Vec<EmittedEvent> = deals
             .iter()
-            .map(|deal_id| Expect::build_market_event("deal-activated", *deal_id, client_id, from))
+            .map(|(deal_id, piece_cid)| {
+                Expect::build_market_event("deal-activated", *deal_id, client_id, from, piece_cid)
+            })
             .collect();
 
         ExpectInvocation {
@@ -82,7 +84,7 @@ impl Expect {
         from: ActorID,
         epoch: ChainEpoch,
         sectors: Vec<SectorNumber>,
-        deals: Vec<(DealID, ActorID)>,
+        deals: Vec<(DealID, ActorID, Cid)>,
     ) -> ExpectInvocation {
         let bf = BitField::try_from_bits(sectors).unwrap();
         let params =
@@ -91,8 +93,8 @@ impl Expect {
 
         let events: Vec<EmittedEvent> = deals
             .into_iter()
-            .map(|(deal_id, client)| {
-                Expect::build_market_event("deal-terminated", deal_id, client, from)
+            .map(|(deal_id, client, piece_cid)| {
+                Expect::build_market_event("deal-terminated", deal_id, client, from, &piece_cid)
             })
             .collect();
 
@@ -422,6 +424,7 @@ impl Expect {
         deal_id: DealID,
         client: ActorID,
         provider: ActorID,
+        piece_cid: &Cid,
     ) -> EmittedEvent {
         EmittedEvent {
             emitter: STORAGE_MARKET_ACTOR_ID,
@@ -430,6 +433,7 @@ impl Expect {
                 .field_indexed("id", &deal_id)
                 .field_indexed("client", &client)
                 .field_indexed("provider", &provider)
+                .field_indexed("piece-cid", piece_cid)
                 .build()
                 .unwrap(),
         }
@@ -27,7 +27,7 @@ use crate::expects::Expect;
 use crate::util::{
     advance_by_deadline_to_epoch, advance_by_deadline_to_epoch_while_proving,
     advance_by_deadline_to_index, advance_to_proving_deadline, bf_all, create_accounts,
-    create_miner, cron_tick, expect_invariants, invariant_failure_patterns,
+    create_miner, cron_tick, expect_invariants, get_deal, invariant_failure_patterns,
     make_piece_manifests_from_deal_ids, market_add_balance, market_pending_deal_allocations,
     market_publish_deal, miner_precommit_one_sector_v2, miner_prove_sector,
     override_compute_unsealed_sector_cid, precommit_meta_data_from_deals, sector_deadline,
@@ -689,7 +689,7 @@ pub fn extend_updated_sector_with_claims_test(v: &dyn VM) {
         subinvocs: Some(vec![
             Expect::market_activate_deals(
                 miner_id,
-                deal_ids,
+                deal_ids.iter().map(|id| (*id, get_deal(v, *id).piece_cid)).collect(),
                 verified_client.id().unwrap(),
                 sector_number,
                 initial_sector_info.expiration,
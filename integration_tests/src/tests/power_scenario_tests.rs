@@ -3,7 +3,7 @@ use fil_actor_init::Method as InitMethod;
 use fil_actor_miner::{
     max_prove_commit_duration, Method as MinerMethod, MinerConstructorParams, MIN_SECTOR_EXPIRATION,
 };
-use fil_actor_power::{CreateMinerParams, Method as PowerMethod};
+use fil_actor_power::{CreateMinerParams, Method as PowerMethod, State as PowerState};
 use fil_actors_runtime::runtime::Policy;
 
 use fil_actors_runtime::{
@@ -15,11 +15,12 @@ use fvm_ipld_encoding::BytesDe;
 use fvm_ipld_encoding::RawBytes;
 use fvm_shared::address::Address;
 use fvm_shared::econ::TokenAmount;
+use fvm_shared::error::ExitCode;
 use fvm_shared::sector::{RegisteredPoStProof, RegisteredSealProof};
 use fvm_shared::METHOD_SEND;
 use num_traits::Zero;
 use vm_api::trace::ExpectInvocation;
-use vm_api::util::{apply_ok, serialize_ok};
+use vm_api::util::{apply_code, apply_ok, get_state, serialize_ok};
 use vm_api::VM;
 
 use crate::expects::Expect;
@@ -103,6 +104,43 @@ pub fn power_create_miner_test(v: &dyn VM) {
     assert_invariants(v, &Policy::default(), None);
 }
 
+#[vm_test]
+pub fn power_create_miner_with_invalid_peer_info_test(v: &dyn VM) {
+    let owner = Address::new_bls(&[1; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    v.execute_message(
+        &TEST_FAUCET_ADDR,
+        &owner,
+        &TokenAmount::from_atto(10_000u32),
+        METHOD_SEND,
+        None,
+    )
+    .unwrap();
+    // An empty multiaddr is rejected by the miner constructor.
+    let params = CreateMinerParams {
+        owner,
+        worker: owner,
+        window_post_proof_type: RegisteredPoStProof::StackedDRGWindow32GiBV1P1,
+        peer: "miner".as_bytes().to_vec(),
+        multiaddrs: vec![BytesDe(vec![])],
+    };
+
+    apply_code(
+        v,
+        &owner,
+        &STORAGE_POWER_ACTOR_ADDR,
+        &TokenAmount::from_atto(1000u32),
+        PowerMethod::CreateMiner as u64,
+        Some(params),
+        ExitCode::USR_ILLEGAL_ARGUMENT,
+    );
+
+    // No miner actor was created, nor registered with the power actor.
+    assert!(v.actor(&Address::new_id(FIRST_TEST_USER_ADDR + 1)).is_none());
+    let power_st: PowerState = get_state(v, &STORAGE_POWER_ACTOR_ADDR).unwrap();
+    assert_eq!(0, power_st.miner_count);
+    assert_invariants(v, &Policy::default(), None);
+}
+
 #[vm_test]
 pub fn cron_tick_test(v: &dyn VM) {
     let addrs = create_accounts(v, 1, &TokenAmount::from_whole(10_000));
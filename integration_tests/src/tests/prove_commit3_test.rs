@@ -35,7 +35,7 @@ use crate::deals::{DealBatcher, DealOptions};
 use crate::expects::Expect;
 use crate::util::{
     advance_by_deadline_to_epoch, create_accounts, create_miner, datacap_create_allocations,
-    market_add_balance, market_list_deals, market_list_sectors_deals,
+    get_deal, market_add_balance, market_list_deals, market_list_sectors_deals,
     override_compute_unsealed_sector_cid, precommit_sectors_v2, sector_info, verifreg_add_client,
     verifreg_add_verifier, verifreg_list_claims, PrecommitMetadata,
 };
@@ -368,6 +368,7 @@ pub fn prove_commit_sectors2_test(v: &dyn VM) {
                                 *deal_id,
                                 client_id,
                                 miner_id,
+                                &get_deal(v, *deal_id).piece_cid,
                             )
                         })
                         .collect::<Vec<_>>(),
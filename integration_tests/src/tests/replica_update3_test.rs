@@ -36,7 +36,7 @@ use crate::deals::{DealBatcher, DealOptions};
 use crate::expects::Expect;
 use crate::util::{
     advance_by_deadline_to_epoch, advance_by_deadline_to_index, advance_to_proving_deadline,
-    create_accounts, create_miner, datacap_create_allocations, market_add_balance,
+    create_accounts, create_miner, datacap_create_allocations, get_deal, market_add_balance,
     market_list_deals, market_list_sectors_deals, override_compute_unsealed_sector_cid,
     precommit_sectors_v2, sector_info, submit_windowed_post, verifreg_add_client,
     verifreg_add_verifier, verifreg_list_claims, PrecommitMetadata,
@@ -430,6 +430,7 @@ pub fn prove_replica_update2_test(v: &dyn VM) {
                                 *deal_id,
                                 client_id,
                                 miner_id,
+                                &get_deal(v, *deal_id).piece_cid,
                             )
                         })
                         .collect::<Vec<_>>(),
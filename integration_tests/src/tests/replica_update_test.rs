@@ -35,11 +35,11 @@ use crate::expects::Expect;
 use crate::util::{
     advance_by_deadline_to_epoch, advance_by_deadline_to_index, advance_to_proving_deadline,
     assert_invariants, bf_all, check_sector_active, check_sector_faulty, create_accounts,
-    create_miner, cron_tick, deadline_state, declare_recovery, expect_invariants, get_deal_weights,
-    get_network_stats, invariant_failure_patterns, make_bitfield, market_publish_deal,
-    miner_balance, miner_power, miner_prove_sector, override_compute_unsealed_sector_cid,
-    precommit_sectors_v2, prove_commit_sectors, sector_info, submit_invalid_post,
-    submit_windowed_post, verifreg_add_client, verifreg_add_verifier,
+    create_miner, cron_tick, deadline_state, declare_recovery, expect_invariants, get_deal,
+    get_deal_weights, get_network_stats, invariant_failure_patterns, make_bitfield,
+    market_publish_deal, miner_balance, miner_power, miner_prove_sector,
+    override_compute_unsealed_sector_cid, precommit_sectors_v2, prove_commit_sectors, sector_info,
+    submit_invalid_post, submit_windowed_post, verifreg_add_client, verifreg_add_verifier,
 };
 
 #[vm_test]
@@ -1062,7 +1062,7 @@ pub fn replica_update_verified_deal_test(v: &dyn VM) {
         subinvocs: Some(vec![
             Expect::market_activate_deals(
                 miner_id,
-                deal_ids.clone(),
+                deal_ids.iter().map(|id| (*id, get_deal(v, *id).piece_cid)).collect(),
                 client.id().unwrap(),
                 sector_number,
                 old_sector_info.expiration,
@@ -1,5 +1,6 @@
 use std::ops::Neg;
 
+use cid::Cid;
 use fvm_shared::bigint::Zero;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::piece::PaddedPieceSize;
@@ -32,8 +33,8 @@ use crate::expects::Expect;
 use crate::util::{
     advance_by_deadline_to_epoch, advance_by_deadline_to_epoch_while_proving,
     advance_to_proving_deadline, assert_invariants, create_accounts, create_miner, cron_tick,
-    deal_cid_for_testing, make_bitfield, make_piece_manifests_from_deal_ids, market_publish_deal,
-    miner_balance, miner_precommit_one_sector_v2, miner_prove_sector,
+    deal_cid_for_testing, get_deal, make_bitfield, make_piece_manifests_from_deal_ids,
+    market_publish_deal, miner_balance, miner_precommit_one_sector_v2, miner_prove_sector,
     precommit_meta_data_from_deals, submit_windowed_post, verifreg_add_verifier,
 };
 
@@ -215,10 +216,10 @@ pub fn terminate_sectors_test(v: &dyn VM) {
     let epoch = v.epoch();
 
     let expect_event = Expect::build_miner_event("sector-terminated", miner_id, sector_number);
-    let deal_clients: Vec<(DealID, ActorID)> = vec![
-        (deal_ids[0], verified_client_id),
-        (deal_ids[1], verified_client_id),
-        (deal_ids[2], unverified_client.id().unwrap()),
+    let deal_clients: Vec<(DealID, ActorID, Cid)> = vec![
+        (deal_ids[0], verified_client_id, get_deal(v, deal_ids[0]).piece_cid),
+        (deal_ids[1], verified_client_id, get_deal(v, deal_ids[1]).piece_cid),
+        (deal_ids[2], unverified_client.id().unwrap(), get_deal(v, deal_ids[2]).piece_cid),
     ];
 
     // Terminate Sector
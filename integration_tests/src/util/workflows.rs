@@ -870,20 +870,13 @@ pub fn verifreg_add_client(
             params: Some(
                 IpldBlock::serialize_cbor(&MintParams {
                     to: *client,
-                    amount: allowance_tokens.clone(),
+                    amount: allowance_tokens,
                     operators: vec![STORAGE_MARKET_ACTOR_ADDR],
                 })
                 .unwrap(),
             ),
-            subinvocs: Some(vec![Expect::frc46_receiver(
-                DATACAP_TOKEN_ACTOR_ID,
-                *client,
-                DATACAP_TOKEN_ACTOR_ID,
-                client.id().unwrap(),
-                VERIFIED_REGISTRY_ACTOR_ID,
-                allowance_tokens,
-                None,
-            )]),
+            // Account clients are not notified of minted datacap.
+            subinvocs: Some(vec![]),
             ..Default::default()
         }]),
         events: Some(vec![verifier_balance_event_with_client(
@@ -1308,6 +1301,7 @@ pub fn market_publish_deal(
             ret.ids[0],
             deal_client.id().unwrap(),
             miner_id.id().unwrap(),
+            &proposal.piece_cid,
         )]),
         ..Default::default()
     }
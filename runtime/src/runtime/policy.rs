@@ -121,6 +121,9 @@ pub struct Policy {
     /// for permissioned actor methods and winning block elections.
     pub consensus_fault_ineligibility_duration: ChainEpoch,
 
+    /// Maximum age of a consensus fault that may be submitted in a batched fault report.
+    pub consensus_fault_reporting_window: ChainEpoch,
+
     /// The maximum number of new sectors that may be staged by a miner during a single proving period.
     pub new_sectors_per_period_max: usize,
 
@@ -208,6 +211,7 @@ impl Default for Policy {
             deal_limit_denominator: policy_constants::DEAL_LIMIT_DENOMINATOR,
             consensus_fault_ineligibility_duration:
                 policy_constants::CONSENSUS_FAULT_INELIGIBILITY_DURATION,
+            consensus_fault_reporting_window: policy_constants::CONSENSUS_FAULT_REPORTING_WINDOW,
             new_sectors_per_period_max: policy_constants::NEW_SECTORS_PER_PERIOD_MAX,
             chain_finality: policy_constants::CHAIN_FINALITY,
 
@@ -342,6 +346,8 @@ pub mod policy_constants {
 
     pub const CONSENSUS_FAULT_INELIGIBILITY_DURATION: ChainEpoch = CHAIN_FINALITY;
 
+    pub const CONSENSUS_FAULT_REPORTING_WINDOW: ChainEpoch = CHAIN_FINALITY;
+
     pub const NEW_SECTORS_PER_PERIOD_MAX: usize = 128 << 10;
 
     /// This is a conservative value that is chosen via simulations of all known attacks.
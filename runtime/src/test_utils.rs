@@ -197,7 +197,7 @@ pub struct Expectations {
     pub expect_verify_sigs: VecDeque<ExpectedVerifySig>,
     pub expect_verify_post: Option<ExpectVerifyPoSt>,
     pub expect_compute_unsealed_sector_cid: VecDeque<ExpectComputeUnsealedSectorCid>,
-    pub expect_verify_consensus_fault: Option<ExpectVerifyConsensusFault>,
+    pub expect_verify_consensus_fault: VecDeque<ExpectVerifyConsensusFault>,
     pub expect_get_randomness_tickets: VecDeque<ExpectRandomness>,
     pub expect_get_randomness_beacon: VecDeque<ExpectRandomness>,
     pub expect_batch_verify_seals: Option<ExpectBatchVerifySeals>,
@@ -271,7 +271,7 @@ impl Expectations {
             this.expect_compute_unsealed_sector_cid
         );
         assert!(
-            this.expect_verify_consensus_fault.is_none(),
+            this.expect_verify_consensus_fault.is_empty(),
             "expect_verify_consensus_fault {:?}, not received",
             this.expect_verify_consensus_fault
         );
@@ -592,15 +592,16 @@ impl MockRuntime {
         fault: Option<ConsensusFault>,
         exit_code: ExitCode,
     ) {
-        self.expectations.borrow_mut().expect_verify_consensus_fault =
-            Some(ExpectVerifyConsensusFault {
+        self.expectations.borrow_mut().expect_verify_consensus_fault.push_back(
+            ExpectVerifyConsensusFault {
                 require_correct_input: true,
                 block_header_1: h1,
                 block_header_2: h2,
                 block_header_extra: extra,
                 fault,
                 exit_code,
-            });
+            },
+        );
     }
 
     #[allow(dead_code)]
@@ -1442,7 +1443,7 @@ impl Primitives for MockRuntime {
             .expectations
             .borrow_mut()
             .expect_verify_consensus_fault
-            .take()
+            .pop_front()
             .expect("Unexpected syscall to verify_consensus_fault");
 
         if exp.require_correct_input {
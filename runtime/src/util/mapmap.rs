@@ -122,6 +122,27 @@ where
         in_map.for_each(f)
     }
 
+    // Runs a function over values for one outer key, starting at inner key `start` (if given)
+    // and visiting at most `limit` values (if given).
+    // Values are visited in a deterministic order that is not sorted by key.
+    // Returns the number of values visited and the inner key of the next value, if any.
+    pub fn for_each_in_ranged<F>(
+        &mut self,
+        outside_k: K1,
+        start: Option<K2>,
+        limit: Option<usize>,
+        f: F,
+    ) -> Result<(usize, Option<BytesKey>), Error>
+    where
+        F: FnMut(&BytesKey, &V) -> anyhow::Result<()>,
+    {
+        let (is_empty, in_map) = self.load_inner_map(outside_k)?;
+        if is_empty {
+            return Ok((0, None));
+        }
+        in_map.for_each_ranged(start.map(|k| k.key()).as_ref(), limit, f)
+    }
+
     // Puts a key value pair in the MapMap, overwriting any existing value.
     // Returns the previous value, if any.
     pub fn put(&mut self, outside_k: K1, inside_k: K2, value: V) -> Result<Option<V>, Error> {
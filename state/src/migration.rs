@@ -0,0 +1,536 @@
+//! Migration of actor state written by the previous version of the builtin actors.
+//!
+//! Fields added to an actor's state are appended to its tuple encoding, so state written by the
+//! previous actors can't be decoded by the current types. Each migration below decodes the
+//! previous layout of an actor's state and writes the current layout, with the new fields
+//! initialised as they would be for a newly constructed actor.
+
+use std::collections::BTreeMap;
+
+use anyhow::anyhow;
+use cid::multihash::Code;
+use cid::Cid;
+use fil_actor_evm::{BytecodeHash, State as EvmState, Tombstone};
+use fil_actor_market::{DealReassignmentsMap, State as MarketState, DEAL_REASSIGNMENTS_CONFIG};
+use fil_actor_miner::State as MinerState;
+use fil_actor_multisig::{
+    SignerLimitMap, State as MultisigState, TxnExpirationMap, TxnID, SIGNER_LIMITS_CONFIG,
+    TXN_EXPIRATIONS_CONFIG,
+};
+use fil_actor_paych::{State as PaychState, SETTLE_DELAY};
+use fil_actor_power::{PowerSnapshotArray, State as PowerState, POWER_SNAPSHOTS_AMT_BITWIDTH};
+use fil_actor_verifreg::state::{
+    ClientTermDefaultsMap, VerifierDelegatesMap, VerifierDelegatorsMap,
+    CLIENT_TERM_DEFAULTS_CONFIG, VERIFIER_DELEGATES_CONFIG, VERIFIER_DELEGATORS_CONFIG,
+};
+use fil_actor_verifreg::State as VerifregState;
+use fil_actors_runtime::builtin::reward::smooth::FilterEstimate;
+use fil_actors_runtime::runtime::builtins::Type;
+use fvm_ipld_bitfield::BitField;
+use fvm_ipld_blockstore::Blockstore;
+use fvm_ipld_encoding::tuple::*;
+use fvm_ipld_encoding::CborStore;
+use fvm_shared::address::Address;
+use fvm_shared::bigint::bigint_ser;
+use fvm_shared::clock::ChainEpoch;
+use fvm_shared::deal::DealID;
+use fvm_shared::econ::TokenAmount;
+use fvm_shared::sector::StoragePower;
+use vm_api::ActorState;
+
+/// Migrates the state of every actor in the tree whose state layout has changed.
+/// Actors of other types are left unchanged.
+pub fn migrate_state_tree<BS: Blockstore>(
+    store: &BS,
+    manifest: &BTreeMap<Cid, Type>,
+    tree: &mut BTreeMap<Address, ActorState>,
+) -> anyhow::Result<()> {
+    for (key, actor) in tree.iter_mut() {
+        let state = match manifest.get(&actor.code) {
+            Some(Type::EVM) => migrate_evm(store, &actor.state),
+            Some(Type::Market) => migrate_market(store, &actor.state),
+            Some(Type::Miner) => migrate_miner(store, &actor.state),
+            Some(Type::Multisig) => migrate_multisig(store, &actor.state),
+            Some(Type::PaymentChannel) => migrate_paych(store, &actor.state),
+            Some(Type::Power) => migrate_power(store, &actor.state),
+            Some(Type::VerifiedRegistry) => migrate_verifreg(store, &actor.state),
+            _ => continue,
+        };
+        actor.state = state.map_err(|e| anyhow!("failed to migrate {key}: {e}"))?;
+    }
+    Ok(())
+}
+
+// EVM state before transient storage was added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevEvmState {
+    bytecode: Cid,
+    bytecode_hash: BytecodeHash,
+    contract_state: Cid,
+    nonce: u64,
+    tombstone: Option<Tombstone>,
+}
+
+fn migrate_evm<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevEvmState = get_prev_state(store, head)?;
+    let state = EvmState {
+        bytecode: prev.bytecode,
+        bytecode_hash: prev.bytecode_hash,
+        contract_state: prev.contract_state,
+        nonce: prev.nonce,
+        tombstone: prev.tombstone,
+        transient_data: None,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Market state before deal reassignments were added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevMarketState {
+    proposals: Cid,
+    states: Cid,
+    pending_proposals: Cid,
+    escrow_table: Cid,
+    locked_table: Cid,
+    next_id: DealID,
+    deal_ops_by_epoch: Cid,
+    last_cron: ChainEpoch,
+    total_client_locked_collateral: TokenAmount,
+    total_provider_locked_collateral: TokenAmount,
+    total_client_storage_fee: TokenAmount,
+    pending_deal_allocation_ids: Cid,
+    provider_sectors: Cid,
+}
+
+fn migrate_market<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevMarketState = get_prev_state(store, head)?;
+    let pending_deal_reassignments =
+        DealReassignmentsMap::flush_empty(store, DEAL_REASSIGNMENTS_CONFIG)?;
+    let state = MarketState {
+        proposals: prev.proposals,
+        states: prev.states,
+        pending_proposals: prev.pending_proposals,
+        escrow_table: prev.escrow_table,
+        locked_table: prev.locked_table,
+        next_id: prev.next_id,
+        deal_ops_by_epoch: prev.deal_ops_by_epoch,
+        last_cron: prev.last_cron,
+        total_client_locked_collateral: prev.total_client_locked_collateral,
+        total_provider_locked_collateral: prev.total_provider_locked_collateral,
+        total_client_storage_fee: prev.total_client_storage_fee,
+        pending_deal_allocation_ids: prev.pending_deal_allocation_ids,
+        provider_sectors: prev.provider_sectors,
+        pending_deal_reassignments,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Miner state before the fault fee escrow was added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevMinerState {
+    info: Cid,
+    pre_commit_deposits: TokenAmount,
+    locked_funds: TokenAmount,
+    vesting_funds: Cid,
+    fee_debt: TokenAmount,
+    initial_pledge: TokenAmount,
+    pre_committed_sectors: Cid,
+    pre_committed_sectors_cleanup: Cid,
+    allocated_sectors: Cid,
+    sectors: Cid,
+    proving_period_start: ChainEpoch,
+    current_deadline: u64,
+    deadlines: Cid,
+    early_terminations: BitField,
+    deadline_cron_active: bool,
+}
+
+fn migrate_miner<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevMinerState = get_prev_state(store, head)?;
+    let state = MinerState {
+        info: prev.info,
+        pre_commit_deposits: prev.pre_commit_deposits,
+        locked_funds: prev.locked_funds,
+        vesting_funds: prev.vesting_funds,
+        fee_debt: prev.fee_debt,
+        initial_pledge: prev.initial_pledge,
+        pre_committed_sectors: prev.pre_committed_sectors,
+        pre_committed_sectors_cleanup: prev.pre_committed_sectors_cleanup,
+        allocated_sectors: prev.allocated_sectors,
+        sectors: prev.sectors,
+        proving_period_start: prev.proving_period_start,
+        current_deadline: prev.current_deadline,
+        deadlines: prev.deadlines,
+        early_terminations: prev.early_terminations,
+        deadline_cron_active: prev.deadline_cron_active,
+        fault_fee_escrow: TokenAmount::default(),
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Multisig state before signer limits and transaction expirations were added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevMultisigState {
+    signers: Vec<Address>,
+    num_approvals_threshold: u64,
+    next_tx_id: TxnID,
+    initial_balance: TokenAmount,
+    start_epoch: ChainEpoch,
+    unlock_duration: ChainEpoch,
+    pending_txs: Cid,
+}
+
+fn migrate_multisig<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevMultisigState = get_prev_state(store, head)?;
+    let signer_limits = SignerLimitMap::flush_empty(store, SIGNER_LIMITS_CONFIG)?;
+    let txn_expirations = TxnExpirationMap::flush_empty(store, TXN_EXPIRATIONS_CONFIG)?;
+    let state = MultisigState {
+        signers: prev.signers,
+        num_approvals_threshold: prev.num_approvals_threshold,
+        next_tx_id: prev.next_tx_id,
+        initial_balance: prev.initial_balance,
+        start_epoch: prev.start_epoch,
+        unlock_duration: prev.unlock_duration,
+        pending_txs: prev.pending_txs,
+        signer_limits,
+        txn_expirations,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Payment channel state before settlement extensions were added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevPaychState {
+    from: Address,
+    to: Address,
+    to_send: TokenAmount,
+    settling_at: ChainEpoch,
+    min_settle_height: ChainEpoch,
+    lane_states: Cid,
+}
+
+fn migrate_paych<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevPaychState = get_prev_state(store, head)?;
+    // A channel that is already settling gets the limit that Settle would have set,
+    // since its settlement can't have been extended yet.
+    let settle_extension_limit =
+        if prev.settling_at != 0 { prev.settling_at + SETTLE_DELAY } else { 0 };
+    let state = PaychState {
+        from: prev.from,
+        to: prev.to,
+        to_send: prev.to_send,
+        settling_at: prev.settling_at,
+        min_settle_height: prev.min_settle_height,
+        lane_states: prev.lane_states,
+        settle_extension_limit,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Power state before network power snapshots were added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevPowerState {
+    #[serde(with = "bigint_ser")]
+    total_raw_byte_power: StoragePower,
+    #[serde(with = "bigint_ser")]
+    total_bytes_committed: StoragePower,
+    #[serde(with = "bigint_ser")]
+    total_quality_adj_power: StoragePower,
+    #[serde(with = "bigint_ser")]
+    total_qa_bytes_committed: StoragePower,
+    total_pledge_collateral: TokenAmount,
+    #[serde(with = "bigint_ser")]
+    this_epoch_raw_byte_power: StoragePower,
+    #[serde(with = "bigint_ser")]
+    this_epoch_quality_adj_power: StoragePower,
+    this_epoch_pledge_collateral: TokenAmount,
+    this_epoch_qa_power_smoothed: FilterEstimate,
+    miner_count: i64,
+    miner_above_min_power_count: i64,
+    cron_event_queue: Cid,
+    first_cron_epoch: ChainEpoch,
+    claims: Cid,
+    proof_validation_batch: Option<Cid>,
+}
+
+fn migrate_power<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevPowerState = get_prev_state(store, head)?;
+    let power_snapshots =
+        PowerSnapshotArray::<BS>::new_with_bit_width(store, POWER_SNAPSHOTS_AMT_BITWIDTH)
+            .flush()?;
+    let state = PowerState {
+        total_raw_byte_power: prev.total_raw_byte_power,
+        total_bytes_committed: prev.total_bytes_committed,
+        total_quality_adj_power: prev.total_quality_adj_power,
+        total_qa_bytes_committed: prev.total_qa_bytes_committed,
+        total_pledge_collateral: prev.total_pledge_collateral,
+        this_epoch_raw_byte_power: prev.this_epoch_raw_byte_power,
+        this_epoch_quality_adj_power: prev.this_epoch_quality_adj_power,
+        this_epoch_pledge_collateral: prev.this_epoch_pledge_collateral,
+        this_epoch_qa_power_smoothed: prev.this_epoch_qa_power_smoothed,
+        miner_count: prev.miner_count,
+        miner_above_min_power_count: prev.miner_above_min_power_count,
+        cron_event_queue: prev.cron_event_queue,
+        first_cron_epoch: prev.first_cron_epoch,
+        claims: prev.claims,
+        proof_validation_batch: prev.proof_validation_batch,
+        power_snapshots,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+// Verified registry state before client term defaults and allowance delegation were added.
+#[derive(Serialize_tuple, Deserialize_tuple)]
+struct PrevVerifregState {
+    root_key: Address,
+    verifiers: Cid,
+    remove_data_cap_proposal_ids: Cid,
+    allocations: Cid,
+    next_allocation_id: u64,
+    claims: Cid,
+}
+
+fn migrate_verifreg<BS: Blockstore>(store: &BS, head: &Cid) -> anyhow::Result<Cid> {
+    let prev: PrevVerifregState = get_prev_state(store, head)?;
+    let client_term_defaults =
+        ClientTermDefaultsMap::flush_empty(store, CLIENT_TERM_DEFAULTS_CONFIG)?;
+    let verifier_delegators =
+        VerifierDelegatorsMap::flush_empty(store, VERIFIER_DELEGATORS_CONFIG)?;
+    let verifier_delegates =
+        VerifierDelegatesMap::empty(store, VERIFIER_DELEGATES_CONFIG, "verifier delegates")
+            .flush()?;
+    let state = VerifregState {
+        root_key: prev.root_key,
+        verifiers: prev.verifiers,
+        remove_data_cap_proposal_ids: prev.remove_data_cap_proposal_ids,
+        allocations: prev.allocations,
+        next_allocation_id: prev.next_allocation_id,
+        claims: prev.claims,
+        client_term_defaults,
+        verifier_delegators,
+        verifier_delegates,
+    };
+    Ok(store.put_cbor(&state, Code::Blake2b256)?)
+}
+
+fn get_prev_state<BS: Blockstore, T: serde::de::DeserializeOwned>(
+    store: &BS,
+    head: &Cid,
+) -> anyhow::Result<T> {
+    store.get_cbor::<T>(head)?.ok_or_else(|| anyhow!("state {head} not found"))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use fil_actor_multisig::{PendingTxnMap, PENDING_TXN_CONFIG};
+    use fil_actor_power::{ClaimsMap, CLAIMS_CONFIG};
+    use fil_actor_verifreg::state::{DataCapMap, DATACAP_MAP_CONFIG};
+    use fil_actors_runtime::test_utils::{
+        EVM_ACTOR_CODE_ID, MARKET_ACTOR_CODE_ID, MINER_ACTOR_CODE_ID, MULTISIG_ACTOR_CODE_ID,
+        PAYCH_ACTOR_CODE_ID, POWER_ACTOR_CODE_ID, VERIFREG_ACTOR_CODE_ID,
+    };
+    use fvm_ipld_blockstore::MemoryBlockstore;
+    use num_traits::Zero;
+    use vm_api::new_actor;
+
+    // Migrates a tree holding a single actor with the given previous state, returning the
+    // actor's new state root.
+    fn migrate_one<BS: Blockstore, T: serde::Serialize>(
+        store: &BS,
+        code: Cid,
+        typ: Type,
+        prev: &T,
+    ) -> Cid {
+        let head = store.put_cbor(prev, Code::Blake2b256).unwrap();
+        let manifest = BTreeMap::from([(code, typ)]);
+        let addr = Address::new_id(100);
+        let mut tree =
+            BTreeMap::from([(addr, new_actor(code, head, 0, TokenAmount::zero(), None))]);
+        migrate_state_tree(store, &manifest, &mut tree).unwrap();
+        tree[&addr].state
+    }
+
+    #[test]
+    fn migrates_evm() {
+        let store = MemoryBlockstore::new();
+        let prev = PrevEvmState {
+            bytecode: Cid::default(),
+            bytecode_hash: BytecodeHash::EMPTY,
+            contract_state: Cid::default(),
+            nonce: 3,
+            tombstone: Some(Tombstone { origin: 100, nonce: 2 }),
+        };
+        let head = migrate_one(&store, *EVM_ACTOR_CODE_ID, Type::EVM, &prev);
+
+        let st: EvmState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.bytecode_hash, st.bytecode_hash);
+        assert_eq!(prev.nonce, st.nonce);
+        assert_eq!(prev.tombstone, st.tombstone);
+        assert_eq!(None, st.transient_data);
+    }
+
+    #[test]
+    fn migrates_market() {
+        let store = MemoryBlockstore::new();
+        let root = Cid::default();
+        let prev = PrevMarketState {
+            proposals: root,
+            states: root,
+            pending_proposals: root,
+            escrow_table: root,
+            locked_table: root,
+            next_id: 7,
+            deal_ops_by_epoch: root,
+            last_cron: 100,
+            total_client_locked_collateral: TokenAmount::from_atto(1),
+            total_provider_locked_collateral: TokenAmount::from_atto(2),
+            total_client_storage_fee: TokenAmount::from_atto(3),
+            pending_deal_allocation_ids: root,
+            provider_sectors: root,
+        };
+        let head = migrate_one(&store, *MARKET_ACTOR_CODE_ID, Type::Market, &prev);
+
+        let st: MarketState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.next_id, st.next_id);
+        assert_eq!(prev.last_cron, st.last_cron);
+        assert_eq!(prev.total_client_storage_fee, st.total_client_storage_fee);
+        assert!(st.load_pending_deal_reassignments(&store).unwrap().is_empty());
+    }
+
+    #[test]
+    fn migrates_miner() {
+        let store = MemoryBlockstore::new();
+        let root = Cid::default();
+        let prev = PrevMinerState {
+            info: root,
+            pre_commit_deposits: TokenAmount::from_atto(1),
+            locked_funds: TokenAmount::from_atto(2),
+            vesting_funds: root,
+            fee_debt: TokenAmount::from_atto(3),
+            initial_pledge: TokenAmount::from_atto(4),
+            pre_committed_sectors: root,
+            pre_committed_sectors_cleanup: root,
+            allocated_sectors: root,
+            sectors: root,
+            proving_period_start: 100,
+            current_deadline: 2,
+            deadlines: root,
+            early_terminations: BitField::try_from_bits([1]).unwrap(),
+            deadline_cron_active: true,
+        };
+        let head = migrate_one(&store, *MINER_ACTOR_CODE_ID, Type::Miner, &prev);
+
+        let st: MinerState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.fee_debt, st.fee_debt);
+        assert_eq!(prev.initial_pledge, st.initial_pledge);
+        assert_eq!(prev.current_deadline, st.current_deadline);
+        assert_eq!(prev.early_terminations, st.early_terminations);
+        assert!(st.deadline_cron_active);
+        assert!(st.fault_fee_escrow.is_zero());
+    }
+
+    #[test]
+    fn migrates_multisig() {
+        let store = MemoryBlockstore::new();
+        let pending_txs = PendingTxnMap::flush_empty(&store, PENDING_TXN_CONFIG).unwrap();
+        let prev = PrevMultisigState {
+            signers: vec![Address::new_id(101), Address::new_id(102)],
+            num_approvals_threshold: 2,
+            next_tx_id: TxnID(3),
+            initial_balance: TokenAmount::from_atto(100),
+            start_epoch: 10,
+            unlock_duration: 20,
+            pending_txs,
+        };
+        let head = migrate_one(&store, *MULTISIG_ACTOR_CODE_ID, Type::Multisig, &prev);
+
+        let st: MultisigState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.signers, st.signers);
+        assert_eq!(prev.next_tx_id, st.next_tx_id);
+        assert_eq!(prev.pending_txs, st.pending_txs);
+        assert!(st.load_signer_limits(&store).unwrap().is_empty());
+        assert!(st.load_txn_expirations(&store).unwrap().is_empty());
+    }
+
+    #[test]
+    fn migrates_paych() {
+        let store = MemoryBlockstore::new();
+        let prev = |settling_at| PrevPaychState {
+            from: Address::new_id(101),
+            to: Address::new_id(102),
+            to_send: TokenAmount::from_atto(10),
+            settling_at,
+            min_settle_height: 0,
+            lane_states: Cid::default(),
+        };
+
+        // An open channel has no extension limit.
+        let head = migrate_one(&store, *PAYCH_ACTOR_CODE_ID, Type::PaymentChannel, &prev(0));
+        let st: PaychState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev(0).to_send, st.to_send);
+        assert_eq!(0, st.settle_extension_limit);
+
+        // A settling channel may be extended as if it had just settled.
+        let head = migrate_one(&store, *PAYCH_ACTOR_CODE_ID, Type::PaymentChannel, &prev(100));
+        let st: PaychState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(100, st.settling_at);
+        assert_eq!(100 + SETTLE_DELAY, st.settle_extension_limit);
+    }
+
+    #[test]
+    fn migrates_power() {
+        let store = MemoryBlockstore::new();
+        let claims = ClaimsMap::flush_empty(&store, CLAIMS_CONFIG).unwrap();
+        let prev = PrevPowerState {
+            total_raw_byte_power: StoragePower::from(1u64 << 30),
+            total_bytes_committed: StoragePower::from(1u64 << 30),
+            total_quality_adj_power: StoragePower::from(10u64 << 30),
+            total_qa_bytes_committed: StoragePower::from(10u64 << 30),
+            total_pledge_collateral: TokenAmount::from_atto(100),
+            this_epoch_raw_byte_power: StoragePower::from(1u64 << 30),
+            this_epoch_quality_adj_power: StoragePower::from(10u64 << 30),
+            this_epoch_pledge_collateral: TokenAmount::from_atto(100),
+            this_epoch_qa_power_smoothed: FilterEstimate::new(
+                StoragePower::from(10u64 << 30),
+                StoragePower::zero(),
+            ),
+            miner_count: 1,
+            miner_above_min_power_count: 1,
+            cron_event_queue: claims,
+            first_cron_epoch: 10,
+            claims,
+            proof_validation_batch: None,
+        };
+        let head = migrate_one(&store, *POWER_ACTOR_CODE_ID, Type::Power, &prev);
+
+        let st: PowerState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.total_quality_adj_power, st.total_quality_adj_power);
+        assert_eq!(prev.miner_count, st.miner_count);
+        assert_eq!(prev.claims, st.claims);
+        assert_eq!(0, st.load_power_snapshots(&store).unwrap().count());
+    }
+
+    #[test]
+    fn migrates_verifreg() {
+        let store = MemoryBlockstore::new();
+        let verifiers = DataCapMap::flush_empty(&store, DATACAP_MAP_CONFIG).unwrap();
+        let prev = PrevVerifregState {
+            root_key: Address::new_id(80),
+            verifiers,
+            remove_data_cap_proposal_ids: verifiers,
+            allocations: verifiers,
+            next_allocation_id: 5,
+            claims: verifiers,
+        };
+        let head = migrate_one(&store, *VERIFREG_ACTOR_CODE_ID, Type::VerifiedRegistry, &prev);
+
+        let st: VerifregState = store.get_cbor(&head).unwrap().unwrap();
+        assert_eq!(prev.root_key, st.root_key);
+        assert_eq!(prev.next_allocation_id, st.next_allocation_id);
+        assert_eq!(prev.claims, st.claims);
+        assert!(st.load_client_term_defaults(&store).unwrap().is_empty());
+        assert!(st.load_verifier_delegators(&store).unwrap().is_empty());
+        assert!(st.get_verifier_delegates(&store, 101).unwrap().is_empty());
+    }
+}
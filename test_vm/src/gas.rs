@@ -0,0 +1,46 @@
+use fil_actors_runtime::test_blockstores::BSStats;
+
+/// A deterministic gas model for the test VM.
+///
+/// Gas is charged per invocation, per byte of parameters, for each explicit `charge_gas` by an
+/// actor, and for blockstore traffic. Blockstore traffic includes the test VM's own state tree
+/// accesses, so the totals don't match FVM gas. They are stable across runs, though, so can pin
+/// bounds on the relative cost of actor operations in regression tests.
+#[derive(Clone, Debug, PartialEq, Eq)]
+pub struct GasModel {
+    pub invocation: u64,
+    pub param_byte: u64,
+    pub block_read: u64,
+    pub block_read_byte: u64,
+    pub block_write: u64,
+    pub block_write_byte: u64,
+}
+
+impl Default for GasModel {
+    // The prices are arbitrary but roughly proportioned after the FVM price list.
+    fn default() -> Self {
+        Self {
+            invocation: 75_000,
+            param_byte: 10,
+            block_read: 100_000,
+            block_read_byte: 10,
+            block_write: 200_000,
+            block_write_byte: 100,
+        }
+    }
+}
+
+impl GasModel {
+    /// Gas charged for invoking a method with parameters of the given length.
+    pub fn invocation_gas(&self, params_len: usize) -> u64 {
+        self.invocation + self.param_byte * params_len as u64
+    }
+
+    /// Gas charged for the cumulative blockstore traffic recorded in stats.
+    pub fn storage_gas(&self, stats: &BSStats) -> u64 {
+        self.block_read * stats.r as u64
+            + self.block_read_byte * stats.br as u64
+            + self.block_write * stats.w as u64
+            + self.block_write_byte * stats.bw as u64
+    }
+}
@@ -43,6 +43,8 @@ use vm_api::util::{get_state, serialize_ok};
 
 mod constants;
 pub use constants::*;
+mod gas;
+pub use gas::*;
 mod messaging;
 pub use messaging::*;
 
@@ -54,6 +56,9 @@ pub struct TestVM {
     actors_dirty: RefCell<bool>,
     actors_cache: RefCell<HashMap<Address, ActorState>>,
     invocations: RefCell<Vec<InvocationTrace>>,
+    // Gas accounting is disabled unless a model is set.
+    gas_model: RefCell<Option<GasModel>>,
+    gas_charged: RefCell<u64>,
     // MachineContext equivalents
     network_version: NetworkVersion,
     curr_epoch: RefCell<ChainEpoch>,
@@ -81,6 +86,8 @@ impl TestVM {
             network_version: NetworkVersion::V16,
             curr_epoch: RefCell::new(ChainEpoch::zero()),
             invocations: RefCell::new(vec![]),
+            gas_model: RefCell::new(None),
+            gas_charged: RefCell::new(0),
             base_fee: RefCell::new(TokenAmount::zero()),
             timestamp: RefCell::new(0),
         }
@@ -266,6 +273,27 @@ impl TestVM {
         self.actors_dirty.replace(false);
     }
 
+    /// Sets the gas model used to compute the gas used by each invocation in traces.
+    /// Gas accounting is disabled when the model is None, as it is by default.
+    pub fn set_gas_model(&self, model: Option<GasModel>) {
+        self.gas_model.replace(model);
+    }
+
+    /// Returns the total gas used since the VM was created, or None if gas accounting is disabled.
+    pub fn gas_used(&self) -> Option<u64> {
+        self.gas_model
+            .borrow()
+            .as_ref()
+            .map(|m| *self.gas_charged.borrow() + m.storage_gas(&self.store.stats.borrow()))
+    }
+
+    /// Charges gas computed by the model, if gas accounting is enabled.
+    pub(crate) fn charge_gas(&self, f: impl FnOnce(&GasModel) -> u64) {
+        if let Some(m) = self.gas_model.borrow().as_ref() {
+            *self.gas_charged.borrow_mut() += f(m);
+        }
+    }
+
     fn actor_map(&self) -> Map2<&MemoryBlockstore, Address, ActorState> {
         Map2::load(self.store.as_ref(), &self.checkpoint(), DEFAULT_HAMT_CONFIG, "actors").unwrap()
     }
@@ -325,6 +353,7 @@ impl VM for TestVM {
             policy: &Policy::default(),
             subinvocations: RefCell::new(vec![]),
             events: RefCell::new(vec![]),
+            gas_start: None,
         };
         let res = new_ctx.invoke();
 
@@ -114,6 +114,8 @@ pub struct InvocationCtx<'invocation> {
     pub policy: &'invocation Policy,
     pub subinvocations: RefCell<Vec<InvocationTrace>>,
     pub events: RefCell<Vec<EmittedEvent>>,
+    /// The VM's gas used when this invocation started, if gas accounting is enabled.
+    pub gas_start: Option<u64>,
 }
 
 impl<'invocation> InvocationCtx<'invocation> {
@@ -179,6 +181,7 @@ impl<'invocation> InvocationCtx<'invocation> {
                 policy: self.policy,
                 subinvocations: RefCell::new(vec![]),
                 events: RefCell::new(vec![]),
+                gas_start: None,
             };
             if is_account {
                 new_ctx.create_actor(*ACCOUNT_ACTOR_CODE_ID, target_id, None).unwrap();
@@ -209,6 +212,7 @@ impl<'invocation> InvocationCtx<'invocation> {
             Ok((_, addr)) => addr, // use normalized address in trace
             _ => self.msg.to, // if target resolution fails don't fail whole invoke, just use non normalized
         };
+        let gas_used = self.v.gas_used().zip(self.gas_start).map(|(end, start)| end - start);
         InvocationTrace {
             from: msg.from,
             to: msg.to,
@@ -221,6 +225,7 @@ impl<'invocation> InvocationCtx<'invocation> {
             exit_code: code,
             subinvocations: self.subinvocations.take(),
             events: self.events.take(),
+            gas_used,
         }
     }
 
@@ -229,6 +234,9 @@ impl<'invocation> InvocationCtx<'invocation> {
     }
 
     pub fn invoke(&mut self) -> Result<Option<IpldBlock>, ActorError> {
+        self.gas_start = self.v.gas_used();
+        let params_len = self.msg.params.as_ref().map_or(0, |p| p.data.len());
+        self.v.charge_gas(|m| m.invocation_gas(params_len));
         let prior_root = self.v.checkpoint();
 
         // Transfer funds
@@ -517,6 +525,7 @@ impl<'invocation> Runtime for InvocationCtx<'invocation> {
             policy: self.policy,
             subinvocations: RefCell::new(vec![]),
             events: RefCell::new(vec![]),
+            gas_start: None,
         };
         let res = new_ctx.invoke();
         let invoc = new_ctx.gather_trace(res.clone());
@@ -619,7 +628,9 @@ impl<'invocation> Runtime for InvocationCtx<'invocation> {
         self.top.circ_supply.clone()
     }
 
-    fn charge_gas(&self, _name: &'static str, _compute: i64) {}
+    fn charge_gas(&self, _name: &'static str, compute: i64) {
+        self.v.charge_gas(|_| compute.max(0) as u64);
+    }
 
     fn base_fee(&self) -> TokenAmount {
         TokenAmount::zero()
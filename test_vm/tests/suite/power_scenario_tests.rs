@@ -1,4 +1,6 @@
-use fil_actors_integration_tests::tests::{cron_tick_test, power_create_miner_test};
+use fil_actors_integration_tests::tests::{
+    cron_tick_test, power_create_miner_test, power_create_miner_with_invalid_peer_info_test,
+};
 use fil_actors_runtime::test_blockstores::MemoryBlockstore;
 use test_vm::TestVM;
 
@@ -10,6 +12,14 @@ fn power_create_miner() {
     power_create_miner_test(&v);
 }
 
+#[test]
+fn power_create_miner_with_invalid_peer_info() {
+    let store = MemoryBlockstore::new();
+    let v = TestVM::new_with_singletons(store);
+
+    power_create_miner_with_invalid_peer_info_test(&v);
+}
+
 #[test]
 fn cron_tick() {
     let store = MemoryBlockstore::new();
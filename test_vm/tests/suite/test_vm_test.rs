@@ -5,12 +5,14 @@ use fil_actors_runtime::test_blockstores::MemoryBlockstore;
 use fil_actors_runtime::test_utils::{
     make_identity_cid, ACCOUNT_ACTOR_CODE_ID, PAYCH_ACTOR_CODE_ID,
 };
+use fil_actors_runtime::SYSTEM_ACTOR_ID;
 use fvm_shared::address::Address;
 use fvm_shared::econ::TokenAmount;
 use fvm_shared::error::ExitCode;
-use fvm_shared::METHOD_SEND;
+use fvm_shared::{METHOD_CONSTRUCTOR, METHOD_SEND};
 use num_traits::Zero;
-use test_vm::{TestVM, FIRST_TEST_USER_ADDR, TEST_FAUCET_ADDR};
+use test_vm::{GasModel, TestVM, FIRST_TEST_USER_ADDR, TEST_FAUCET_ADDR};
+use vm_api::trace::ExpectInvocation;
 use vm_api::util::{get_state, pk_addrs_from};
 use vm_api::{new_actor, VM};
 
@@ -121,6 +123,75 @@ fn test_sent() {
     assert_invariants(&v, &Policy::default(), None)
 }
 
+#[test]
+fn test_gas_accounting() {
+    let send_to_new_account = |model: Option<GasModel>| {
+        let v = TestVM::new_with_singletons(MemoryBlockstore::new());
+        v.set_gas_model(model);
+        v.take_invocations();
+        let addr = Address::new_bls(&[1; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+        v.execute_message(
+            &TEST_FAUCET_ADDR,
+            &addr,
+            &TokenAmount::from_atto(42u8),
+            METHOD_SEND,
+            None,
+        )
+        .unwrap();
+        v.take_invocations().pop().unwrap()
+    };
+
+    // gas accounting is disabled by default
+    let trace = send_to_new_account(None);
+    assert_eq!(None, trace.gas_used);
+    assert_eq!(None, trace.subinvocations[0].gas_used);
+
+    // gas used is stable across runs and includes subinvocations
+    let trace = send_to_new_account(Some(GasModel::default()));
+    let gas_used = trace.gas_used.unwrap();
+    assert_eq!(Some(gas_used), send_to_new_account(Some(GasModel::default())).gas_used);
+    let ctor_gas_used = trace.subinvocations[0].gas_used.unwrap();
+    assert!(ctor_gas_used > 0);
+    assert!(ctor_gas_used < gas_used);
+
+    let faucet_id = TEST_FAUCET_ADDR.id().unwrap();
+    let new_account = Address::new_id(FIRST_TEST_USER_ADDR);
+    ExpectInvocation {
+        from: faucet_id,
+        to: new_account,
+        method: METHOD_SEND,
+        gas_used_within: Some((gas_used, gas_used)),
+        subinvocs: Some(vec![ExpectInvocation {
+            from: SYSTEM_ACTOR_ID,
+            to: new_account,
+            method: METHOD_CONSTRUCTOR,
+            gas_used_within: Some((1, gas_used - 1)),
+            ..Default::default()
+        }]),
+        ..Default::default()
+    }
+    .matches(&trace);
+}
+
+#[test]
+#[should_panic(expected = "unexpected gas used")]
+fn test_gas_used_out_of_range() {
+    let v = TestVM::new_with_singletons(MemoryBlockstore::new());
+    v.set_gas_model(Some(GasModel::default()));
+    v.take_invocations();
+    let addr = Address::new_bls(&[1; fvm_shared::address::BLS_PUB_LEN]).unwrap();
+    v.execute_message(&TEST_FAUCET_ADDR, &addr, &TokenAmount::from_atto(42u8), METHOD_SEND, None)
+        .unwrap();
+    ExpectInvocation {
+        from: TEST_FAUCET_ADDR.id().unwrap(),
+        to: Address::new_id(FIRST_TEST_USER_ADDR),
+        method: METHOD_SEND,
+        gas_used_within: Some((0, 1)),
+        ..Default::default()
+    }
+    .matches(&v.take_invocations()[0]);
+}
+
 #[test]
 fn test_pk_gen() {
     let addrs = pk_addrs_from(5, 2);
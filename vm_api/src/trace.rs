@@ -28,6 +28,8 @@ pub struct InvocationTrace {
     pub return_value: ReturnValue,
     pub subinvocations: Vec<InvocationTrace>,
     pub events: Vec<EmittedEvent>,
+    /// gas_used is set when the VM accounts gas, and includes gas used by subinvocations
+    pub gas_used: Option<u64>,
 }
 
 /// An expectation for a method invocation trace.
@@ -53,6 +55,8 @@ pub struct ExpectInvocation {
     pub return_value: Option<ReturnValue>,
     pub subinvocs: Option<Vec<ExpectInvocation>>,
     pub events: Option<Vec<EmittedEvent>>,
+    /// Inclusive bounds on gas used, including subinvocations. The VM must account gas.
+    pub gas_used_within: Option<(u64, u64)>,
 }
 
 impl ExpectInvocation {
@@ -115,6 +119,20 @@ impl ExpectInvocation {
             );
         }
 
+        if let Some((min, max)) = self.gas_used_within {
+            let gas_used = invoc.gas_used.unwrap_or_else(|| {
+                panic!("{} expected gas used but VM gas accounting is disabled", id)
+            });
+            assert!(
+                (min..=max).contains(&gas_used),
+                "{} unexpected gas used: expected within [{}, {}], was: {}",
+                id,
+                min,
+                max,
+                gas_used
+            );
+        }
+
         // match emitted events
         if let Some(expected_events) = &self.events {
             let emitted_events = &invoc.events;
@@ -209,6 +227,7 @@ impl Default for ExpectInvocation {
             return_value: None,
             subinvocs: None,
             events: None,
+            gas_used_within: None,
         }
     }
 }